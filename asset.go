@@ -0,0 +1,386 @@
+package vingo
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -------------------- Asset helpers --------------------
+//
+//	<{ asset("img/logo.svg") }>             -> /static/img/logo.svg (Hash: /static/img/logo.3f9a1c2e.svg)
+//	<{ integrity("js/app.js") }>            -> sha384-...
+//	<{ script("js/app.js", defer=true) }>   -> <script src=... integrity=... crossorigin=...></script>
+//	<{ stylesheet("css/app.css") }>         -> <link rel="stylesheet" href=... ...>
+//
+// With Hash (or a bundler Manifest) asset URLs change whenever the file
+// does, so they can be cached forever; AssetHandler serves them:
+//
+//	e.Assets = vingo.AssetOptions{Dir: "static", URLPrefix: "/static/", Hash: true}
+//	http.Handle("/static/", http.StripPrefix("/static/", vingo.AssetHandler(e)))
+
+// AssetOptions: configuration of the asset helpers.
+type AssetOptions struct {
+	Dir       string // directory asset files are read from (for hashing)
+	URLPrefix string // prepended to asset paths, e.g. "/static/"
+
+	// Integrity: script()/stylesheet() emit an SRI integrity attribute
+	// computed from the file, plus crossorigin. Per call: integrity=false.
+	Integrity   bool
+	CrossOrigin string // crossorigin value sent with integrity, default "anonymous"
+
+	// Hash: asset URLs get a hash of the file content in the file name,
+	// css/app.css -> css/app.3f9a1c2e.css.
+	Hash bool
+
+	// Manifest: JSON file mapping asset paths to the names a bundler built
+	// them as, {"css/app.css": "css/app.3f9a1c.css"} or the Vite form
+	// {"css/app.css": {"file": "css/app.3f9a1c.css"}}; relative to Dir.
+	// Assets missing from it fall back to Hash.
+	Manifest string
+}
+
+func init() {
+	builtinFuncs["asset"] = assetFunc
+	builtinFuncs["integrity"] = integrityFunc
+	builtinFuncs["script"] = scriptFunc
+	builtinFuncs["stylesheet"] = stylesheetFunc
+}
+
+// assetCache: hashes per file and the parsed manifest, recomputed when the
+// files change.
+type assetCache struct {
+	mu       sync.Mutex
+	hashes   map[string]assetHash
+	manifest assetManifest
+}
+
+type assetHash struct {
+	mod   time.Time
+	sri   string
+	short string // hex prefix of the same hash, for file names
+}
+
+type assetManifest struct {
+	path  string
+	mod   time.Time
+	files map[string]string // asset path -> built path
+	built map[string]bool   // built paths
+}
+
+// assetHashLen: hex digits of the content hash in hashed file names.
+const assetHashLen = 8
+
+// hash: hashes of the asset file name (a path under Dir).
+func (e *Engine) hash(name string) (assetHash, error) {
+	p := filepath.Join(e.Assets.Dir, filepath.FromSlash(strings.TrimPrefix(name, "/")))
+	st, err := os.Stat(p)
+	if err != nil {
+		return assetHash{}, err
+	}
+
+	c := &e.assets
+	c.mu.Lock()
+	h, ok := c.hashes[p]
+	c.mu.Unlock()
+	if ok && h.mod.Equal(st.ModTime()) {
+		return h, nil
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return assetHash{}, err
+	}
+	sum := sha512.Sum384(b)
+	h = assetHash{
+		mod:   st.ModTime(),
+		sri:   "sha384-" + base64.StdEncoding.EncodeToString(sum[:]),
+		short: hex.EncodeToString(sum[:assetHashLen/2]),
+	}
+
+	c.mu.Lock()
+	if c.hashes == nil {
+		c.hashes = map[string]assetHash{}
+	}
+	c.hashes[p] = h
+	c.mu.Unlock()
+	return h, nil
+}
+
+// integrity: "sha384-<base64>" of the asset name, of its built file when
+// the manifest has one.
+func (e *Engine) integrity(name string) (string, error) {
+	if built, ok, err := e.manifestFile(name); err != nil {
+		return "", err
+	} else if ok {
+		name = built
+	}
+	h, err := e.hash(name)
+	return h.sri, err
+}
+
+// manifest: the parsed Assets.Manifest, reloaded when the file changes.
+func (e *Engine) manifest() (assetManifest, error) {
+	p := e.Assets.Manifest
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(e.Assets.Dir, p)
+	}
+	st, err := os.Stat(p)
+	if err != nil {
+		return assetManifest{}, fmt.Errorf("vingo: asset manifest: %w", err)
+	}
+	c := &e.assets
+	c.mu.Lock()
+	m := c.manifest
+	c.mu.Unlock()
+	if m.path == p && m.mod.Equal(st.ModTime()) {
+		return m, nil
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return assetManifest{}, fmt.Errorf("vingo: asset manifest: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return assetManifest{}, fmt.Errorf("vingo: asset manifest %s: %w", p, err)
+	}
+	m = assetManifest{path: p, mod: st.ModTime(), files: map[string]string{}, built: map[string]bool{}}
+	for name, v := range raw {
+		var file string
+		if json.Unmarshal(v, &file) != nil {
+			var entry struct {
+				File string `json:"file"`
+			}
+			if err := json.Unmarshal(v, &entry); err != nil || entry.File == "" {
+				return assetManifest{}, fmt.Errorf("vingo: asset manifest %s: %q: expected a path or {\"file\": path}", p, name)
+			}
+			file = entry.File
+		}
+		file = strings.TrimPrefix(file, "/")
+		m.files[strings.TrimPrefix(name, "/")] = file
+		m.built[file] = true
+	}
+
+	c.mu.Lock()
+	c.manifest = m
+	c.mu.Unlock()
+	return m, nil
+}
+
+// manifestFile: built path of the asset name in the manifest.
+func (e *Engine) manifestFile(name string) (string, bool, error) {
+	if e.Assets.Manifest == "" {
+		return "", false, nil
+	}
+	m, err := e.manifest()
+	if err != nil {
+		return "", false, err
+	}
+	file, ok := m.files[strings.TrimPrefix(name, "/")]
+	return file, ok, nil
+}
+
+// assetURL: public URL of the asset name.
+func (e *Engine) assetURL(name string) (string, error) {
+	if built, ok, err := e.manifestFile(name); err != nil {
+		return "", err
+	} else if ok {
+		name = built
+	} else if e.Assets.Hash {
+		h, err := e.hash(name)
+		if err != nil {
+			return "", err
+		}
+		name = hashedName(name, h.short)
+	}
+	if e.Assets.URLPrefix == "" {
+		return name, nil
+	}
+	return strings.TrimSuffix(e.Assets.URLPrefix, "/") + "/" + strings.TrimPrefix(name, "/"), nil
+}
+
+// hashedName: css/app.css -> css/app.<hash>.css.
+func hashedName(name, hash string) string {
+	dir, file := path.Split(name)
+	if file == "" {
+		return name
+	}
+	if i := strings.IndexByte(file[1:], '.'); i >= 0 {
+		// app.min.css -> app.<hash>.min.css, .htaccess keeps its dot
+		return dir + file[:i+1] + "." + hash + file[i+1:]
+	}
+	return name + "." + hash
+}
+
+// unhashedName: inverse of hashedName; ok is false when name has no hash.
+func unhashedName(name string) (orig, hash string, ok bool) {
+	dir, file := path.Split(name)
+	parts := strings.Split(file, ".")
+	for i := 1; i < len(parts); i++ {
+		if isAssetHash(parts[i]) {
+			orig := dir + strings.Join(append(parts[:i:i], parts[i+1:]...), ".")
+			return orig, parts[i], true
+		}
+	}
+	return "", "", false
+}
+
+func isAssetHash(s string) bool {
+	if len(s) != assetHashLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// assetMaxAge: Cache-Control of hashed asset URLs.
+const assetMaxAge = "public, max-age=31536000, immutable"
+
+// AssetHandler: serves the files of e.Assets.Dir (nil: the default engine)
+// under the URLs asset() gives. Hashed names are served from the original
+// file with a long-lived Cache-Control, as are the built files of the
+// manifest; a stale hash still gets the current file, but uncached. Mount
+// it under Assets.URLPrefix with http.StripPrefix.
+func AssetHandler(e *Engine) http.Handler {
+	if e == nil {
+		e = defaultEngine
+	}
+	files := http.FileServer(http.Dir(e.Assets.Dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if _, err := os.Stat(filepath.Join(e.Assets.Dir, filepath.FromSlash(name))); err == nil {
+			if e.Assets.Manifest != "" {
+				if m, err := e.manifest(); err == nil && m.built[name] {
+					w.Header().Set("Cache-Control", assetMaxAge)
+				}
+			}
+			files.ServeHTTP(w, r)
+			return
+		}
+		orig, hash, ok := unhashedName(name)
+		if !ok {
+			files.ServeHTTP(w, r)
+			return
+		}
+		h, err := e.hash(orig)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if h.short == hash {
+			w.Header().Set("Cache-Control", assetMaxAge)
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + orig
+		r2.URL.RawPath = ""
+		files.ServeHTTP(w, r2)
+	})
+}
+
+func assetFunc(c *Call) (interface{}, error) {
+	return c.Engine().assetURL(argString(c.Arg(0)))
+}
+
+func integrityFunc(c *Call) (interface{}, error) {
+	return c.Engine().integrity(argString(c.Arg(0)))
+}
+
+func scriptFunc(c *Call) (interface{}, error) {
+	b := &strings.Builder{}
+	if err := writeAssetTag(b, c, `<script src="%s"`, "src"); err != nil {
+		return nil, err
+	}
+	b.WriteString("></script>")
+	return b.String(), nil
+}
+
+func stylesheetFunc(c *Call) (interface{}, error) {
+	b := &strings.Builder{}
+	if err := writeAssetTag(b, c, `<link rel="stylesheet" href="%s"`, "href"); err != nil {
+		return nil, err
+	}
+	b.WriteString(">")
+	return b.String(), nil
+}
+
+// writeAssetTag: opening tag (without ">") for the asset in c.Arg(0) with
+// integrity/crossorigin and the remaining keyword arguments as attributes.
+func writeAssetTag(b *strings.Builder, c *Call, open string, urlAttr string) error {
+	name := argString(c.Arg(0))
+	if name == "" {
+		return fmt.Errorf("missing asset path")
+	}
+	e := c.Engine()
+	u, err := e.assetURL(name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, open, html.EscapeString(u))
+	if condTruthy(c.Kwarg("integrity", e.Assets.Integrity)) {
+		sri, err := e.integrity(name)
+		if err != nil {
+			return err
+		}
+		co := e.Assets.CrossOrigin
+		if co == "" {
+			co = "anonymous"
+		}
+		fmt.Fprintf(b, ` integrity="%s" crossorigin="%s"`, sri, html.EscapeString(argString(c.Kwarg("crossorigin", co))))
+	}
+	if nonce, ok := cspNonceOf(c); ok {
+		if _, set := c.Kwargs["nonce"]; !set {
+			fmt.Fprintf(b, ` nonce="%s"`, html.EscapeString(nonce))
+		}
+	}
+	writeAttrs(b, c.Kwargs, urlAttr, "integrity", "crossorigin")
+	return nil
+}
+
+// writeAttrs: writes kwargs as HTML attributes in name order, skipping the
+// given names. true becomes a bare attribute (defer), false/nil is dropped.
+func writeAttrs(b *strings.Builder, kwargs map[string]interface{}, skip ...string) {
+	names := make([]string, 0, len(kwargs))
+	for k := range kwargs {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || s == k
+		}
+		if !skipped {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		switch v := kwargs[k].(type) {
+		case nil:
+		case bool:
+			if v {
+				fmt.Fprintf(b, " %s", k)
+			}
+		default:
+			fmt.Fprintf(b, ` %s="%s"`, k, html.EscapeString(argString(v)))
+		}
+	}
+}
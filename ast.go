@@ -0,0 +1,213 @@
+package vingo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// -------------------- Syntax tree for tooling --------------------
+//
+// ParseAST exposes the structure of a template with source positions, for
+// editors, linters and other external tools. It is a description of the
+// source, not the compiled form: constants are not folded, includes are not
+// inlined and <{ test }> tags stay in place.
+
+// ASTPos: position in the template source; Line and Col are 1-based,
+// Col and Offset count bytes.
+type ASTPos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+// ASTNode: one node of a parsed template.
+//
+// Type is "template" (the root), "text", "var", "if", "branch" (the if /
+// elseif parts of an if), "else", "for", "switch", "case", "default",
+// "block", "cache", "csv", "row", "section", "yield", "component", "slot",
+// "once", "include", "test", "params" or "import". Attrs holds the
+// arguments of tags:
+//
+//	var      expr, default
+//	branch   cond
+//	for      item, index, list, where, sort, order
+//	switch   expr
+//	case     cond
+//	block    name
+//	cache    args
+//	csv      args
+//	row      args
+//	section  name, args
+//	yield    name
+//	component name, args
+//	slot     name
+//	once     key
+//	include  path, args
+//	test     name, args
+//	params   args
+//	import   args
+type ASTNode struct {
+	Type     string            `json:"type"`
+	Pos      ASTPos            `json:"pos"`
+	End      *ASTPos           `json:"end,omitempty"` // closing tag of if, for, switch, block, cache, csv
+	Raw      string            `json:"raw,omitempty"` // tag source between <{ and }>
+	Text     string            `json:"text,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []*ASTNode        `json:"children,omitempty"`
+}
+
+// ParseAST: parses file (relative to Root) into its syntax tree. Templates
+// that don't compile return a *SyntaxError, like Render.
+func (e *Engine) ParseAST(file string) (*ASTNode, error) {
+	path := e.resolve(file)
+	b, err := e.loader().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// compile to report syntax errors; the tree is built from the tokens
+	if _, err := compileTokens(tokens); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return buildAST(tokens), nil
+}
+
+// buildAST: tree of a token stream that compiles.
+func buildAST(tokens []*Token) *ASTNode {
+	root := &ASTNode{Type: "template", Pos: ASTPos{Line: 1, Col: 1}}
+	stack := []*ASTNode{root}
+	top := func() *ASTNode { return stack[len(stack)-1] }
+	push := func(n *ASTNode) {
+		top().Children = append(top().Children, n)
+		stack = append(stack, n)
+	}
+	pop := func() *ASTNode {
+		n := top()
+		if len(stack) > 1 {
+			stack = stack[:len(stack)-1]
+		}
+		return n
+	}
+	// closes the open branch / case of the enclosing if / switch
+	popPart := func(types ...string) {
+		for _, typ := range types {
+			if top().Type == typ {
+				pop()
+				return
+			}
+		}
+	}
+
+	for _, t := range tokens {
+		n := &ASTNode{Type: t.Type.String(), Pos: ASTPos{Offset: t.Pos, Line: t.Line, Col: t.Col}, Raw: t.Raw}
+		end := &n.Pos
+		switch t.Type {
+		case TText:
+			n.Raw = ""
+			n.Text = t.Value
+			top().Children = append(top().Children, n)
+		case TVar:
+			n.Attrs = map[string]string{"expr": t.Value}
+			if t.Default != "" {
+				n.Attrs["default"] = t.Default
+			}
+			top().Children = append(top().Children, n)
+		case TIf:
+			push(&ASTNode{Type: "if", Pos: n.Pos, Raw: t.Raw})
+			n.Type, n.Attrs = "branch", map[string]string{"cond": t.Value}
+			push(n)
+		case TElseIf:
+			popPart("branch")
+			n.Type, n.Attrs = "branch", map[string]string{"cond": t.Value}
+			push(n)
+		case TElse:
+			popPart("branch")
+			push(n)
+		case TFor:
+			vars, list, _ := strings.Cut(t.Value, ":")
+			lc := splitLoop(list)
+			n.Attrs = map[string]string{"list": lc.list}
+			if lc.where != "" {
+				n.Attrs["where"] = lc.where
+			}
+			if lc.sortBy != "" {
+				n.Attrs["sort"] = lc.sortBy
+				if lc.desc {
+					n.Attrs["order"] = "desc"
+				}
+			}
+			if idx, item, ok := strings.Cut(vars, ","); ok {
+				n.Attrs["index"], n.Attrs["item"] = strings.TrimSpace(idx), strings.TrimSpace(item)
+			} else {
+				n.Attrs["item"] = vars
+			}
+			push(n)
+		case TSwitch:
+			n.Attrs = map[string]string{"expr": t.Value}
+			push(n)
+		case TCase:
+			popPart("case", "default")
+			n.Attrs = map[string]string{"cond": t.Value}
+			push(n)
+		case TDefault:
+			popPart("case", "default")
+			push(n)
+		case TBlock:
+			name, _ := literalFromString(t.Value).(string)
+			n.Attrs = map[string]string{"name": name}
+			push(n)
+		case TCache, TCSV:
+			n.Attrs = map[string]string{"args": t.Value}
+			push(n)
+		case TRow:
+			n.Attrs = map[string]string{"args": t.Value}
+			top().Children = append(top().Children, n)
+		case TSection:
+			n.Attrs = map[string]string{"args": t.Value}
+			if args, _, err := parseTagArgs(t.Value); err == nil && len(args) > 0 {
+				n.Attrs["name"] = literalName(args[0])
+			}
+			push(n)
+		case TOnce:
+			n.Attrs = map[string]string{"key": t.Value}
+			push(n)
+		case TComponent, TSlot:
+			n.Attrs = map[string]string{"args": t.Value}
+			if args, _, err := parseTagArgs(t.Value); err == nil && len(args) > 0 {
+				n.Attrs["name"] = literalName(args[0])
+			}
+			push(n)
+		case TYield:
+			name, _ := literalFromString(t.Value).(string)
+			n.Attrs = map[string]string{"name": name}
+			top().Children = append(top().Children, n)
+		case TInclude:
+			n.Attrs = map[string]string{"args": t.Value}
+			if inc, err := parseInclude(t); err == nil && inc.Path != "" {
+				n.Attrs["path"] = inc.Path
+			}
+			top().Children = append(top().Children, n)
+		case TParams, TImport:
+			n.Attrs = map[string]string{"args": t.Value}
+			top().Children = append(top().Children, n)
+		case TTest:
+			n.Attrs = map[string]string{"args": t.Value}
+			if test, err := parseTest(t); err == nil {
+				n.Attrs["name"] = test.Name
+			}
+			top().Children = append(top().Children, n)
+		case TEndIf:
+			popPart("branch", "else")
+			pop().End = end
+		case TEndSwitch:
+			popPart("case", "default")
+			pop().End = end
+		case TEndFor, TEndBlock, TEndCache, TEndCSV, TEndSection, TEndComponent, TEndSlot, TEndOnce:
+			pop().End = end
+		}
+	}
+	return root
+}
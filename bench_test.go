@@ -0,0 +1,88 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchmarkSource: renders src compiled by e with data b.N times.
+func benchmarkSource(b *testing.B, e *Engine, src string, data map[string]interface{}) {
+	b.Helper()
+	tpl, err := e.compile(e.resolve("bench.vgo"), src, "", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.execute(ctx, tpl.Nodes, tpl.size, "", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoopScope: a 1000-item loop next to 200 top-level keys, which
+// loop scopes must not copy per iteration.
+func BenchmarkLoopScope(b *testing.B) {
+	data := map[string]interface{}{}
+	for i := 0; i < 200; i++ {
+		data[fmt.Sprintf("key%d", i)] = i
+	}
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]interface{}{"Name": fmt.Sprintf("item %d", i), "Price": i}
+	}
+	data["items"] = items
+	src := `<{ for i, item in items }><{ i }>: <{ item.Name }> <{ item.Price }> <{ key7 }>
+<{ /for }>`
+	benchmarkSource(b, New(), src, data)
+}
+
+// BenchmarkLargeTemplate: a template of about 750 KB of text mixed with
+// ifs, loops and switches, which renders into one pooled buffer.
+func BenchmarkLargeTemplate(b *testing.B) {
+	chunk := `<div class="card">` + strings.Repeat("lorem ipsum dolor sit amet ", 20) + `
+<{ if user.Admin }><b><{ user.Name }></b><{ else }><{ user.Name }><{ /if }>
+<ul><{ for tag in tags }><li><{ tag }></li><{ /for }></ul>
+<{ switch user.Role }><{ case "admin" }>A<{ case "editor" }>E<{ default }>U<{ /switch }>
+</div>
+`
+	src := strings.Repeat(chunk, 750<<10/len(chunk))
+	data := map[string]interface{}{
+		"user": map[string]interface{}{"Name": "Ayşe", "Admin": true, "Role": "editor"},
+		"tags": []string{"go", "templates", "vingo"},
+	}
+	benchmarkSource(b, New(), src, data)
+}
+
+type benchAddress struct {
+	City string
+}
+
+type benchPerson struct {
+	Name    string
+	Address *benchAddress
+}
+
+type benchUser struct {
+	benchPerson
+	Email string
+}
+
+// BenchmarkStructFields: a 1000-item loop over structs reading promoted,
+// nested and plain fields, which walk the cached field index paths.
+func BenchmarkStructFields(b *testing.B) {
+	users := make([]benchUser, 1000)
+	for i := range users {
+		users[i] = benchUser{
+			benchPerson: benchPerson{Name: fmt.Sprintf("user %d", i), Address: &benchAddress{City: "İzmir"}},
+			Email:       fmt.Sprintf("user%d@example.com", i),
+		}
+	}
+	src := `<{ for u in users }><{ u.Name }> <{ u.Email }> <{ u.Address.City }>
+<{ /for }>`
+	benchmarkSource(b, New(), src, map[string]interface{}{"users": users})
+}
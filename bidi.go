@@ -0,0 +1,97 @@
+package vingo
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// -------------------- Bidi text helpers --------------------
+//
+//	<html lang="<{ locale }>" dir="<{ dir(locale) }>">
+//	<p><{ review.Author | isolate }> wrote: ...</p>
+//
+// dir returns "rtl" or "ltr". Its argument is a locale ("ar", "he-IL",
+// "fa_IR"), or else text whose first strong character decides, like
+// dir="auto". isolate wraps a value in FSI ... PDI, so a name or title in
+// the opposite direction doesn't reorder the sentence around it;
+// isolate:"ltr" and isolate:"rtl" force the direction (LRI / RLI).
+
+const (
+	bidiLRI = "\u2066" // left-to-right isolate
+	bidiRLI = "\u2067" // right-to-left isolate
+	bidiFSI = "\u2068" // first strong isolate
+	bidiPDI = "\u2069" // pop directional isolate
+)
+
+// rtlScripts: ISO 15924 codes of scripts written right to left.
+var rtlScripts = map[string]bool{
+	"Adlm": true, "Arab": true, "Hebr": true, "Mand": true, "Nkoo": true,
+	"Rohg": true, "Samr": true, "Syrc": true, "Thaa": true, "Yezi": true,
+}
+
+func init() {
+	builtinFuncs["dir"] = dirFunc
+	builtinFuncs["isolate"] = isolateFunc
+}
+
+func dirFunc(c *Call) (interface{}, error) {
+	if isRTL(argString(c.Arg(0))) {
+		return "rtl", nil
+	}
+	return "ltr", nil
+}
+
+func isolateFunc(c *Call) (interface{}, error) {
+	s := argString(c.Arg(0))
+	if s == "" {
+		return "", nil
+	}
+	open := bidiFSI
+	switch d := argString(c.Arg(1)); d {
+	case "", "auto":
+	case "ltr":
+		open = bidiLRI
+	case "rtl":
+		open = bidiRLI
+	default:
+		return nil, fmt.Errorf("unknown direction %q", d)
+	}
+	return open + s + bidiPDI, nil
+}
+
+// isRTL: direction of a locale tag, or of text by its first strong character.
+func isRTL(s string) bool {
+	if isLocaleLike(s) {
+		if tag, err := language.Parse(s); err == nil {
+			script, _ := tag.Script()
+			return rtlScripts[script.String()]
+		}
+	}
+	for _, r := range s {
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.L:
+			return false
+		case bidi.R, bidi.AL:
+			return true
+		}
+	}
+	return false
+}
+
+// isLocaleLike: s could be a BCP 47 tag (ASCII letters and digits separated
+// by - or _), as opposed to text.
+func isLocaleLike(s string) bool {
+	if s == "" || len(s) > 35 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package vingo
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// -------------------- Template cache --------------------
+//
+// Compiled templates are kept in an LRU keyed by loader and path. Without
+// Engine.CacheLimits it never evicts; servers rendering thousands of
+// distinct templates (one set per tenant) set limits so the cache stays
+// within a memory budget. Sizes are estimates: the source length plus a
+// fixed cost per node.
+
+// CacheLimits: bounds of the compiled template cache (zero = unbounded).
+type CacheLimits struct {
+	MaxTemplates int // number of cached templates
+	MaxBytes     int // sum of their estimated sizes
+}
+
+// CacheStats: state and counters of the template cache.
+type CacheStats struct {
+	Templates int    // templates in the cache
+	Bytes     int    // their estimated size
+	Hits      uint64 // renders and includes served from the cache
+	Misses    uint64 // compiles, including recompiles of changed files
+	Evictions uint64 // templates dropped to stay within CacheLimits
+}
+
+// CacheStats: reports the template cache counters of e, e.g. for metrics.
+func (e *Engine) CacheStats() CacheStats {
+	return e.cache.stats()
+}
+
+// nodeCost: estimated memory of one compiled node beyond its text.
+const nodeCost = 128
+
+// templateCost: estimated memory of a compiled template.
+func templateCost(tpl *Template) int {
+	n := 0
+	walkNodes(tpl.Nodes, func(Node) { n++ })
+	return tpl.size + n*nodeCost
+}
+
+type cacheKey struct {
+	loader Loader
+	path   string
+	locale string // locale the translations were inlined for
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	tpl  *Template
+	cost int
+}
+
+// templateCache: LRU of compiled templates. The zero value is ready to use.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element // values are *cacheEntry
+	order   list.List                  // front: most recently used
+	bytes   int
+
+	hits, misses, evictions atomic.Uint64
+}
+
+// get: cached template for key, marked as most recently used.
+func (c *templateCache) get(key cacheKey) (*Template, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).tpl, true
+}
+
+// put: caches tpl under key, then evicts the least recently used templates
+// until limits hold again. tpl itself is never evicted by its own put.
+func (c *templateCache) put(key cacheKey, tpl *Template, limits CacheLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[cacheKey]*list.Element{}
+		c.order.Init()
+	}
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+	ent := &cacheEntry{key: key, tpl: tpl, cost: templateCost(tpl)}
+	c.entries[key] = c.order.PushFront(ent)
+	c.bytes += ent.cost
+	for c.order.Len() > 1 && c.overLocked(limits) {
+		c.removeLocked(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+func (c *templateCache) overLocked(limits CacheLimits) bool {
+	return (limits.MaxTemplates > 0 && c.order.Len() > limits.MaxTemplates) ||
+		(limits.MaxBytes > 0 && c.bytes > limits.MaxBytes)
+}
+
+func (c *templateCache) removeLocked(el *list.Element) {
+	ent := c.order.Remove(el).(*cacheEntry)
+	delete(c.entries, ent.key)
+	c.bytes -= ent.cost
+}
+
+// removeIf: drops the templates for which drop returns true, reports
+// whether there were any.
+func (c *templateCache) removeIf(drop func(path string, tpl *Template) bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := false
+	for _, el := range c.entries {
+		if ent := el.Value.(*cacheEntry); drop(ent.key.path, ent.tpl) {
+			c.removeLocked(el)
+			found = true
+		}
+	}
+	return found
+}
+
+// clear: drops every template; the counters are kept.
+func (c *templateCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.order.Init()
+	c.bytes = 0
+}
+
+// paths: paths of the cached templates.
+func (c *templateCache) paths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	paths := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		paths = append(paths, key.path)
+	}
+	return paths
+}
+
+func (c *templateCache) stats() CacheStats {
+	c.mu.Lock()
+	st := CacheStats{Templates: c.order.Len(), Bytes: c.bytes}
+	c.mu.Unlock()
+	st.Hits = c.hits.Load()
+	st.Misses = c.misses.Load()
+	st.Evictions = c.evictions.Load()
+	return st
+}
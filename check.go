@@ -0,0 +1,233 @@
+package vingo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// -------------------- Linter --------------------
+//
+// Check reports problems of a template without rendering it: syntax errors
+// (unclosed if/for/switch, invalid expressions), unknown tags, calls of
+// undefined functions and filters, invalid regular expressions, and
+// if/switch branches that can never be taken.
+
+// Diagnostic: one problem found by Check.
+type Diagnostic struct {
+	File      string
+	Line, Col int
+	Message   string
+}
+
+// String: "file:line:col: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Col, d.Message)
+}
+
+// Check: lints file. Functions are looked up in e, so register them with
+// AddFunc before checking. The error is about reading the file, problems
+// of the template are returned as diagnostics, ordered by position.
+func (e *Engine) Check(file string) ([]Diagnostic, error) {
+	b, err := e.loader().ReadFile(e.resolve(file))
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{e: e, file: file}
+	tokens, err := tokenize(string(b))
+	if err == nil {
+		c.tokens(tokens)
+		_, err = compileTokens(tokens)
+	}
+	var serr *SyntaxError
+	if errors.As(err, &serr) {
+		c.diags = append(c.diags, Diagnostic{File: file, Line: serr.Line, Col: serr.Col, Message: serr.Err.Error()})
+	} else if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		a, b := c.diags[i], c.diags[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Col < b.Col
+	})
+	return c.diags, nil
+}
+
+type checker struct {
+	e     *Engine
+	file  string
+	diags []Diagnostic
+	open  []*branchSet // enclosing if / switch tags
+}
+
+// branchSet: conditions of the if/elseif or case tags of one if / switch.
+type branchSet struct {
+	tag    TokenType // TIf or TSwitch
+	seen   map[string]int
+	always bool // an earlier branch is always taken
+}
+
+func (c *checker) report(t *Token, format string, args ...interface{}) {
+	c.diags = append(c.diags, Diagnostic{File: c.file, Line: t.Line, Col: t.Col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) tokens(tokens []*Token) {
+	for _, t := range tokens {
+		switch t.Type {
+		case TText:
+			if t.Raw != "" {
+				c.report(t, "unknown tag <{ %s }>", t.Raw)
+			}
+		case TVar:
+			if closer, ok := misspelledCloser(t); ok {
+				c.report(t, "unknown tag <{ %s }>, did you mean <{ %s }>?", t.Raw, closer)
+				continue
+			}
+			c.expr(t, t.expr)
+		case TIf, TSwitch:
+			set := &branchSet{tag: t.Type, seen: map[string]int{}}
+			c.open = append(c.open, set)
+			x := c.parse(t, t.Value)
+			if t.Type == TIf {
+				c.branch(t, set, x)
+			}
+		case TElseIf, TCase:
+			src := t.Value
+			if t.Type == TCase {
+				src = caseSource(src)
+			}
+			x := c.parse(t, src)
+			if set := c.top(); set != nil && (t.Type == TCase) == (set.tag == TSwitch) {
+				c.branch(t, set, x)
+			}
+		case TElse, TDefault:
+			if set := c.top(); set != nil && set.always {
+				c.report(t, "unreachable %v: an earlier branch is always taken", t.Type)
+			}
+		case TEndIf, TEndSwitch:
+			if len(c.open) > 0 {
+				c.open = c.open[:len(c.open)-1]
+			}
+		case TFor:
+			if k := strings.Index(t.Value, ":"); k >= 0 {
+				lc := splitLoop(t.Value[k+1:])
+				for _, src := range []string{lc.list, lc.where, lc.sortBy} {
+					if src != "" {
+						c.parse(t, src)
+					}
+				}
+			}
+		case TCache, TInclude, TCSV, TRow, TSection, TComponent, TOnce:
+			args, kwargs, err := parseTagArgs(t.Value)
+			if err != nil {
+				continue // reported by the compiler
+			}
+			for _, a := range args {
+				c.expr(t, a)
+			}
+			for _, kw := range kwargs {
+				c.expr(t, kw.val)
+			}
+		}
+	}
+}
+
+func (c *checker) top() *branchSet {
+	if len(c.open) == 0 {
+		return nil
+	}
+	return c.open[len(c.open)-1]
+}
+
+// parse: the expression src of t, checked; nil if it does not parse (the
+// compiler reports that).
+func (c *checker) parse(t *Token, src string) Expr {
+	x, err := parseExpr(src)
+	if err != nil {
+		return nil
+	}
+	c.expr(t, x)
+	return x
+}
+
+// branch: checks that the branch t of set can be taken.
+func (c *checker) branch(t *Token, set *branchSet, x Expr) {
+	if x == nil {
+		return
+	}
+	key := strings.Join(strings.Fields(t.Value), " ")
+	switch line, dup := set.seen[key]; {
+	case set.always:
+		c.report(t, "unreachable %v: an earlier branch is always taken", t.Type)
+	case dup:
+		c.report(t, "unreachable %v: same condition as line %d", t.Type, line)
+	default:
+		set.seen[key] = t.Line
+	}
+	// literal conditions only; build tags and constants are dead on purpose
+	if lit, ok := x.(*litExpr); ok && t.Type != TCase {
+		if condTruthy(lit.val) {
+			set.always = true
+		} else {
+			c.report(t, "%v condition %q is always false", t.Type, t.Value)
+		}
+	}
+}
+
+// expr: reports calls of undefined functions and filters in x.
+func (c *checker) expr(t *Token, x Expr) {
+	switch x := x.(type) {
+	case *callExpr:
+		if c.e.lookupFunc(x.name) == nil {
+			c.report(t, "undefined function %q", x.name)
+		}
+		c.call(t, x)
+	case *filterExpr:
+		if c.e.lookupFunc(x.call.name) == nil {
+			c.report(t, "undefined filter %q", x.call.name)
+		}
+		c.expr(t, x.x)
+		c.call(t, x.call)
+	case *listExpr:
+		for _, it := range x.items {
+			c.expr(t, it)
+		}
+	case *mapExpr:
+		for _, v := range x.vals {
+			c.expr(t, v)
+		}
+	case *binaryExpr:
+		c.expr(t, x.left)
+		c.expr(t, x.right)
+		if lit, ok := x.right.(*litExpr); ok && x.op == "matches" {
+			if _, err := regexp.Compile(argString(lit.val)); err != nil {
+				c.report(t, "invalid pattern %q: %v", argString(lit.val), err)
+			}
+		}
+	case *notExpr:
+		c.expr(t, x.x)
+	case *setExpr:
+		c.expr(t, x.x)
+	case *switchExpr:
+		c.expr(t, x.x)
+		for _, sc := range x.cases {
+			for _, cond := range sc.conds {
+				c.expr(t, cond.cond)
+			}
+			c.expr(t, sc.val)
+		}
+		if x.def != nil {
+			c.expr(t, x.def)
+		}
+	}
+}
+
+func (c *checker) call(t *Token, x *callExpr) {
+	for _, a := range x.args {
+		c.expr(t, a)
+	}
+	for _, kw := range x.kwargs {
+		c.expr(t, kw.val)
+	}
+}
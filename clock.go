@@ -0,0 +1,32 @@
+package vingo
+
+import (
+	"time"
+)
+
+// -------------------- Clock --------------------
+//
+//	<{ if event.StartsAt > now() }>upcoming<{ /if }>
+//	<{ if post.Date >= today() }>new<{ /if }>
+//	<{ if deadline < "2025-01-01" }>...<{ /if }>     date strings compare too
+//
+// now() and today() read Engine.Clock, so tests can render with a fixed
+// time.
+
+func init() {
+	builtinFuncs["now"] = func(c *Call) (interface{}, error) {
+		return c.Engine().now(), nil
+	}
+	builtinFuncs["today"] = func(c *Call) (interface{}, error) {
+		t := c.Engine().now()
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	}
+}
+
+// now: Engine.Clock or time.Now.
+func (e *Engine) now() time.Time {
+	if e.Clock != nil {
+		return e.Clock()
+	}
+	return time.Now()
+}
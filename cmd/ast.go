@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/coderiantest/vingo"
+)
+
+// ast: vingo ast template.vgo [--format json]
+//
+// Template'in node ağacını (pozisyonlarıyla) yazar; editörler ve harici
+// araçlar için. Bkz. vingo.Engine.ParseAST.
+func ast(fset *flag.FlagSet, args []string) error {
+	format := fset.String("format", "json", "output format (json)")
+
+	// flag'ler template isminden sonra da gelebilir
+	var files []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return err
+		}
+		if fset.NArg() == 0 {
+			break
+		}
+		files = append(files, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+	if len(files) != 1 {
+		return errUsage("exactly one template required")
+	}
+	if *format != "json" {
+		return errUsage("unknown output format: %s", *format)
+	}
+
+	tree, err := vingo.New().ParseAST(files[0])
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(tree)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coderiantest/vingo"
+)
+
+// build: vingo build [--src content] [--out dist] [--data site.json] [--cache file] [--force] [--jobs n] [--routes file]
+//
+// Statik site üretir: src altındaki her template bir sayfadır ve
+// out altına aynı yolda .html olarak render edilir (blog/post.vgo ->
+// dist/blog/post.html). Diğer dosyalar (css, resim) olduğu gibi kopyalanır.
+//
+// Sayfanın data'sı sırasıyla birleştirilir: --data dosyası, sayfanın yanındaki
+// aynı isimli data dosyası (post.json, post.yaml, post.toml) ve template'in
+// başındaki front matter. Ayrıca "page" değişkeni sayfanın url'ini ve
+// dosyasını verir. İsmi "_" ile başlayan dosya ve klasörler (partial'lar,
+// layout'lar) sayfa olarak render edilmez ve kopyalanmaz.
+//
+// --og-template ve --og-command ile sayfalardaki og_image() çağrıları
+// out/og/ altına PNG üretir.
+//
+// Girdileri (template, include'lar, data, kopyalanan dosyalar) ve çıktısı
+// önceki build'den beri değişmeyen sayfalar --cache dosyasına bakılarak
+// atlanır (bkz. buildCache); --force hepsini yeniden render eder,
+// --cache "" cache'i kapatır.
+//
+// Sayfalar --jobs (varsayılan CPU sayısı) worker'la paralel render edilir;
+// yazılan dosyalar ve hata raporu --jobs'tan bağımsızdır: hatalı sayfaların
+// hepsi, klasördeki sırayla raporlanır.
+//
+// --routes sayfaların listesini (url, template, data dosyaları, son
+// değişiklik zamanı) JSON olarak yazar; bkz. routeManifest.
+func build(fset *flag.FlagSet, args []string) (err error) {
+	src := fset.String("src", "content", "content directory")
+	out := fset.String("out", "dist", "output directory")
+	dataFile := fset.String("data", "", "data file given to every page (.json, .yaml, .toml)")
+	ogTemplate := fset.String("og-template", "", "template of og_image() images, relative to src")
+	ogCommand := fset.String("og-command", "", `command converting HTML on stdin to PNG on stdout, e.g. "wkhtmltoimage --width {width} --height {height} -f png - -"`)
+	cacheFile := fset.String("cache", ".vingo-build-cache.json", `build cache file, pages whose inputs did not change are skipped ("" = no cache)`)
+	force := fset.Bool("force", false, "render every page, ignoring the build cache")
+	jobs := fset.Int("jobs", runtime.NumCPU(), "number of pages rendered in parallel")
+	routes := fset.String("routes", "", "write a JSON manifest of the routes (path, template, data files, last modified) to this file")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 0 {
+		return errUsage("unexpected arguments: %v", fset.Args())
+	}
+	if *jobs < 1 {
+		return errUsage("--jobs must be at least 1")
+	}
+
+	site, err := loadData(*dataFile, "")
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(*src)
+	if err != nil {
+		return err
+	}
+	outDir, err := filepath.Abs(*out)
+	if err != nil {
+		return err
+	}
+
+	// sayfalar ve yanlarındaki data dosyaları
+	var pages, files []string
+	pageData := map[string]bool{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == outDir {
+			return filepath.SkipDir
+		}
+		if path != root && strings.HasPrefix(d.Name(), "_") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(rel); ext == ".vgo" || ext == ".vingo" {
+			pages = append(pages, rel)
+			base := strings.TrimSuffix(rel, ext)
+			for _, dext := range []string{".json", ".yaml", ".yml", ".toml"} {
+				pageData[base+dext] = true
+			}
+		} else {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e := vingo.New()
+	e.Root = root
+	e.Loader = vingo.FrontMatterLoader{}
+	if *ogTemplate != "" {
+		if *ogCommand == "" {
+			return errUsage("--og-template requires --og-command")
+		}
+		e.OGImages = vingo.OGImageOptions{
+			Template:   *ogTemplate,
+			Rasterizer: vingo.CommandRasterizer(strings.Fields(*ogCommand)),
+			Dir:        filepath.Join(outDir, "og"),
+			URLPrefix:  "/og/",
+		}
+	}
+
+	var cache *buildCache
+	var siteInput string
+	if *cacheFile != "" {
+		cache = loadBuildCache(*cacheFile)
+		if *force {
+			cache.old = map[string]buildCacheEntry{}
+		}
+		cache.retain(pages)
+		if siteInput, err = siteHash(root, files, site, *ogTemplate, *ogCommand, outDir); err != nil {
+			return err
+		}
+		// yarıda kalan build'de de o ana kadar yazılan sayfalar kaydedilir
+		defer func() {
+			if serr := cache.save(); serr != nil && err == nil {
+				err = serr
+			}
+		}()
+	}
+
+	// sayfalar --jobs worker'la paralel render edilir; hatalar sayfa
+	// sırasıyla, hepsi birden raporlanır
+	errs := make([]error, len(pages))
+	var unchanged atomic.Int64
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(*jobs, len(pages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				skipped, err := buildPage(e, cache, root, outDir, pages[i], site, siteInput)
+				if skipped {
+					unchanged.Add(1)
+				}
+				errs[i] = err
+			}
+		}()
+	}
+	for i := range pages {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	switch len(failed) {
+	case 0:
+	case 1:
+		return failed[0]
+	default:
+		for _, err := range failed {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return errorf("%d of %d pages failed", len(failed), len(pages))
+	}
+
+	if *routes != "" {
+		if err := writeRoutes(*routes, e, root, pages, *dataFile); err != nil {
+			return err
+		}
+	}
+
+	copied := 0
+	for _, rel := range files {
+		if pageData[rel] {
+			continue
+		}
+		f, err := os.Open(filepath.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		err = writeFile(filepath.Join(outDir, rel), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		copied++
+	}
+
+	if n := unchanged.Load(); n > 0 {
+		printf("%d pages (%d unchanged), %d files -> %s\n", len(pages), n, copied, *out)
+	} else {
+		printf("%d pages, %d files -> %s\n", len(pages), copied, *out)
+	}
+	return nil
+}
+
+// buildPage: tek bir sayfayı render edip yazar; cache'e göre değişmemişse
+// atlar ve skipped true döner. Worker'lardan paralel çağrılır.
+func buildPage(e *vingo.Engine, cache *buildCache, root, outDir, rel string, site map[string]interface{}, siteInput string) (skipped bool, err error) {
+	data, err := buildPageData(root, rel, site)
+	if err != nil {
+		return false, err
+	}
+	target := filepath.Join(outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+".html")
+	var input string
+	if cache != nil {
+		if input, err = pageHash(e, root, rel, siteInput, data); err != nil {
+			return false, err
+		}
+		if cache.fresh(rel, input, target) {
+			return true, nil
+		}
+	}
+	html, err := e.Render(rel, data)
+	if err != nil {
+		return false, err
+	}
+	if err := writeFile(target, strings.NewReader(html)); err != nil {
+		return false, err
+	}
+	if cache != nil {
+		cache.record(rel, input, []byte(html))
+	}
+	return false, nil
+}
+
+// buildPageData: site data + sayfanın data dosyası + front matter + page.
+func buildPageData(root, rel string, site map[string]interface{}) (map[string]interface{}, error) {
+	data := maps.Clone(site)
+	path := filepath.Join(root, rel)
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+		if _, err := os.Stat(base + ext); err != nil {
+			continue
+		}
+		d, err := vingo.ReadDataFile(base + ext)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(data, d)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, _, err := vingo.SplitFrontMatter(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	maps.Copy(data, fm)
+
+	data["page"] = map[string]interface{}{"url": pageURL(rel), "file": filepath.ToSlash(rel)}
+	return data, nil
+}
+
+// pageURL: src'ye göre rel olan sayfanın url'i, blog/post.vgo -> /blog/post.html.
+func pageURL(rel string) string {
+	return "/" + filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))) + ".html"
+}
+
+// writeFile: r'yi path'e yazar, gerekli klasörleri oluşturur.
+func writeFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errorf("could not create directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errorf("could not write file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return errorf("could not write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return errorf("could not write file: %w", err)
+	}
+	return nil
+}
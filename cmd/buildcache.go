@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/coderiantest/vingo"
+)
+
+// buildCacheVersion: cache formatı ya da hash'e giren girdiler değişince
+// artırılır; eski cache'ler yok sayılır.
+const buildCacheVersion = 1
+
+// buildCache: vingo build'in artımlı build cache'i. Her sayfa için girdilerin
+// (template, include ettikleri, data, site geneli) hash'ini ve yazılan
+// çıktının hash'ini tutar; ikisi de tutan sayfa yeniden render edilmez.
+//
+// Cache build sonunda, hata olsa da, geçici dosyaya yazılıp rename ile
+// değiştirilir; yarıda kalan bir build eski cache'i bozmaz, yarım yazılmış
+// bir çıktı da hash'i tutmadığı için yeniden üretilir.
+type buildCache struct {
+	path  string
+	old   map[string]buildCacheEntry
+	mu    sync.Mutex                 // pages, sayfalar paralel render edilir
+	pages map[string]buildCacheEntry // kaydedilecek olanlar, bkz. retain
+}
+
+type buildCacheFile struct {
+	Version int                        `json:"version"`
+	Pages   map[string]buildCacheEntry `json:"pages"`
+}
+
+type buildCacheEntry struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// loadBuildCache: path'teki cache; yoksa, okunamıyorsa ya da başka bir
+// sürümünse boş cache (bütün sayfalar render edilir).
+func loadBuildCache(path string) *buildCache {
+	c := &buildCache{path: path, old: map[string]buildCacheEntry{}, pages: map[string]buildCacheEntry{}}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var f buildCacheFile
+	if json.Unmarshal(b, &f) == nil && f.Version == buildCacheVersion && f.Pages != nil {
+		c.old = f.Pages
+	}
+	return c
+}
+
+// retain: silinmiş sayfaları cache'ten çıkarır. Build yarıda kalırsa
+// henüz render edilmemiş sayfaların eski kayıtları korunur; çıktıları
+// değişmediği için hâlâ doğrudurlar.
+func (c *buildCache) retain(pages []string) {
+	for _, rel := range pages {
+		if ent, ok := c.old[rel]; ok {
+			c.pages[rel] = ent
+		}
+	}
+}
+
+// fresh: sayfa girdileri değişmemiş ve target hâlâ son yazılan çıktıysa true.
+func (c *buildCache) fresh(rel, input, target string) bool {
+	ent, ok := c.old[rel]
+	if !ok || ent.Input != input {
+		return false
+	}
+	b, err := os.ReadFile(target)
+	return err == nil && hashBytes(b) == ent.Output
+}
+
+// record: sayfanın yazılan çıktısını kaydeder.
+func (c *buildCache) record(rel, input string, output []byte) {
+	ent := buildCacheEntry{Input: input, Output: hashBytes(output)}
+	c.mu.Lock()
+	c.pages[rel] = ent
+	c.mu.Unlock()
+}
+
+// save: cache'i atomik olarak yazar.
+func (c *buildCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(buildCacheFile{Version: buildCacheVersion, Pages: c.pages}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return errorf("could not write build cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return errorf("could not write build cache: %w", err)
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errorf("could not write build cache: %w", err)
+	}
+	return nil
+}
+
+// siteHash: bütün sayfaları etkileyen girdilerin hash'i: ayarlar, site
+// data'sı ve kopyalanan dosyalar (asset() hash'leri onlardan hesaplanır).
+// Dosyalar içerikleri yerine boyut ve değişiklik zamanıyla hash'lenir.
+func siteHash(root string, files []string, site map[string]interface{}, settings ...string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "vingo build %d\n", buildCacheVersion)
+	for _, s := range settings {
+		fmt.Fprintf(h, "%q\n", s)
+	}
+	if err := hashJSON(h, site); err != nil {
+		return "", err
+	}
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	for _, rel := range sorted {
+		st, err := os.Stat(filepath.Join(root, rel))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%q %d %d\n", rel, st.Size(), st.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pageHash: sayfanın girdilerinin hash'i: site hash'i, template kaynağı,
+// include ettiği template'ler ve sayfanın data'sı.
+func pageHash(e *vingo.Engine, root, rel, site string, data map[string]interface{}) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%q\n", site, rel)
+	deps, err := e.Dependencies(rel)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range append([]string{filepath.Join(root, rel)}, deps...) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%q %d\n", path, len(b))
+		h.Write(b)
+	}
+	if err := hashJSON(h, data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashJSON: v'nin JSON hali (map anahtarları sıralı) h'ye yazılır.
+func hashJSON(h hash.Hash, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errorf("could not hash data: %w", err)
+	}
+	h.Write(b)
+	h.Write([]byte{'\n'})
+	return nil
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
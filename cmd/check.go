@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/coderiantest/vingo"
+)
+
+// check: vingo check [dosya|klasör|klasör/...]...
+//
+// Template'leri render etmeden kontrol eder; bulunan sorunlar
+// dosya:satır:sütun formatında yazılır, sorun varsa çıkış kodu 1'dir.
+func check(fset *flag.FlagSet, args []string) error {
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	args = fset.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	files, err := templateFiles(args)
+	if err != nil {
+		return err
+	}
+
+	e := vingo.New()
+	problems, bad := 0, 0
+	for _, file := range files {
+		diags, err := e.Check(file)
+		if err != nil {
+			return err
+		}
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+		if len(diags) > 0 {
+			problems += len(diags)
+			bad++
+		}
+	}
+	if problems > 0 {
+		return errorf("%d problems in %d files", problems, bad)
+	}
+	printf("%d files checked, no problems ✅\n", len(files))
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/coderiantest/vingo"
+)
+
+// deps: vingo deps [--dot] [--dependents dosya] [dosya|klasör]...
+//
+// Template'lerin include ettiği template'leri yazar (varsayılan: bu klasör).
+// Çıktı Makefile formatındadır (sayfa.vgo: header.vgo footer.vgo);
+// --dot Graphviz grafiği, --dependents verilen dosya değişince yeniden
+// build edilmesi gereken template'leri yazar.
+func deps(fset *flag.FlagSet, args []string) error {
+	dot := fset.Bool("dot", false, "print the include graph in Graphviz DOT format")
+	dependents := fset.String("dependents", "", "print the templates affected by a change to this file")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	args = fset.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	files, err := templateFiles(args)
+	if err != nil {
+		return err
+	}
+
+	e := vingo.New()
+	g, err := e.DependencyGraph(files...)
+	if err != nil {
+		return err
+	}
+	wd, _ := os.Getwd()
+	rel := func(path string) string {
+		if r, err := filepath.Rel(wd, path); err == nil {
+			return r
+		}
+		return path
+	}
+
+	switch {
+	case *dependents != "":
+		abs, err := filepath.Abs(*dependents)
+		if err != nil {
+			return err
+		}
+		// sadece argümanlardaki template'ler; başka klasörlerden include
+		// edilenler grafikte olsa da yazılmaz
+		pages := map[string]bool{}
+		for _, f := range files {
+			if a, err := filepath.Abs(f); err == nil {
+				pages[a] = true
+			}
+		}
+		for _, p := range g.Dependents(abs) {
+			if pages[p] {
+				fmt.Println(rel(p))
+			}
+		}
+	case *dot:
+		paths := make([]string, 0, len(g))
+		for p := range g {
+			paths = append(paths, p)
+		}
+		slices.Sort(paths)
+		fmt.Println("digraph vingo {")
+		for _, p := range paths {
+			fmt.Printf("  %q;\n", rel(p))
+			for _, dep := range g[p] {
+				fmt.Printf("  %q -> %q;\n", rel(p), rel(dep))
+			}
+		}
+		fmt.Println("}")
+	default:
+		for _, file := range files {
+			list, err := e.Dependencies(file)
+			if err != nil {
+				return err
+			}
+			for i := range list {
+				list[i] = rel(list[i])
+			}
+			fmt.Println(strings.TrimSpace(file + ": " + strings.Join(list, " ")))
+		}
+	}
+	return nil
+}
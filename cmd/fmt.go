@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/coderiantest/vingo"
+)
+
+// format: vingo fmt [--check] [--write] [dosya|klasör]...
+//
+// Template'leri standart stile getirir (bkz. vingo.Format). Flag'siz
+// formatlanmış içerik stdout'a yazılır; --write dosyaların üzerine yazar,
+// --check formatlanması gereken dosyaları listeler ve 1 ile çıkar (CI için).
+func format(fset *flag.FlagSet, args []string) error {
+	check := fset.Bool("check", false, "list files that need formatting, don't change them")
+	write := fset.Bool("write", false, "overwrite the files")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	paths := fset.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := templateFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	unformatted := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, err := vingo.Format(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		changed := !bytes.Equal(src, out)
+		switch {
+		case *check:
+			if changed {
+				unformatted++
+				fmt.Println(file)
+			}
+		case *write:
+			if changed {
+				if err := os.WriteFile(file, out, 0644); err != nil {
+					return errorf("could not write file: %w", err)
+				}
+				fmt.Println(file)
+			}
+		default:
+			os.Stdout.Write(out)
+		}
+	}
+	if unformatted > 0 {
+		return errorf("%d files not formatted (fix with vingo fmt --write)", unformatted)
+	}
+	return nil
+}
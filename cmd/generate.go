@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+
+	"github.com/coderiantest/vingo"
+)
+
+// generate: vingo generate [-pkg views] [-o views_gen.go] <dosya|klasör>...
+func generate(fset *flag.FlagSet, args []string) error {
+	pkg := fset.String("pkg", "views", "package name of the generated file")
+	out := fset.String("o", "", "output file (empty = stdout)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return errUsage("no templates given")
+	}
+
+	files, err := templateFiles(fset.Args())
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := vingo.New().Generate(&buf, *pkg, files...); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		return errorf("could not write file: %w", err)
+	}
+	printf("%s created (%d templates) ✅\n", *out, len(files))
+	return nil
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/coderiantest/vingo"
+)
+
+// lsp: vingo lsp
+//
+// stdin/stdout üzerinden Language Server Protocol sunucusu: parse hataları
+// ve Check sorunları için diagnostic, tag / değişken / fonksiyon / filter
+// tamamlama, tag ve fonksiyonlar için hover, include edilen dosyaya gitme.
+// Editörde açık ve kaydedilmemiş dosyalar diskteki hallerinin yerine
+// kullanılır.
+func lsp(fset *flag.FlagSet, args []string) error {
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 0 {
+		return errUsage("unexpected arguments: %v", fset.Args())
+	}
+	s := newLSPServer()
+	return s.serve(os.Stdin, os.Stdout)
+}
+
+// -------------------- JSON-RPC --------------------
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"` // boş: notification
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"` // sunucudan gelen notification'lar
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// readMessage: Content-Length başlıklı bir mesaj.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("lsp: invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("lsp: missing Content-Length")
+	}
+	b := make([]byte, length)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+// -------------------- Server --------------------
+
+type lspServer struct {
+	engine   *vingo.Engine
+	docs     *overlayLoader
+	out      io.Writer
+	mu       sync.Mutex // out
+	shutdown bool
+}
+
+func newLSPServer() *lspServer {
+	docs := &overlayLoader{docs: map[string]*overlayDoc{}}
+	e := vingo.New()
+	e.Loader = docs
+	return &lspServer{engine: e, docs: docs}
+}
+
+// serve: exit mesajına ya da in kapanana kadar istekleri işler.
+func (s *lspServer) serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := bufio.NewReader(in)
+	for {
+		b, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			s.send(rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: err.Error()}})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("lsp: exit without shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(req.Method, req.Params)
+		if req.ID == nil {
+			continue // notification
+		}
+		resp := rpcResponse{ID: req.ID}
+		if err != nil {
+			var rerr *rpcError
+			if !errors.As(err, &rerr) {
+				rerr = &rpcError{Code: -32603, Message: err.Error()}
+			}
+			resp.Error = rerr
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			return err
+		}
+		s.send(resp)
+	}
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func (s *lspServer) send(m rpcResponse) {
+	m.JSONRPC = "2.0"
+	b, _ := json.Marshal(m)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(b), b)
+}
+
+func (s *lspServer) notify(method string, params interface{}) {
+	s.send(rpcResponse{Method: method, Params: params})
+}
+
+// LSP tipleri, kullanılan alanlarıyla.
+type (
+	lspPosition struct {
+		Line      int `json:"line"`
+		Character int `json:"character"` // UTF-16 birimi
+	}
+	lspRange struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	}
+	lspLocation struct {
+		URI   string   `json:"uri"`
+		Range lspRange `json:"range"`
+	}
+	lspDocumentPosition struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Position lspPosition `json:"position"`
+	}
+	lspDiagnostic struct {
+		Range    lspRange `json:"range"`
+		Severity int      `json:"severity"`
+		Source   string   `json:"source"`
+		Message  string   `json:"message"`
+	}
+	lspCompletionItem struct {
+		Label            string     `json:"label"`
+		Kind             int        `json:"kind"`
+		Detail           string     `json:"detail,omitempty"`
+		Documentation    *lspMarkup `json:"documentation,omitempty"`
+		InsertText       string     `json:"insertText,omitempty"`
+		InsertTextFormat int        `json:"insertTextFormat,omitempty"` // 2: snippet
+	}
+	lspMarkup struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+)
+
+// completion item türleri
+const (
+	lspKindFunction = 3
+	lspKindVariable = 6
+	lspKindKeyword  = 14
+)
+
+// handle: bir isteğin sonucu; notification'lar için nil.
+func (s *lspServer) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1, // full
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"{", "|", " "}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "vingo"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		s.update(p.TextDocument.URI, p.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p lspDocumentPosition
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		s.docs.remove(uriPath(p.TextDocument.URI))
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": p.TextDocument.URI, "diagnostics": []lspDiagnostic{}})
+		return nil, nil
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var p lspDocumentPosition
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		path := uriPath(p.TextDocument.URI)
+		text, ok := s.docs.text(path)
+		if !ok {
+			return nil, nil
+		}
+		off := offsetAt(text, p.Position)
+		switch method {
+		case "textDocument/completion":
+			return s.complete(text, off), nil
+		case "textDocument/hover":
+			return s.hover(text, off), nil
+		default:
+			return s.definition(path, text, off), nil
+		}
+	}
+	if strings.HasPrefix(method, "$/") || method == "initialized" || strings.HasPrefix(method, "textDocument/did") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: -32601, Message: "method not found: " + method}
+}
+
+// update: açık dokümanın yeni içeriği; diagnostic'leri yeniden yayınlar.
+func (s *lspServer) update(uri, text string) {
+	path := uriPath(uri)
+	s.docs.set(path, text)
+	diags := []lspDiagnostic{}
+	found, err := s.engine.Check(path)
+	if err != nil {
+		diags = append(diags, lspDiagnostic{Severity: 1, Source: "vingo", Message: err.Error()})
+	}
+	for _, d := range found {
+		// tag'in tamamı işaretlenir
+		off := byteOffset(text, d.Line, d.Col)
+		end := off + 1
+		if i := strings.Index(text[off:], "}>"); i >= 0 && strings.HasPrefix(text[off:], "<{") {
+			end = off + i + 2
+		}
+		diags = append(diags, lspDiagnostic{
+			Range:    lspRange{Start: positionAt(text, off), End: positionAt(text, min(end, len(text)))},
+			Severity: 1,
+			Source:   "vingo",
+			Message:  d.Message,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": uri, "diagnostics": diags})
+}
+
+// -------------------- Completion, hover, definition --------------------
+
+// openTag: off'un içinde bulunduğu tag'in "<{" offset'i, tag dışındaysa -1.
+func openTag(text string, off int) int {
+	start := strings.LastIndex(text[:off], "<{")
+	if start < 0 || strings.Contains(text[start:off], "}>") {
+		return -1
+	}
+	return start
+}
+
+// tagWord: off'ta biten (tag, değişken ya da fonksiyon) kelimesinin başı.
+func tagWord(text string, off int) int {
+	i := off
+	for i > 0 && isWordByte(text[i-1]) {
+		i--
+	}
+	return i
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '/' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (s *lspServer) complete(text string, off int) []lspCompletionItem {
+	start := openTag(text, off)
+	if start < 0 {
+		return []lspCompletionItem{}
+	}
+	wordStart := tagWord(text, off)
+	before := strings.TrimSpace(text[start+2 : wordStart])
+	prefix := text[wordStart:off]
+	var items []lspCompletionItem
+
+	if before == "" {
+		for _, t := range lspTags {
+			if strings.HasPrefix(t.name, prefix) {
+				items = append(items, lspCompletionItem{
+					Label: t.name, Kind: lspKindKeyword, Documentation: &lspMarkup{"markdown", t.doc},
+					InsertText: t.snippet, InsertTextFormat: 2,
+				})
+			}
+		}
+	}
+	if !strings.HasSuffix(before, "|") {
+		for _, v := range templateVariables(text) {
+			if strings.HasPrefix(v, prefix) && v != prefix {
+				items = append(items, lspCompletionItem{Label: v, Kind: lspKindVariable})
+			}
+		}
+	}
+	for _, name := range s.engine.FuncNames() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		item := lspCompletionItem{Label: name, Kind: lspKindFunction, Detail: "function"}
+		if doc, ok := lspFuncDocs[name]; ok {
+			item.Documentation = &lspMarkup{"markdown", doc}
+		}
+		items = append(items, item)
+	}
+	if items == nil {
+		items = []lspCompletionItem{}
+	}
+	return items
+}
+
+var (
+	identPattern  = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	stringPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+	tagPattern    = regexp.MustCompile(`(?s)<\{(.*?)\}>`)
+)
+
+// templateVariables: dokümanın tag'lerinde geçen değişken isimleri;
+// keyword'ler, fonksiyonlar ve alan erişimleri (.Name) hariç.
+func templateVariables(text string) []string {
+	skip := map[string]bool{"in": true, "where": true, "sort": true, "by": true, "asc": true, "desc": true, "and": true, "or": true, "not": true, "matches": true, "true": true, "false": true, "nil": true, "build": true}
+	for _, t := range lspTags {
+		skip[t.name] = true
+	}
+	seen := map[string]bool{}
+	for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+		body := stringPattern.ReplaceAllString(m[1], `""`)
+		for _, loc := range identPattern.FindAllStringIndex(body, -1) {
+			name := body[loc[0]:loc[1]]
+			if loc[0] > 0 && (body[loc[0]-1] == '.' || body[loc[0]-1] == '/') {
+				continue
+			}
+			if rest := strings.TrimLeft(body[loc[1]:], " "); strings.HasPrefix(rest, "(") || strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==") {
+				continue // fonksiyon ya da keyword argüman
+			}
+			if loc[0] > 0 && strings.HasSuffix(strings.TrimRight(body[:loc[0]], " "), "|") {
+				continue // filter
+			}
+			if !skip[name] {
+				seen[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *lspServer) hover(text string, off int) interface{} {
+	start := openTag(text, off)
+	if start < 0 {
+		return nil
+	}
+	end := off
+	for end < len(text) && isWordByte(text[end]) {
+		end++
+	}
+	wordStart := tagWord(text, off)
+	word := strings.TrimRight(text[wordStart:end], ".")
+	if i := strings.IndexByte(word, '.'); i >= 0 {
+		word = word[:i]
+	}
+	if word == "" {
+		return nil
+	}
+	doc := ""
+	if strings.TrimSpace(text[start+2:wordStart]) == "" {
+		for _, t := range lspTags {
+			if t.name == word {
+				doc = t.doc
+			}
+		}
+	}
+	if doc == "" {
+		for _, name := range s.engine.FuncNames() {
+			if name == word {
+				doc = lspFuncDocs[name]
+				if doc == "" {
+					doc = "`" + name + "`: template function"
+				}
+			}
+		}
+	}
+	if doc == "" && word == "loop" {
+		doc = "`loop`: the current for iteration: `loop.Index`, `loop.First`, `loop.Last`, `loop.Length`."
+	}
+	if doc == "" {
+		return nil
+	}
+	return map[string]interface{}{"contents": lspMarkup{"markdown", doc}}
+}
+
+// includePattern: include tag'inin path'i.
+var includePattern = regexp.MustCompile(`^\s*include\s+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`)
+
+// definition: include tag'inin üzerindeyse include edilen dosya.
+func (s *lspServer) definition(path, text string, off int) interface{} {
+	start := openTag(text, off)
+	if start < 0 {
+		return nil
+	}
+	end := strings.Index(text[start:], "}>")
+	if end < 0 {
+		end = len(text) - start
+	}
+	m := includePattern.FindStringSubmatch(text[start+2 : start+end])
+	if m == nil {
+		return nil
+	}
+	target := m[1][1 : len(m[1])-1]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	if _, err := s.docs.ModTime(target); err != nil {
+		return nil
+	}
+	return lspLocation{URI: pathURI(target)}
+}
+
+// -------------------- Positions and URIs --------------------
+
+// offsetAt: LSP pozisyonunun (UTF-16) text içindeki byte offset'i.
+func offsetAt(text string, pos lspPosition) int {
+	off := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		off += i + 1
+	}
+	for units := 0; units < pos.Character && off < len(text) && text[off] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		units += len(utf16.Encode([]rune{r}))
+		off += size
+	}
+	return off
+}
+
+// byteOffset: 1'den başlayan satır ve byte sütununun offset'i.
+func byteOffset(text string, line, col int) int {
+	off := 0
+	for l := 1; l < line; l++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		off += i + 1
+	}
+	return min(off+col-1, len(text))
+}
+
+// positionAt: byte offset'in LSP pozisyonu.
+func positionAt(text string, off int) lspPosition {
+	lineStart := strings.LastIndexByte(text[:off], '\n') + 1
+	units := 0
+	for _, r := range text[lineStart:off] {
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return lspPosition{Line: strings.Count(text[:lineStart], "\n"), Character: units}
+}
+
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func pathURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// -------------------- Open documents --------------------
+
+// overlayLoader: editörde açık dokümanları diskteki hallerinin yerine okur.
+type overlayLoader struct {
+	mu   sync.Mutex
+	docs map[string]*overlayDoc
+}
+
+type overlayDoc struct {
+	text string
+	mod  time.Time
+}
+
+func (l *overlayLoader) set(path, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.docs[path] = &overlayDoc{text: text, mod: time.Now()}
+}
+
+func (l *overlayLoader) remove(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.docs, path)
+}
+
+func (l *overlayLoader) text(path string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	d, ok := l.docs[path]
+	if !ok {
+		return "", false
+	}
+	return d.text, true
+}
+
+func (l *overlayLoader) ModTime(path string) (time.Time, error) {
+	l.mu.Lock()
+	d, ok := l.docs[path]
+	l.mu.Unlock()
+	if ok {
+		return d.mod, nil
+	}
+	return vingo.FileLoader{}.ModTime(path)
+}
+
+func (l *overlayLoader) ReadFile(path string) ([]byte, error) {
+	if text, ok := l.text(path); ok {
+		return []byte(text), nil
+	}
+	return vingo.FileLoader{}.ReadFile(path)
+}
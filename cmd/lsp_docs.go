@@ -0,0 +1,120 @@
+package main
+
+// lspTag: completion ve hover için bir tag keyword'ü.
+type lspTag struct {
+	name, snippet, doc string
+}
+
+// lspTags: tag keyword'leri, tag'in başında tamamlanır.
+var lspTags = []lspTag{
+	{"if", "if ${1:cond}", "`<{ if cond }> ... <{ elseif cond }> ... <{ else }> ... <{ /if }>`\n\nRenders the first branch whose condition is true."},
+	{"elseif", "elseif ${1:cond}", "`<{ elseif cond }>`: another branch of an if."},
+	{"else", "else", "`<{ else }>`: rendered when no branch of the if is taken."},
+	{"/if", "/if", "Closes an if."},
+	{"for", "for ${1:item} in ${2:list}", "`<{ for item in list }> ... <{ /for }>`, `<{ for i, item in list }>`\n\nRepeats the body for every element. `loop.Index`, `loop.First`, `loop.Last` and `loop.Length` describe the iteration. `<{ for p in products where p.InStock }>` skips the elements failing the condition, `sort by p.Price desc` sorts them."},
+	{"/for", "/for", "Closes a for."},
+	{"switch", "switch ${1:expr}", "`<{ switch expr }> <{ case value }> ... <{ default }> ... <{ /switch }>`\n\nRenders the first matching case; the value is `__switch__` in case conditions and bodies, and `<{ case > 100 }>` compares it directly.\n\nAs an expression: `switch status case \"paid\": \"green\" default: \"gray\"`."},
+	{"case", "case ${1:value}", "`<{ case value }>`: a case of a switch; a value, a condition or a comparison with the value like `case >= 18`."},
+	{"default", "default", "`<{ default }>`: rendered when no case of the switch matches."},
+	{"/switch", "/switch", "Closes a switch."},
+	{"block", `block "${1:name}"`, "`<{ block \"name\" }> ... <{ /block }>`\n\nNamed part of the template, renderable on its own with RenderBlock."},
+	{"/block", "/block", "Closes a block."},
+	{"cache", `cache "${1:key}"`, "`<{ cache \"key\" vary=[...] per=5m ttl=1h stale=10m }> ... <{ /cache }>`\n\nCaches the rendered body in the fragment store."},
+	{"/cache", "/cache", "Closes a cache."},
+	{"csv", "csv header=[${1}]", "`<{ csv delimiter=\",\" header=[\"Name\", \"Email\"] crlf=true }> ... <{ /csv }>`\n\nCSV/TSV export: only row tags write output inside the block."},
+	{"/csv", "/csv", "Closes a csv."},
+	{"row", "row ${1:fields}", "`<{ row u.Name u.Email }>`: one CSV record of the enclosing csv block, fields quoted and escaped."},
+	{"section", `section "${1:name}"`, "`<{ section \"scripts\" }> ... <{ /section }>`\n\nAdds the body to a named section of the layout instead of rendering it in place; sections of the same name are appended, `replace=true` replaces them."},
+	{"/section", "/section", "Closes a section."},
+	{"yield", `yield "${1:name}"`, "`<{ yield \"scripts\" }>`: the content added to the section so far (in a layout: by the page)."},
+	{"component", `component "${1:name}"`, "`<{ component \"modal\" title=\"Delete?\" }> ... <{ slot \"footer\" }> ... <{ /slot }> <{ /component }>`\n\nRenders components/modal.vgo (Engine.Components) with the keyword arguments; the body fills its slots, the part outside slot tags is the default slot."},
+	{"/component", "/component", "Closes a component."},
+	{"slot", `slot "${1:name}"`, "`<{ slot \"footer\" }> ... <{ /slot }>`: fills a named slot of the enclosing component tag."},
+	{"/slot", "/slot", "Closes a slot."},
+	{"once", "once", "`<{ once }> ... <{ /once }>`, `<{ once \"chart.js\" }>`\n\nRenders the body only the first time in a render (per tag, or per key across templates): scripts and styles of partials included many times."},
+	{"/once", "/once", "Closes a once."},
+	{"set", "set ${1:name} = ${2:value}", "`<{ set badge = switch status case \"paid\": \"green\" default: \"gray\" }>`\n\nAssigns a variable for the rest of the render; loop variables of the same name shadow it."},
+	{"include", `include "${1:path}"`, "`<{ include \"partials/header.vgo\" title=\"Home\" }>`\n\nRenders another template in place; the path is relative to this file, keyword arguments add variables. The path can be an expression (`include \"widgets/\" + w.Type + \".vgo\"`) for templates allowed by Engine.DynamicIncludes."},
+	{"t", `t "${1:key}"`, "`<{ t \"cart.items\" count=n }>`\n\nTranslation of the key in the locale of the render, from Engine.I18n.Catalog; keyword arguments fill {name} placeholders, count selects the plural form."},
+	{"test", `test "${1:name}"`, "`<{ test \"name\" data={...} contains \"text\" }>`\n\nTest case run by `vingo test`; not rendered."},
+	{"params", "params ${1:name} ${2:type}", "`<{ params user *models.User, items []models.Item }>`\n\nGo parameters of the function `vingo generate` writes for this template, which then takes them instead of a data map; not rendered."},
+	{"import", `import "${1:path}"`, "`<{ import \"example.com/app/models\" }>`: Go package imported by the code `vingo generate` writes, for the types of params; not rendered."},
+}
+
+// lspFuncDocs: yerleşik fonksiyonların açıklamaları; engine'e eklenmiş
+// fonksiyonlar sadece isimleriyle gösterilir.
+var lspFuncDocs = map[string]string{
+	"upper":             "`x | upper`: upper case.",
+	"lower":             "`x | lower`: lower case.",
+	"escape":            "`x | escape`: HTML-escapes the value.",
+	"dict":              "`dict(\"a\", 1, \"b\", 2)`, `dict(a=1)`: a map, like the literal `{\"a\": 1, b: 2}`.",
+	"list":              "`list(1, 2, 3)`: a list, like the literal `[1, 2, 3]`.",
+	"default":           "`x | default:other`, `x | default:0`: the argument when x is undefined or empty (\"\", an empty list or map); `x | \"text\"` is the short form for strings.",
+	"merge":             "`merge(a, b, ...)`: new map with the entries of the maps, later ones winning; deep=true merges nested maps.",
+	"defaults":          "`defaults(opts, {color: \"blue\"})`: copy of the map with missing or nil entries taken from the defaults, nested maps too.",
+	"asset":             "`asset(\"img/logo.svg\")`: URL of a static file, with the asset prefix; with Assets.Hash or Assets.Manifest the file name carries a content hash.",
+	"integrity":         "`integrity(\"js/app.js\")`: SRI hash (sha384-...) of a static file.",
+	"script":            "`script(\"js/app.js\", defer=true)`: `<script>` tag with integrity attribute.",
+	"stylesheet":        "`stylesheet(\"css/app.css\")`: `<link rel=\"stylesheet\">` tag with integrity attribute.",
+	"image":             "`image(\"hero.jpg\", widths=[480, 960], sizes=\"50vw\", alt=\"...\")`: responsive `<img>` with srcset; formats=[\"avif\", \"webp\"] emits a `<picture>`.",
+	"t":                 "`t(\"key\", count=n)`: translation of the key in the locale of the render; keyword arguments fill {name} placeholders.",
+	"pluralize":         "`n | pluralize`, `n | pluralize:\"item\",\"items\"`: \"s\" (or the plural form) unless n (a number or a list) is 1.",
+	"ordinal":           "`n | ordinal`: 1st, 2nd, 3rd, 11th...",
+	"humanize_bytes":    "`size | humanize_bytes`: byte count as \"1.5 KB\", \"2 MB\" (powers of 1024).",
+	"filesizeformat":    "`size | filesizeformat`: same as humanize_bytes.",
+	"humanize_duration": "`d | humanize_duration`: a duration (or seconds) as \"2 hours 5 minutes\".",
+	"toint":             "`x | toint`, `x | toint:1`: the value as an integer (\"3.9\" -> 3), the argument (0 without one) when it isn't a number.",
+	"tofloat":           "`x | tofloat`, `x | tofloat:0`: the value as a float, the argument (0 without one) when it isn't a number.",
+	"todate":            "`x | todate`, `x | todate:\"02.01.2006\"`: the string parsed as a time with a Go layout (RFC 3339 or 2006-01-02 forms without one); undefined if it doesn't parse.",
+	"now":               "`now()`: the current time, from Engine.Clock; compares with times and date strings: `event.StartsAt > now()`.",
+	"today":             "`today()`: midnight of the current day, from Engine.Clock.",
+	"markdown":          "`text | markdown`: markdown converted to HTML with Engine.Markdown (safe BasicMarkdown by default).",
+	"json":              "`x | json`: the value as JSON, safe inside `<script>` (<, > and & are escaped); json:2 indents.",
+	"dir":               "`dir(locale)`: \"rtl\" or \"ltr\" for a locale or a text.",
+	"isolate":           "`x | isolate`: wraps the value in Unicode isolates so it doesn't reorder the surrounding text; isolate:\"ltr\" / isolate:\"rtl\" force the direction.",
+	"errors_for":        "`errors_for(\"field\")`: validation messages of a form field, from the `errors` variable.",
+	"has_error":         "`has_error(\"field\")`: whether a form field has validation errors.",
+	"error_list":        "`error_list(\"field\")`: renders the error list of a field, or of every field without an argument.",
+	"old":               "`old(\"field\", default)`: previously submitted value of a form field, from the `old` variable.",
+	"input":             "`input(\"email\", user.Email, type=\"email\")`: `<input>` repopulated from `old`, with Forms.ErrorClass and aria-invalid when the field has errors; keyword arguments are attributes.",
+	"textarea":          "`textarea(\"bio\", user.Bio, rows=5)`: `<textarea>` repopulated from `old`, like input.",
+	"select":            "`select(\"country\", options, selected)`: `<select>` from a list of values, [value, label] pairs or a value -> label map; the submitted value wins.",
+	"sort":              "`users | sort:\"Name\"`, `events | sort:\"StartsAt\",\"desc\"`: a sorted copy of the list (stable), by a field or by the elements; times, numbers and strings compare by type.",
+	"reverse":           "`items | reverse`: a copy of the list in reverse order.",
+	"regex_replace":     "`phone | regex_replace:\"[^0-9]\":\"\"`: every match of the pattern replaced; $1 in the replacement is a submatch. See also the `matches` operator: `email matches \"^[^@]+@\"`.",
+	"cycle":             "`cycle(\"odd\", \"even\")`: the arguments in turn, one per iteration of the enclosing loop (striped rows).",
+	"min":               "`min(a, b)`, `min(list)`: the smallest value; numbers, strings and times compare by type.",
+	"max":               "`max(a, b)`, `max(list)`: the largest value.",
+	"abs":               "`abs(x)`: the absolute value of a number.",
+	"round":             "`round(x)`, `round(rating, 1)`: the number rounded to the given decimals (an integer without).",
+	"ceil":              "`x | ceil`: the smallest integer not below x.",
+	"floor":             "`x | floor`: the largest integer not above x.",
+	"sum":               "`items | pluck:\"Total\" | sum`: the sum of a list of numbers.",
+	"avg":               "`avg(ratings)`: the mean of a list of numbers, undefined if empty.",
+	"length":            "`items | length`, `len(name)`: the number of items of a list or map, or characters of a string; `len(x)` is 0 when x is undefined.",
+	"count":             "`items | count`: same as length.",
+	"len":               "`len(items)`: same as length.",
+	"join":              "`tags | join:\", \"`: the items of a list as text, separated by the argument.",
+	"split":             "`line | split:\",\"`: the parts of a string as a list; without a separator, the words.",
+	"pluck":             "`users | pluck:\"Name\"`: the field (or dotted path) of every item, as a list.",
+	"first":             "`list | first`: the first item (character of a string), undefined if empty.",
+	"last":              "`list | last`: the last item (character of a string), undefined if empty.",
+	"limit":             "`posts | limit:5`: the first n items.",
+	"offset":            "`posts | offset:20`: the items after the first n.",
+	"slice":             "`posts | slice:2:5`, `slice:-3`: items from start up to end (exclusive); negative indexes count from the end.",
+	"batch":             "`products | batch:3`, `batch:3,\"\"`: the list split into rows of n items for grids; a second argument pads the last row.",
+	"group_by":          "`products | group_by:\"Category\"`: the items grouped by a field, in order of first appearance; each group has Key and Items.",
+	"slot":              "`slot()`, `slot(\"footer\")`: in a component template, the default or a named slot filled by the component tag; undefined if not filled.",
+	"checkbox":          "`checkbox(\"newsletter\", checked, value=\"on\")`: checkbox `<input>`, checked from `old` after a submission.",
+	"paginate":          "`paginate(page, per_page, total, window=2)`: page window of a list: Page, Pages, Offset, From, To, HasPrev, HasNext, Prev, Next and Window.",
+	"pagination":        "`pagination(p, url=\"/posts?page={page}\")`: accessible `<nav>` of prev/next and page links for a paginate() result; label, prev and next set the texts.",
+	"flash":             "`flash()`, `flash(\"error\")`: renders the pending flash messages (of a kind) from Engine.Flashes.",
+	"og_image":          "`og_image(title=..., ...)`: URL of a social preview PNG rendered from Engine.OGImages.Template with the keyword arguments.",
+	"ical_escape":       "`x | ical_escape`: escapes a TEXT value of an iCalendar file (backslash, comma, semicolon, line breaks).",
+	"vcard_escape":      "`x | vcard_escape`: escapes a TEXT value of a vCard, like ical_escape.",
+	"ical_time":         "`t | ical_time`: UTC date-time of an iCalendar file (20060102T150405Z); ical_time:\"date\" gives a DATE value.",
+	"csrf_token":        "`csrf_token()`: CSRF token of the request, stored in the render context by CSRFToken middleware (or WithCSRFToken).",
+	"csrf_field":        "`csrf_field()`, `csrf_field(name=\"_csrf\")`: hidden input with the CSRF token of the request.",
+	"csp_nonce":         "`csp_nonce()`: CSP nonce of the request, from CSPNonce middleware (or WithCSPNonce); script() and stylesheet() add it by themselves.",
+	"honeypot":          "`honeypot()`: hidden decoy field and signed timestamp, validated by FormGuard.Middleware.",
+}
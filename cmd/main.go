@@ -1,44 +1,164 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// command: bir vingo alt komutu.
+type command struct {
+	name    string
+	args    string // flag'lerden sonraki argümanlar, kullanım satırı için
+	summary string // `vingo help` listesindeki açıklama
+
+	// run: komutu çalıştırır. fset boştur; komut flag'lerini tanımlayıp
+	// fset.Parse(args) çağırır, --help ve hatalı flag'leri dispatcher raporlar.
+	run func(fset *flag.FlagSet, args []string) error
+}
+
+// commands: alfabetik sırayla; `vingo help` bu sırayla listeler.
+var commands = []*command{
+	{name: "ast", args: "<template> [flags]", summary: "print the syntax tree of a template with positions", run: ast},
+	{name: "build", args: "[flags]", summary: "render a content directory into a static site", run: build},
+	{name: "check", args: "[file|dir|dir/...]...", summary: "report problems in templates without rendering them", run: check},
+	{name: "deps", args: "[flags] [file|dir]...", summary: "print the templates each template includes", run: deps},
+	{name: "fmt", args: "[flags] [file|dir]...", summary: "format templates in the canonical style", run: format},
+	{name: "generate", args: "[flags] <file|dir>...", summary: "compile templates to Go code", run: generate},
+	{name: "lsp", summary: "run the language server for editors (stdin/stdout)", run: lsp},
+	{name: "migrate", args: "[flags] <file>...", summary: "convert Go html/template or Jinja2 templates to vingo", run: migrate},
+	{name: "render", args: "<template> [flags]", summary: "render a template with data from a file or stdin", run: render},
+	{name: "serve", args: "[flags]", summary: "serve templates over HTTP with live reload", run: serve},
+	{name: "test", args: "[flags] [file|dir]...", summary: "run the <{ test }> tags and golden files of templates", run: test},
+	{name: "theme-diff", args: "[flags] <base-theme> <customized-theme>", summary: "report and merge drift of a customized theme", run: themeDiff},
+	{name: "vscode-ext", args: "[flags]", summary: "generate a VSCode extension with syntax highlighting and snippets", run: vscodeExt},
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Kullanım: vingo <komut>")
-		return
+		usage(os.Stderr)
+		os.Exit(2)
 	}
-
-	switch os.Args[1] {
-	case "create":
-		dir := ".vscode"
-		file := filepath.Join(dir, "settings.json")
-
-		// Klasörü oluştur
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			fmt.Println("Klasör oluşturulamadı:", err)
+	name, args := os.Args[1], os.Args[2:]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) == 0 {
+			usage(os.Stdout)
 			return
 		}
+		// vingo help <komut> = vingo <komut> --help
+		name, args = args[0], []string{"--help"}
+	}
+	c := lookup(name)
+	if c == nil {
+		fmt.Fprintln(os.Stderr, msgf("vingo: unknown command %q", name))
+		fmt.Fprintln(os.Stderr, msg(`Run "vingo help" for the list of commands.`))
+		os.Exit(2)
+	}
+	os.Exit(c.exec(args))
+}
 
-		// JSON içeriği
-		content := `{
-    "files.associations": {
-        "*.vgo": "html"
-    }
-}`
-
-		// Dosyayı yaz
-		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
-			fmt.Println("Dosya yazılamadı:", err)
-			return
+// lookup: name isimli komut, yoksa nil.
+func lookup(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
 		}
+	}
+	return nil
+}
 
-		fmt.Println(".vscode/settings.json başarıyla oluşturuldu ✅")
+// usage: komut listesini w'ye yazar.
+func usage(w *os.File) {
+	fmt.Fprintln(w, msg("Usage: vingo <command> [arguments]"))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, msg("Commands:"))
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", c.name, msg(c.summary))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, msg(`Run "vingo help <command>" for the flags of a command.`))
+}
 
-	default:
-		fmt.Println("Bilinmeyen komut:", os.Args[1])
+// exec: komutu çalıştırır ve çıkış kodunu döner: 0 başarılı (ve --help),
+// 1 komut hatası, 2 hatalı kullanım.
+func (c *command) exec(args []string) int {
+	fset := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	usageShown := false
+	fset.Usage = func() {
+		usageShown = true
+		c.usage(fset)
+	}
+
+	err := c.run(fset, args)
+	var uerr usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &uerr):
+		fmt.Fprintf(os.Stderr, "vingo %s: %v\n", c.name, err)
+		c.usage(fset)
+		return 2
+	case usageShown:
+		// hatalı flag; flag paketi hatayı ve kullanımı zaten yazdı
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "vingo %s: %v\n", c.name, err)
+	return 1
+}
+
+// usage: komutun kullanımı ve flag'leri, stderr'e.
+func (c *command) usage(fset *flag.FlagSet) {
+	w := fset.Output()
+	fmt.Fprintf(w, "%s %s\n\n", msg("Usage:"), strings.TrimSpace("vingo "+c.name+" "+c.args))
+	fmt.Fprintf(w, "%s\n", msg(c.summary))
+	hasFlags := false
+	fset.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(w, "\n%s\n", msg("Flags:"))
+		// flag açıklamaları da çevrilir
+		fset.VisitAll(func(f *flag.Flag) { f.Usage = msg(f.Usage) })
+		fset.PrintDefaults()
+	}
+}
+
+// usageError: hatalı argümanlar; komutun kullanımıyla raporlanır, çıkış kodu 2.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// errUsage: komutun argümanları hatalı.
+func errUsage(format string, args ...interface{}) error {
+	return usageError(msgf(format, args...))
+}
+
+// templateFiles: args içindeki dosyalar ve klasörlerdeki (recursive) .vgo /
+// .vingo dosyaları. Go tarzı "views/..." da kabul edilir.
+func templateFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		arg = strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+		if arg == "" {
+			arg = "."
+		}
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (path == arg || strings.HasSuffix(path, ".vgo") || strings.HasSuffix(path, ".vingo")) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
+	return files, nil
 }
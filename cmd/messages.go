@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// -------------------- Messages --------------------
+//
+// CLI mesajları İngilizce yazılır ve kullanıcının diline çevrilir. Dil
+// VINGO_LANG, LC_ALL, LC_MESSAGES, LANG sırasıyla ilk dolu olandan alınır
+// ("tr_TR.UTF-8" -> "tr"). Çevirisi olmayan mesajlar İngilizce kalır; yeni
+// bir dil için catalogs'a mesaj -> çeviri tablosu eklenir. Format
+// string'leri çevirilerde aynı sırayla korunmalıdır.
+
+// catalogs: dil -> (İngilizce mesaj -> çeviri).
+var catalogs = map[string]map[string]string{
+	"tr": messagesTR,
+}
+
+// catalog: kullanıcının dilinin tablosu, İngilizce için nil.
+var catalog = sync.OnceValue(func() map[string]string {
+	for _, env := range []string{"VINGO_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(v), "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		return catalogs[lang]
+	}
+	return nil
+})
+
+// msg: s'nin çevirisi, yoksa s.
+func msg(s string) string {
+	if t, ok := catalog()[s]; ok {
+		return t
+	}
+	return s
+}
+
+// msgf: çevrilmiş format ile fmt.Sprintf.
+func msgf(format string, args ...interface{}) string {
+	return fmt.Sprintf(msg(format), args...)
+}
+
+// errorf: çevrilmiş format ile fmt.Errorf (%w desteklenir).
+func errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(msg(format), args...)
+}
+
+// printf: çevrilmiş format ile stdout'a yazar.
+func printf(format string, args ...interface{}) {
+	fmt.Printf(msg(format), args...)
+}
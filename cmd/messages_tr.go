@@ -0,0 +1,125 @@
+package main
+
+// messagesTR: Türkçe mesajlar.
+var messagesTR = map[string]string{
+	// main
+	"Usage: vingo <command> [arguments]": "Kullanım: vingo <komut> [argümanlar]",
+	"Usage:":                             "Kullanım:",
+	"Commands:":                          "Komutlar:",
+	"Flags:":                             "Flag'ler:",
+	`Run "vingo help <command>" for the flags of a command.`: `Bir komutun flag'leri için "vingo help <komut>" çalıştırın.`,
+	`Run "vingo help" for the list of commands.`:             `Komut listesi için "vingo help" çalıştırın.`,
+	"vingo: unknown command %q":                              "vingo: bilinmeyen komut %q",
+	"unexpected arguments: %v":                               "beklenmeyen argümanlar: %v",
+	"could not create directory: %w":                         "Klasör oluşturulamadı: %w",
+	"could not write file: %w":                               "Dosya yazılamadı: %w",
+
+	// komut açıklamaları
+	"print the syntax tree of a template with positions":                "template'in node ağacını pozisyonlarıyla yaz",
+	"render a content directory into a static site":                     "içerik klasöründen statik site üret",
+	"report problems in templates without rendering them":               "template'lerdeki sorunları render etmeden raporla",
+	"print the templates each template includes":                        "her template'in include ettiği template'leri yaz",
+	"generate a VSCode extension with syntax highlighting and snippets": "renklendirme ve snippet'ler içeren bir VSCode eklentisi üret",
+	"format templates in the canonical style":                           "template'leri standart stile getir",
+	"run the language server for editors (stdin/stdout)":                "editörler için language server'ı çalıştır (stdin/stdout)",
+	"compile templates to Go code":                                      "template'leri Go koduna derle",
+	"convert Go html/template or Jinja2 templates to vingo":             "Go html/template ya da Jinja2 template'lerini vingo'ya çevir",
+	"render a template with data from a file or stdin":                  "template'i dosyadaki ya da stdin'deki data ile render et",
+	"serve templates over HTTP with live reload":                        "template'leri canlı yenilemeyle HTTP üzerinden sun",
+	"run the <{ test }> tags and golden files of templates":             "template'lerdeki <{ test }> tag'lerini ve golden dosyalarını çalıştır",
+	"report and merge drift of a customized theme":                      "özelleştirilmiş temanın farklarını raporla ve birleştir",
+
+	// ast
+	"output format (json)":      "çıktı formatı (json)",
+	"unknown output format: %s": "bilinmeyen çıktı formatı: %s",
+
+	// build
+	"content directory": "içerik klasörü",
+	"output directory":  "çıktı klasörü",
+	"data file given to every page (.json, .yaml, .toml)":                                                                  "bütün sayfalara verilen data dosyası (.json, .yaml, .toml)",
+	"template of og_image() images, relative to src":                                                                       "og_image() resimlerinin template'i, src'ye göre",
+	`command converting HTML on stdin to PNG on stdout, e.g. "wkhtmltoimage --width {width} --height {height} -f png - -"`: `stdin'deki HTML'i stdout'a PNG olarak çeviren komut, ör. "wkhtmltoimage --width {width} --height {height} -f png - -"`,
+	"--og-template requires --og-command":                                                                                  "--og-template için --og-command gerekli",
+	`build cache file, pages whose inputs did not change are skipped ("" = no cache)`:                                      `build cache dosyası, girdileri değişmeyen sayfalar atlanır ("" = cache yok)`,
+	"render every page, ignoring the build cache":                                                                          "build cache'e bakmadan bütün sayfaları render et",
+	"%d pages (%d unchanged), %d files -> %s\n":                                                                            "%d sayfa (%d değişmedi), %d dosya -> %s\n",
+	"could not write build cache: %w":                                                                                      "build cache yazılamadı: %w",
+	"could not hash data: %w":                                                                                              "data hash'lenemedi: %w",
+	"number of pages rendered in parallel":                                                                                 "paralel render edilen sayfa sayısı",
+	"--jobs must be at least 1":                                                                                            "--jobs en az 1 olmalı",
+	"write a JSON manifest of the routes (path, template, data files, last modified) to this file":                         "route'ların JSON listesini (yol, template, data dosyaları, son değişiklik) bu dosyaya yaz",
+	"%d of %d pages failed":                                                                                                "%d/%d sayfa başarısız",
+	"%d pages, %d files -> %s\n":                                                                                           "%d sayfa, %d dosya -> %s\n",
+
+	// deps
+	"print the include graph in Graphviz DOT format":        "include grafiğini Graphviz DOT formatında yaz",
+	"print the templates affected by a change to this file": "bu dosya değişince etkilenen template'leri yaz",
+
+	// migrate
+	"source syntax: html/template or jinja":              "kaynak sözdizimi: html/template ya da jinja",
+	"write file.vgo next to each file instead of stdout": "stdout yerine her dosyanın yanına dosya.vgo yaz",
+	"no files given":                       "dosya verilmedi",
+	"several files require --write":        "birden fazla dosya için --write gerekli",
+	"unknown source syntax: %s":            "bilinmeyen kaynak sözdizimi: %s",
+	"%s:%d: cannot convert %s: %v":         "%s:%d: %s çevrilemedi: %v",
+	"%s: unclosed block":                   "%s: kapanmamış blok",
+	"%d constructs could not be converted": "%d yapı çevrilemedi",
+
+	// check
+	"%d problems in %d files":           "%[2]d dosyada %[1]d sorun bulundu",
+	"%d files checked, no problems ✅\n": "%d dosya kontrol edildi, sorun yok ✅\n",
+
+	// generate, vscode-ext
+	"%s created ✅\n":                    "%s başarıyla oluşturuldu ✅\n",
+	"output directory of the extension": "eklentinin çıktı klasörü",
+	"Install: copy it to ~/.vscode/extensions/ or package it with `vsce package`\n": "Kurulum: ~/.vscode/extensions/ altına kopyalayın ya da `vsce package` ile paketleyin\n",
+	"package name of the generated file":                                            "üretilen dosyanın paket adı",
+	"output file (empty = stdout)":                                                  "çıktı dosyası (boş = stdout)",
+	"no templates given":                                                            "template verilmedi",
+	"%s created (%d templates) ✅\n":                                                 "%s oluşturuldu (%d template) ✅\n",
+
+	// fmt
+	"list files that need formatting, don't change them":  "formatlanması gereken dosyaları listele, değiştirme",
+	"overwrite the files":                                 "dosyaların üzerine yaz",
+	"%d files not formatted (fix with vingo fmt --write)": "%d dosya formatlı değil (vingo fmt --write ile düzeltin)",
+
+	// render, serve
+	"data file (.json, .yaml, .toml; - = stdin)":                                      "data dosyası (.json, .yaml, .toml; - = stdin)",
+	"data file (.json, .yaml, .toml)":                                                 "data dosyası (.json, .yaml, .toml)",
+	"data format: json, yaml, toml (empty = from the file extension, json for stdin)": "data formatı: json, yaml, toml (boş = dosya uzantısından, stdin için json)",
+	"data format: json, yaml, toml (empty = from the file extension)":                 "data formatı: json, yaml, toml (boş = dosya uzantısından)",
+	"exactly one template required":                                                   "tek bir template verilmeli",
+	"could not read data: %w":                                                         "Data okunamadı: %w",
+	"unknown data format: %s (use --format)":                                          "Data formatı bilinmiyor: %s (--format kullanın)",
+	"template directory":                                                              "template klasörü",
+	"port to listen on":                                                               "dinlenecek port",
+	"serve can't read data from stdin, give a file with --data":                       "serve stdin'den data okuyamaz, --data ile dosya verin",
+	"serve the template playground at /__vingo/playground/":                           "template playground'unu /__vingo/playground/ adresinde aç",
+	"playground at http://localhost%s%s\n":                                            "playground: http://localhost%s%s\n",
+	"reloading templates\n":                                                           "template'ler yeniden yükleniyor\n",
+	"serving %s at http://localhost%s\n":                                              "%s sunuluyor: http://localhost%s\n",
+	"watch error:":                                                                    "İzleme hatası:",
+
+	// test
+	"%d passed, %d failed\n": "%d test geçti, %d başarısız\n",
+	"tests failed":           "başarısız testler var",
+	"rewrite the golden files with the current output": "golden dosyalarını mevcut çıktıyla yeniden yaz",
+	"updated %s\n":              "güncellendi %s\n",
+	"%d golden files updated\n": "%d golden dosyası güncellendi\n",
+
+	// theme-diff
+	"new version of the theme; its changes are merged": "temanın yeni sürümü; değişiklikleri birleştirilir",
+	"write merged files to the customized theme":       "birleştirilen dosyaları özelleştirilmiş temaya yaz",
+	"base and customized theme directories required":   "base ve özelleştirilmiş tema klasörleri verilmeli",
+	"new         %s\n":                                    "yeni        %s\n",
+	"changed     %s (+%d -%d lines)\n":                    "değişmiş    %s (+%d -%d satır)\n",
+	"  deleted upstream\n":                                "  upstream'de silinmiş\n",
+	"deleted     %s (upstream)\n":                         "silinmiş    %s (upstream'de)\n",
+	"updated     %s\n":                                    "güncellendi %s\n",
+	"  conflict: %d hunks, left with markers (<<<<<<<)\n": "  çakışma: %d yer, işaretlerle (<<<<<<<) bırakıldı\n",
+	"  upstream changes merged\n":                         "  upstream değişiklikleri birleştirildi\n",
+	"%d files, %d changed":                                "%d dosya, %d değişmiş",
+	", %d with conflicts":                                 ", %d çakışmalı",
+	"(report only; -w to write)":                          "(rapor; yazmak için -w)",
+	"conflicts in %d files, resolve them by hand":         "%d dosyada çakışma var, elle düzeltilmeli",
+}
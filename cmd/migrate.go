@@ -0,0 +1,925 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/coderiantest/vingo"
+)
+
+// migrate: vingo migrate [--from html/template|jinja] [--write] <dosya>...
+//
+// Go html/template ya da Jinja2 template'lerini mekanik olarak vingo
+// tag'lerine çevirir. Çevrilemeyen yapılar (değişken atamaları, macro'lar,
+// range/else...) olduğu gibi bırakılır ve stderr'e dosya:satır ile yazılır;
+// bu durumda çıkış kodu 1'dir. Çıktı stdout'a ya da --write ile dosyanın
+// yanına .vgo uzantısıyla yazılır.
+func migrate(fset *flag.FlagSet, args []string) error {
+	from := fset.String("from", "html/template", "source syntax: html/template or jinja")
+	write := fset.Bool("write", false, "write file.vgo next to each file instead of stdout")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	files := fset.Args()
+	if len(files) == 0 {
+		return errUsage("no files given")
+	}
+	if len(files) > 1 && !*write {
+		return errUsage("several files require --write")
+	}
+	var convert func(m *migrator, src string)
+	switch *from {
+	case "html/template", "text/template", "go":
+		convert = (*migrator).goTemplate
+	case "jinja", "jinja2":
+		convert = (*migrator).jinja
+	default:
+		return errUsage("unknown source syntax: %s", *from)
+	}
+
+	funcs := vingo.New().FuncNames()
+	failed := 0
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		m := &migrator{file: file, src: string(b), funcs: funcs}
+		convert(m, m.src)
+		for _, w := range m.warnings {
+			fmt.Fprintln(os.Stderr, w)
+		}
+		failed += len(m.warnings)
+
+		if !*write {
+			fmt.Print(m.out.String())
+			continue
+		}
+		out := strings.TrimSuffix(file, filepath.Ext(file)) + ".vgo"
+		if err := os.WriteFile(out, []byte(m.out.String()), 0644); err != nil {
+			return errorf("could not write file: %w", err)
+		}
+		printf("%s created ✅\n", out)
+	}
+	if failed > 0 {
+		return errorf("%d constructs could not be converted", failed)
+	}
+	return nil
+}
+
+// migrator: bir dosyanın çevirisi.
+type migrator struct {
+	file     string
+	src      string
+	goSyntax bool     // Go template'i (trim işaretleri farklı)
+	funcs    []string // vingo fonksiyonları; bilinmeyen filter'lar çevrilmez
+	out      strings.Builder
+	warnings []string
+
+	trimNext bool           // sonraki metnin baştaki boşlukları silinir ({{- ... -}})
+	stack    []migrateBlock // açık bloklar
+	dots     []string       // Go: "." in gösterdiği ifade ("" = kök data)
+	loops    int            // Go: isimsiz range değişkenleri için sayaç
+}
+
+// migrateBlock: açık bir blok; end kapanış tag'i, "" ise blok çevrilemedi
+// ve kapanışı da olduğu gibi bırakılır.
+type migrateBlock struct {
+	kind string // if, range, with, define, for, block
+	end  string
+	dot  bool // Go: blok "." u değiştirdi
+}
+
+// migrateAction: kaynaktaki bir {{ }}, {% %} ya da {# #}.
+type migrateAction struct {
+	open string // "{{", "{%", "{#"
+	body string // delimiter ve trim işaretleri olmadan
+	raw  string // yazıldığı gibi
+}
+
+// scan: src'yi metin ve action'lara ayırır; metni (trim işaretlerine göre)
+// yazar, action'ları fn'e verir. fn çevrilmiş tag'i ya da hata döner;
+// hatada action olduğu gibi bırakılır.
+func (m *migrator) scan(src string, opens []string, fn func(a migrateAction) (string, error)) {
+	closes := map[string]string{"{{": "}}", "{%": "%}", "{#": "#}"}
+	i := 0
+	for i < len(src) {
+		j, open := -1, ""
+		for _, o := range opens {
+			if k := strings.Index(src[i:], o); k >= 0 && (j < 0 || i+k < j) {
+				j, open = i+k, o
+			}
+		}
+		if j < 0 {
+			m.text(src[i:], false)
+			break
+		}
+		end := m.actionEnd(src, j, open, closes[open])
+		if end < 0 {
+			m.text(src[i:], false)
+			m.warn(j, src[j:min(len(src), j+20)], errors.New("unterminated action"))
+			break
+		}
+		a := migrateAction{open: open, raw: src[j:end]}
+		body := src[j+len(open) : end-len(closes[open])]
+		trimLeft, trimRight := false, false
+		if m.goSyntax {
+			// Go: trim işaretinden sonra boşluk zorunlu
+			if len(body) >= 2 && body[0] == '-' && strings.ContainsRune(" \t\r\n", rune(body[1])) {
+				trimLeft, body = true, body[1:]
+			}
+			if n := len(body); n >= 2 && body[n-1] == '-' && strings.ContainsRune(" \t\r\n", rune(body[n-2])) {
+				trimRight, body = true, body[:n-1]
+			}
+		} else {
+			if strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+") {
+				trimLeft, body = body[0] == '-', body[1:]
+			}
+			if strings.HasSuffix(body, "-") || strings.HasSuffix(body, "+") {
+				trimRight, body = body[len(body)-1] == '-', body[:len(body)-1]
+			}
+		}
+		a.body = strings.TrimSpace(body)
+		m.text(src[i:j], trimLeft)
+
+		tag, err := fn(a)
+		if err != nil {
+			m.warn(j, a.raw, err)
+			tag = a.raw
+		}
+		m.out.WriteString(tag)
+		m.trimNext = trimRight
+		i = end
+	}
+}
+
+// actionEnd: src[start:]'taki action'ın bittiği offset (kapanıştan sonra),
+// tırnak içindekiler atlanır; -1: kapanmamış.
+func (m *migrator) actionEnd(src string, start int, open, close string) int {
+	i := start + len(open)
+	if open == "{#" {
+		if k := strings.Index(src[i:], close); k >= 0 {
+			return i + k + len(close)
+		}
+		return -1
+	}
+	// Go yorumu: {{/* ... */}}
+	if rest := strings.TrimLeft(strings.TrimPrefix(src[i:], "-"), " \t\r\n"); open == "{{" && strings.HasPrefix(rest, "/*") {
+		k := strings.Index(src[i:], "*/")
+		if k < 0 {
+			return -1
+		}
+		if e := strings.Index(src[i+k:], close); e >= 0 {
+			return i + k + e + len(close)
+		}
+		return -1
+	}
+	for ; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'', '`':
+			k := i + 1
+			for k < len(src) && src[k] != c {
+				if src[k] == '\\' && c != '`' {
+					k++
+				}
+				k++
+			}
+			i = k
+		default:
+			if strings.HasPrefix(src[i:], close) {
+				return i + len(close)
+			}
+		}
+	}
+	return -1
+}
+
+// text: action'lar arasındaki metin; "<{" vingo'da tag açacağı için escape
+// edilir.
+func (m *migrator) text(s string, trimRight bool) {
+	if m.trimNext {
+		s = strings.TrimLeft(s, " \t\r\n")
+	}
+	if trimRight {
+		s = strings.TrimRight(s, " \t\r\n")
+	}
+	m.trimNext = false
+	m.out.WriteString(strings.ReplaceAll(s, "<{", `\<{`))
+}
+
+func (m *migrator) warn(off int, raw string, err error) {
+	line := strings.Count(m.src[:off], "\n") + 1
+	m.warnings = append(m.warnings, msgf("%s:%d: cannot convert %s: %v", m.file, line, raw, err))
+}
+
+// push / pop: açık bloklar.
+func (m *migrator) push(b migrateBlock) {
+	m.stack = append(m.stack, b)
+}
+
+func (m *migrator) top() *migrateBlock {
+	if len(m.stack) == 0 {
+		return nil
+	}
+	return &m.stack[len(m.stack)-1]
+}
+
+func (m *migrator) pop() (migrateBlock, bool) {
+	if len(m.stack) == 0 {
+		return migrateBlock{}, false
+	}
+	b := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return b, true
+}
+
+// -------------------- Go html/template --------------------
+
+func (m *migrator) goTemplate(src string) {
+	m.goSyntax, m.dots = true, []string{""}
+	m.scan(src, []string{"{{"}, m.goAction)
+	for range m.stack {
+		m.warnings = append(m.warnings, msgf("%s: unclosed block", m.file))
+	}
+}
+
+func (m *migrator) goAction(a migrateAction) (string, error) {
+	body := a.body
+	if strings.HasPrefix(body, "/*") {
+		return "", nil // yorumlar atılır
+	}
+	word, rest, _ := strings.Cut(body, " ")
+	rest = strings.TrimSpace(rest)
+	switch word {
+	case "if":
+		x, err := m.goPipeline(rest)
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+			return "", err
+		}
+		m.push(migrateBlock{kind: "if", end: "/if"})
+		return "<{ if " + x + " }>", nil
+	case "else":
+		b := m.top()
+		if b == nil || b.end == "" {
+			return "", errors.New("else outside of a converted block")
+		}
+		if rest == "" {
+			switch b.kind {
+			case "if":
+				return "<{ else }>", nil
+			case "with":
+				// else gövdesinde "." yine dıştaki değer
+				b.dot = false
+				m.dots = m.dots[:len(m.dots)-1]
+				return "<{ else }>", nil
+			}
+			return "", fmt.Errorf("vingo has no else for %s", b.kind)
+		}
+		if cond, ok := strings.CutPrefix(rest, "if "); ok && b.kind == "if" {
+			x, err := m.goPipeline(strings.TrimSpace(cond))
+			if err != nil {
+				return "", err
+			}
+			return "<{ elseif " + x + " }>", nil
+		}
+		return "", fmt.Errorf("vingo has no else %s", rest)
+	case "end":
+		b, ok := m.pop()
+		if !ok {
+			return "", errors.New("end without a block")
+		}
+		if b.dot {
+			m.dots = m.dots[:len(m.dots)-1]
+		}
+		if b.end == "" {
+			return a.raw, nil
+		}
+		return "<{ " + b.end + " }>", nil
+	case "range":
+		tag, err := m.goRange(rest)
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+		}
+		return tag, err
+	case "with":
+		x, err := m.goPipeline(rest)
+		if err == nil && !isPath(x) {
+			err = errors.New("with is only converted for fields")
+		}
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+			return "", err
+		}
+		m.push(migrateBlock{kind: "with", end: "/if", dot: true})
+		m.dots = append(m.dots, x)
+		return "<{ if " + x + " }>", nil
+	case "define", "block":
+		name, arg, _ := strings.Cut(rest, " ")
+		arg = strings.TrimSpace(arg)
+		s, err := strconv.Unquote(name)
+		if err == nil && word == "block" && arg != "." {
+			err = errors.New("block is only converted with . as data")
+		}
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+			return "", err
+		}
+		m.push(migrateBlock{kind: word, end: "/block", dot: true})
+		m.dots = append(m.dots, "")
+		return "<{ block " + strconv.Quote(s) + " }>", nil
+	case "template":
+		name, arg, _ := strings.Cut(rest, " ")
+		arg = strings.TrimSpace(arg)
+		s, err := strconv.Unquote(name)
+		if err != nil {
+			return "", err
+		}
+		if arg != "" && !(arg == "$" || arg == "." && m.dots[len(m.dots)-1] == "") {
+			return "", errors.New("includes only see the data of the page, not " + arg)
+		}
+		return "<{ include " + strconv.Quote(s) + " }>", nil
+	case "break", "continue":
+		return "", fmt.Errorf("vingo has no %s", word)
+	}
+	if strings.HasPrefix(body, "$") && strings.Contains(body, "=") {
+		if _, _, err := goAssign(body); err == nil {
+			return "", errors.New("vingo has no variable assignments")
+		}
+	}
+	x, err := m.goPipeline(body)
+	if err != nil {
+		return "", err
+	}
+	return "<{ " + x + " }>", nil
+}
+
+// goRange: range [$i, $v :=] pipeline.
+func (m *migrator) goRange(rest string) (string, error) {
+	index, item := "", ""
+	if vars, list, err := goAssign(rest); err == nil {
+		rest = list
+		if len(vars) == 2 {
+			index, item = vars[0], vars[1]
+		} else {
+			item = vars[0]
+		}
+	}
+	x, err := m.goPipeline(rest)
+	if err != nil {
+		return "", err
+	}
+	if item == "" {
+		m.loops++
+		item = "item"
+		if m.loops > 1 {
+			item += strconv.Itoa(m.loops)
+		}
+	}
+	m.push(migrateBlock{kind: "range", end: "/for", dot: true})
+	m.dots = append(m.dots, item)
+	if index != "" {
+		return "<{ for " + index + ", " + item + " in " + x + " }>", nil
+	}
+	return "<{ for " + item + " in " + x + " }>", nil
+}
+
+// goAssign: "$a, $b := x" ya da "$a := x" -> değişken isimleri ($ olmadan), x.
+func goAssign(s string) ([]string, string, error) {
+	left, right, ok := strings.Cut(s, ":=")
+	if !ok {
+		left, right, ok = strings.Cut(s, "=")
+	}
+	if !ok {
+		return nil, "", errors.New("not an assignment")
+	}
+	var vars []string
+	for _, v := range strings.Split(left, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) < 2 || v[0] != '$' || !isIdent(v[1:]) {
+			return nil, "", errors.New("not an assignment")
+		}
+		vars = append(vars, v[1:])
+	}
+	if len(vars) > 2 {
+		return nil, "", errors.New("not an assignment")
+	}
+	return vars, strings.TrimSpace(right), nil
+}
+
+// goPipeline: Go template pipeline'ını vingo ifadesine çevirir.
+func (m *migrator) goPipeline(src string) (string, error) {
+	toks, err := goTokens(src)
+	if err != nil {
+		return "", err
+	}
+	p := &goParser{m: m, toks: toks}
+	x, err := p.pipeline()
+	if err != nil {
+		return "", err
+	}
+	if p.pos < len(p.toks) {
+		return "", fmt.Errorf("unexpected %s", p.toks[p.pos])
+	}
+	return x, nil
+}
+
+// goTokens: pipeline'ın token'ları; alanlar (.A.B), değişkenler ($x.A),
+// string'ler, sayılar, isimler ve ( ) | tek token'dır.
+func goTokens(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == '|':
+			toks = append(toks, string(c))
+			i++
+		case c == '"' || c == '`' || c == '\'':
+			k := i + 1
+			for k < len(src) && src[k] != c {
+				if src[k] == '\\' && c != '`' {
+					k++
+				}
+				k++
+			}
+			if k >= len(src) {
+				return nil, errors.New("unterminated string")
+			}
+			toks = append(toks, src[i:k+1])
+			i = k + 1
+		default:
+			k := i
+			for k < len(src) && !strings.ContainsRune(" \t\r\n()|\"`'", rune(src[k])) {
+				k++
+			}
+			toks = append(toks, src[i:k])
+			i = k
+		}
+	}
+	return toks, nil
+}
+
+type goParser struct {
+	m    *migrator
+	toks []string
+	pos  int
+}
+
+func (p *goParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+// pipeline: command ("|" fonksiyon)*
+func (p *goParser) pipeline() (string, error) {
+	x, err := p.command()
+	if err != nil {
+		return "", err
+	}
+	for p.peek() == "|" {
+		p.pos++
+		name := p.peek()
+		p.pos++
+		if next := p.peek(); next != "|" && next != ")" && next != "" {
+			return "", fmt.Errorf("pipe into %s with arguments", name)
+		}
+		switch name {
+		case "html":
+			x += " | escape"
+		case "not":
+			x = "not (" + x + ")"
+		default:
+			return "", fmt.Errorf("no vingo equivalent of %s", name)
+		}
+	}
+	return x, nil
+}
+
+// command: operand ya da fonksiyon ve argümanları.
+func (p *goParser) command() (string, error) {
+	start := p.peek()
+	var args []string
+	for t := p.peek(); t != "" && t != "|" && t != ")"; t = p.peek() {
+		if len(args) == 0 && isIdent(t) && !slices.Contains([]string{"true", "false", "nil"}, t) {
+			p.pos++
+			args = append(args, t) // fonksiyon adı
+			continue
+		}
+		x, err := p.operand()
+		if err != nil {
+			return "", err
+		}
+		args = append(args, x)
+	}
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+	if !isIdent(start) || slices.Contains([]string{"true", "false", "nil"}, start) {
+		if len(args) > 1 {
+			return "", errors.New("method calls with arguments")
+		}
+		return args[0], nil
+	}
+
+	fn, args := args[0], args[1:]
+	ops := map[string]string{"ne": "!=", "lt": "<", "le": "<=", "gt": ">", "ge": ">="}
+	switch {
+	case fn == "eq" && len(args) >= 2:
+		var parts []string
+		for _, a := range args[1:] {
+			parts = append(parts, args[0]+" == "+a)
+		}
+		return strings.Join(parts, " or "), nil
+	case ops[fn] != "" && len(args) == 2:
+		return args[0] + " " + ops[fn] + " " + args[1], nil
+	case (fn == "and" || fn == "or") && len(args) >= 2:
+		return strings.Join(args, " "+fn+" "), nil
+	case fn == "not" && len(args) == 1:
+		return "not " + args[0], nil
+	case fn == "html" && len(args) == 1:
+		return args[0] + " | escape", nil
+	}
+	return "", fmt.Errorf("no vingo equivalent of %s", fn)
+}
+
+// operand: alan, değişken, sabit ya da parantezli pipeline.
+func (p *goParser) operand() (string, error) {
+	t := p.peek()
+	p.pos++
+	dot := p.m.dots[len(p.m.dots)-1]
+	switch {
+	case t == "(":
+		x, err := p.pipeline()
+		if err != nil {
+			return "", err
+		}
+		if p.peek() != ")" {
+			return "", errors.New("missing )")
+		}
+		p.pos++
+		return "(" + x + ")", nil
+	case t == ".":
+		if dot == "" {
+			return "", errors.New("vingo has no name for the whole data")
+		}
+		return dot, nil
+	case t[0] == '.':
+		if dot == "" {
+			return t[1:], nil
+		}
+		return dot + t, nil
+	case t == "$":
+		return "", errors.New("vingo has no name for the whole data")
+	case strings.HasPrefix(t, "$."):
+		return t[2:], nil
+	case t[0] == '$':
+		return t[1:], nil
+	case t[0] == '"':
+		return t, nil
+	case t[0] == '`':
+		return strconv.Quote(t[1 : len(t)-1]), nil
+	case t[0] == '\'':
+		return "", errors.New("character constants")
+	case t[0] >= '0' && t[0] <= '9' || t[0] == '-':
+		return t, nil
+	case t == "true" || t == "false" || t == "nil":
+		return t, nil
+	}
+	return "", fmt.Errorf("unexpected %s", t)
+}
+
+// -------------------- Jinja2 --------------------
+
+func (m *migrator) jinja(src string) {
+	m.scan(src, []string{"{{", "{%", "{#"}, m.jinjaAction)
+	for range m.stack {
+		m.warnings = append(m.warnings, msgf("%s: unclosed block", m.file))
+	}
+}
+
+func (m *migrator) jinjaAction(a migrateAction) (string, error) {
+	switch a.open {
+	case "{#":
+		return "", nil // yorumlar atılır
+	case "{{":
+		x, err := m.jinjaExpr(a.body, true)
+		if err != nil {
+			return "", err
+		}
+		return "<{ " + x + " }>", nil
+	}
+
+	word, rest, _ := strings.Cut(a.body, " ")
+	rest = strings.TrimSpace(rest)
+	switch word {
+	case "if":
+		x, err := m.jinjaExpr(rest, false)
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+			return "", err
+		}
+		m.push(migrateBlock{kind: "if", end: "/if"})
+		return "<{ if " + x + " }>", nil
+	case "elif", "else":
+		b := m.top()
+		if b == nil || b.kind != "if" {
+			if b != nil && b.end == "" {
+				return a.raw, nil
+			}
+			return "", fmt.Errorf("vingo has no %s here", word)
+		}
+		if word == "else" {
+			return "<{ else }>", nil
+		}
+		x, err := m.jinjaExpr(rest, false)
+		if err != nil {
+			return "", err
+		}
+		return "<{ elseif " + x + " }>", nil
+	case "for":
+		tag, err := m.jinjaFor(rest)
+		if err != nil {
+			m.push(migrateBlock{kind: "raw"})
+		}
+		return tag, err
+	case "block":
+		if !isIdent(rest) {
+			m.push(migrateBlock{kind: "raw"})
+			return "", errors.New("invalid block name")
+		}
+		m.push(migrateBlock{kind: "block", end: "/block"})
+		return "<{ block " + strconv.Quote(rest) + " }>", nil
+	case "endif", "endfor", "endblock":
+		b, ok := m.pop()
+		if !ok {
+			return "", errors.New(word + " without a block")
+		}
+		if b.end == "" {
+			return a.raw, nil
+		}
+		return "<{ " + b.end + " }>", nil
+	case "include":
+		path, opts, _ := strings.Cut(rest, " ")
+		opts = strings.TrimSpace(opts)
+		if path == "" || path[0] != '"' && path[0] != '\'' || opts != "" && opts != "with context" {
+			return "", errors.New("include is only converted for a literal path")
+		}
+		return "<{ include " + jinjaString(path) + " }>", nil
+	case "macro", "call", "filter", "raw", "set", "with", "autoescape":
+		if word != "set" || !strings.Contains(rest, "=") {
+			m.push(migrateBlock{kind: "raw"})
+		}
+	default:
+		if strings.HasPrefix(word, "end") {
+			b, ok := m.pop()
+			if ok && b.end == "" {
+				return a.raw, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("vingo has no %s", word)
+}
+
+// jinjaFor: "x in list" / "i, x in list" (unpacking çevrilmez).
+func (m *migrator) jinjaFor(rest string) (string, error) {
+	item, list, ok := strings.Cut(rest, " in ")
+	item = strings.TrimSpace(item)
+	if !ok || !isIdent(item) {
+		return "", errors.New("for is only converted for a single loop variable")
+	}
+	if strings.Contains(list, " if ") || strings.HasSuffix(list, " recursive") {
+		return "", errors.New("loop filters and recursive loops")
+	}
+	x, err := m.jinjaExpr(list, false)
+	if err != nil {
+		return "", err
+	}
+	m.push(migrateBlock{kind: "for", end: "/for"})
+	return "<{ for " + item + " in " + x + " }>", nil
+}
+
+// jinjaLoop: Jinja loop değişkeninin vingo karşılıkları.
+var jinjaLoop = map[string]string{
+	"loop.index":  "loop.Index + 1",
+	"loop.index0": "loop.Index",
+	"loop.first":  "loop.First",
+	"loop.last":   "loop.Last",
+	"loop.length": "loop.Length",
+}
+
+// jinjaExpr: Jinja ifadesini vingo'ya çevirir; output true ise son
+// default("...") filter'ı output tag'inin default'u olur.
+func (m *migrator) jinjaExpr(src string, output bool) (string, error) {
+	toks, err := jinjaTokens(src)
+	if err != nil {
+		return "", err
+	}
+	var out []string
+	def := ""
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t == "|":
+			if i+1 >= len(toks) || !isIdent(toks[i+1]) {
+				return "", errors.New("invalid filter")
+			}
+			name := toks[i+1]
+			i++
+			var args []string
+			if i+1 < len(toks) && toks[i+1] == "(" {
+				end := matchParen(toks, i+1)
+				if end < 0 {
+					return "", errors.New("missing )")
+				}
+				for _, a := range splitArgs(toks[i+2 : end]) {
+					x, err := m.jinjaExpr(strings.Join(a, " "), false)
+					if err != nil {
+						return "", err
+					}
+					args = append(args, x)
+				}
+				i = end
+			}
+			switch name {
+			case "safe":
+			case "e", "escape":
+				out = append(out, "| escape")
+			case "default", "d":
+				if !output || i+1 < len(toks) || len(args) != 1 || args[0][0] != '"' {
+					return "", errors.New("default is only converted with a string, as the last filter")
+				}
+				def = args[0]
+			default:
+				if !slices.Contains(m.funcs, name) {
+					return "", fmt.Errorf("no vingo filter %s", name)
+				}
+				f := "| " + name
+				for _, a := range args {
+					f += ":" + a
+				}
+				out = append(out, f)
+			}
+		case t == "~":
+			out = append(out, "+")
+		case t == "True" || t == "true":
+			out = append(out, "true")
+		case t == "False" || t == "false":
+			out = append(out, "false")
+		case t == "None" || t == "none":
+			out = append(out, "nil")
+		case jinjaLoop[t] != "":
+			out = append(out, jinjaLoop[t])
+		case t == "in" || t == "is" || t == "if" || t == "else":
+			return "", fmt.Errorf("vingo has no %s operator", t)
+		case t == "[" || t == "]" || t == "-" || t == "*" || t == "/" || t == "%" || t == "{" || t == "}":
+			return "", fmt.Errorf("vingo has no %s operator", t)
+		case t[0] == '\'' || t[0] == '"':
+			out = append(out, jinjaString(t))
+		case isIdent(t) && i+1 < len(toks) && toks[i+1] == "(":
+			if !slices.Contains(m.funcs, t) {
+				return "", fmt.Errorf("no vingo function %s", t)
+			}
+			out = append(out, t+"(")
+			i++
+		case strings.HasPrefix(t, "loop."):
+			return "", fmt.Errorf("no vingo equivalent of %s", t)
+		case strings.Contains(t, ".") && isIdentStart(t[0]) && i+1 < len(toks) && toks[i+1] == "(":
+			return "", errors.New("vingo has no method calls")
+		default:
+			out = append(out, t)
+		}
+	}
+	x := strings.Join(out, " ")
+	x = strings.NewReplacer("( ", "(", " )", ")", " ,", ",").Replace(x)
+	if def != "" {
+		x += " | " + def
+	}
+	return x, nil
+}
+
+// jinjaTokens: Jinja ifadesinin token'ları; noktalı isimler (user.name)
+// tek token'dır.
+func jinjaTokens(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			k := i + 1
+			for k < len(src) && src[k] != c {
+				if src[k] == '\\' {
+					k++
+				}
+				k++
+			}
+			if k >= len(src) {
+				return nil, errors.New("unterminated string")
+			}
+			toks = append(toks, src[i:k+1])
+			i = k + 1
+		case isIdentStart(c) || c >= '0' && c <= '9':
+			k := i
+			for k < len(src) && (isIdentStart(src[k]) || src[k] >= '0' && src[k] <= '9' || src[k] == '.') {
+				k++
+			}
+			toks = append(toks, src[i:k])
+			i = k
+		default:
+			if i+1 < len(src) && slices.Contains([]string{"==", "!=", "<=", ">=", "**", "//"}, src[i:i+2]) {
+				toks = append(toks, src[i:i+2])
+				i += 2
+				continue
+			}
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks, nil
+}
+
+// jinjaString: tek ya da çift tırnaklı Jinja string'i, çift tırnaklı.
+func jinjaString(s string) string {
+	if s[0] == '"' {
+		return s
+	}
+	return strconv.Quote(strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(s[1 : len(s)-1]))
+}
+
+// matchParen: toks[open] "(" ise kapanan parantezin indeksi, yoksa -1.
+func matchParen(toks []string, open int) int {
+	depth := 0
+	for i := open; i < len(toks); i++ {
+		switch toks[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs: virgülle ayrılmış argümanlar (parantez içi hariç).
+func splitArgs(toks []string) [][]string {
+	var args [][]string
+	start, depth := 0, 0
+	for i, t := range toks {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, toks[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(toks) {
+		args = append(args, toks[start:])
+	}
+	return args
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isIdent: s bir isim mi (noktasız).
+func isIdent(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentStart(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isPath: s noktalı bir isim mi (user.Address.City).
+func isPath(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if !isIdent(part) {
+			return false
+		}
+	}
+	return true
+}
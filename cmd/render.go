@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/coderiantest/vingo"
+)
+
+// render: vingo render template.vgo [--data data.json|-] [--format yaml] [--out out.html]
+//
+// Template'i data dosyasıyla (JSON, YAML, TOML; "-" = stdin) render edip
+// stdout'a ya da --out dosyasına yazar; shell pipeline'ları ve CI için.
+func render(fset *flag.FlagSet, args []string) error {
+	dataFile := fset.String("data", "", "data file (.json, .yaml, .toml; - = stdin)")
+	format := fset.String("format", "", "data format: json, yaml, toml (empty = from the file extension, json for stdin)")
+	out := fset.String("out", "", "output file (empty = stdout)")
+
+	// flag'ler template isminden sonra da gelebilir
+	var files []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return err
+		}
+		if fset.NArg() == 0 {
+			break
+		}
+		files = append(files, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+	if len(files) != 1 {
+		return errUsage("exactly one template required")
+	}
+
+	data, err := loadData(*dataFile, *format)
+	if err != nil {
+		return err
+	}
+
+	html, err := vingo.New().Render(files[0], data)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = io.WriteString(os.Stdout, html)
+		return err
+	}
+	if err := os.WriteFile(*out, []byte(html), 0644); err != nil {
+		return errorf("could not write file: %w", err)
+	}
+	return nil
+}
+
+// loadData: file'daki data (boş = boş data, "-" = stdin); format boşsa
+// dosya uzantısından bulunur, stdin için json.
+func loadData(file, format string) (map[string]interface{}, error) {
+	if file == "" {
+		return map[string]interface{}{}, nil
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+		if format == "" {
+			format = "json"
+		}
+	} else {
+		b, err = os.ReadFile(file)
+		if format == "" {
+			format = vingo.DataFormat(file)
+		}
+	}
+	if err != nil {
+		return nil, errorf("could not read data: %w", err)
+	}
+	if format == "" {
+		return nil, errorf("unknown data format: %s (use --format)", file)
+	}
+	return vingo.DecodeData(b, format)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coderiantest/vingo"
+)
+
+// routeManifest: vingo build --routes ile yazılan dosya. Reverse proxy
+// ayarları, sitemap ve arama indeksleri template'lerle aynı kaynaktan
+// üretilebilsin diye sitenin bütün sayfalarını listeler.
+type routeManifest struct {
+	Routes []route `json:"routes"`
+}
+
+// route: bir sayfa. Data dosyaları src'ye göre, --data dosyası verildiği
+// gibi yazılır; LastModified template'in, include ettiklerinin ve data
+// dosyalarının en yeni değişiklik zamanıdır.
+type route struct {
+	Path         string    `json:"path"`
+	Template     string    `json:"template"`
+	Data         []string  `json:"data,omitempty"`
+	FrontMatter  bool      `json:"frontMatter,omitempty"`
+	LastModified time.Time `json:"lastmod"`
+}
+
+// pageRoute: root altındaki rel sayfasının route'u.
+func pageRoute(e *vingo.Engine, root, rel, dataFile string) (route, error) {
+	r := route{
+		Path:     pageURL(rel),
+		Template: filepath.ToSlash(rel),
+	}
+	path := filepath.Join(root, rel)
+	deps, err := e.Dependencies(rel)
+	if err != nil {
+		return route{}, err
+	}
+	inputs := append([]string{path}, deps...)
+	if dataFile != "" {
+		r.Data = append(r.Data, filepath.ToSlash(dataFile))
+		inputs = append(inputs, dataFile)
+	}
+	base := strings.TrimSuffix(rel, filepath.Ext(rel))
+	for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+		if _, err := os.Stat(filepath.Join(root, base+ext)); err == nil {
+			r.Data = append(r.Data, filepath.ToSlash(base+ext))
+			inputs = append(inputs, filepath.Join(root, base+ext))
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return route{}, err
+	}
+	fm, _, err := vingo.SplitFrontMatter(b)
+	if err != nil {
+		return route{}, err
+	}
+	r.FrontMatter = fm != nil
+
+	for _, in := range inputs {
+		st, err := os.Stat(in)
+		if err != nil {
+			return route{}, err
+		}
+		if t := st.ModTime(); t.After(r.LastModified) {
+			r.LastModified = t
+		}
+	}
+	r.LastModified = r.LastModified.UTC().Truncate(time.Second)
+	return r, nil
+}
+
+// writeRoutes: pages'in route manifest'ini path'e yazar.
+func writeRoutes(path string, e *vingo.Engine, root string, pages []string, dataFile string) error {
+	m := routeManifest{Routes: []route{}}
+	for _, rel := range pages {
+		r, err := pageRoute(e, root, rel, dataFile)
+		if err != nil {
+			return err
+		}
+		m.Routes = append(m.Routes, r)
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, strings.NewReader(string(b)+"\n"))
+}
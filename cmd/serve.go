@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/coderiantest/vingo"
+	"github.com/coderiantest/vingo/vingohttp"
+	"github.com/fsnotify/fsnotify"
+)
+
+// serve: vingo serve [--dir ./views] [--data data.json] [--format yaml] [--port 8080] [--playground]
+//
+// Klasördeki template'leri HTTP üzerinden render eder; /about isteği
+// about.vgo'yu, / isteği index.vgo'yu render eder, diğer dosyalar (css,
+// resim) olduğu gibi sunulur. Data dosyası her istekte yeniden okunur.
+// Klasörde ya da data dosyasında bir değişiklik olunca sayfalar, içlerine
+// eklenen küçük bir script sayesinde kendiliğinden yenilenir; SIGHUP
+// compile edilmiş template'leri ve cache'lenmiş fragment'ları atar. --playground
+// template denemek için playground sayfasını /__vingo/playground/ altında açar.
+func serve(fset *flag.FlagSet, args []string) error {
+	dir := fset.String("dir", ".", "template directory")
+	dataFile := fset.String("data", "", "data file (.json, .yaml, .toml)")
+	format := fset.String("format", "", "data format: json, yaml, toml (empty = from the file extension)")
+	port := fset.Int("port", 8080, "port to listen on")
+	playground := fset.Bool("playground", false, "serve the template playground at /__vingo/playground/")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 0 {
+		return errUsage("unexpected arguments: %v", fset.Args())
+	}
+	if *dataFile == "-" {
+		return errUsage("serve can't read data from stdin, give a file with --data")
+	}
+
+	root, err := filepath.Abs(*dir)
+	if err != nil {
+		return err
+	}
+	reload := &reloader{clients: map[chan struct{}]bool{}}
+	if err := reload.watch(root, *dataFile); err != nil {
+		return err
+	}
+
+	e := vingo.New()
+	e.Root = root
+	files := http.FileServer(http.Dir(root))
+
+	// SIGHUP: compile edilmiş template'leri ve fragment cache'ini boşaltır
+	go e.ReloadOnSignal(context.Background(), func() {
+		printf("reloading templates\n")
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, reload)
+	if *playground {
+		mux.Handle(playgroundPath, http.StripPrefix(strings.TrimSuffix(playgroundPath, "/"), vingohttp.Playground(e)))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		file, ok := servedTemplate(root, r.URL.Path)
+		if !ok {
+			files.ServeHTTP(w, r)
+			return
+		}
+		data, err := loadData(*dataFile, *format)
+		var out string
+		if err == nil {
+			out, err = e.RenderContext(r.Context(), file, data)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			out = "<!DOCTYPE html><pre>" + html.EscapeString(err.Error()) + "</pre>"
+		}
+		fmt.Fprint(w, injectReload(out))
+	})
+
+	addr := fmt.Sprintf(":%d", *port)
+	printf("serving %s at http://localhost%s\n", root, addr)
+	if *playground {
+		printf("playground at http://localhost%s%s\n", addr, playgroundPath)
+	}
+	return http.ListenAndServe(addr, mux)
+}
+
+// playgroundPath: --playground ile açılan playground sayfasının adresi.
+const playgroundPath = "/__vingo/playground/"
+
+// servedTemplate: URL path'ine karşılık gelen template (root'a göre), yoksa false.
+func servedTemplate(root, urlPath string) (string, bool) {
+	p := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if p == "" || strings.HasSuffix(urlPath, "/") {
+		p = path.Join(p, "index")
+	}
+	candidates := []string{p}
+	if path.Ext(p) == "" {
+		candidates = []string{p + ".vgo", p + ".vingo", path.Join(p, "index.vgo"), path.Join(p, "index.vingo")}
+	}
+	for _, c := range candidates {
+		if !strings.HasSuffix(c, ".vgo") && !strings.HasSuffix(c, ".vingo") {
+			continue
+		}
+		if st, err := os.Stat(filepath.Join(root, filepath.FromSlash(c))); err == nil && !st.IsDir() {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// -------------------- Live reload --------------------
+
+// reloadPath: sayfaların değişiklikleri dinlediği server-sent events adresi.
+const reloadPath = "/__vingo/reload"
+
+const reloadScript = `<script>new EventSource("` + reloadPath + `").onmessage = function () { location.reload() }</script>`
+
+// injectReload: reload script'ini </body>'den önce, yoksa sona ekler.
+func injectReload(page string) string {
+	if i := strings.LastIndex(strings.ToLower(page), "</body>"); i >= 0 {
+		return page[:i] + reloadScript + page[i:]
+	}
+	return page + reloadScript
+}
+
+// reloader: dosya değişikliklerini bağlı sayfalara bildirir.
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+// watch: root altındaki bütün klasörleri ve data dosyasının klasörünü izler.
+func (rl *reloader) watch(root, dataFile string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(p)
+		}
+		return nil
+	})
+	if err == nil && dataFile != "" {
+		err = w.Add(filepath.Dir(dataFile))
+	}
+	if err != nil {
+		w.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				// yeni klasörler de izlenir
+				if ev.Has(fsnotify.Create) {
+					if st, err := os.Stat(ev.Name); err == nil && st.IsDir() {
+						w.Add(ev.Name)
+					}
+				}
+				if ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create) || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+					rl.notify()
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintln(os.Stderr, msg("watch error:"), err)
+			}
+		}
+	}()
+	return nil
+}
+
+// notify: bağlı bütün sayfalara yenilenme mesajı gönderir.
+func (rl *reloader) notify() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for c := range rl.clients {
+		select {
+		case c <- struct{}{}:
+		default: // mesaj zaten bekliyor
+		}
+	}
+}
+
+// ServeHTTP: bir sayfanın event stream'i; her değişiklikte "reload" gönderir.
+func (rl *reloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan struct{}, 1)
+	rl.mu.Lock()
+	rl.clients[c] = true
+	rl.mu.Unlock()
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, c)
+		rl.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/coderiantest/vingo"
+)
+
+// test: vingo test [--update] [dosya|klasör]...
+//
+// Template'lerdeki <{ test }> tag'lerini ve testdata/<template>/ altındaki
+// golden dosyalarını çalıştırır (varsayılan: bu klasör). --update golden
+// dosyalarını render çıktısıyla yeniden yazar.
+func test(fset *flag.FlagSet, args []string) error {
+	update := fset.Bool("update", false, "rewrite the golden files with the current output")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	args = fset.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	files, err := templateFiles(args)
+	if err != nil {
+		return err
+	}
+
+	e := vingo.New()
+	passed, failed, updated := 0, 0, 0
+	for _, file := range files {
+		results, err := e.RunTests(context.Background(), file)
+		if err == nil {
+			var golden []vingo.TestResult
+			golden, err = e.RunGoldenTests(context.Background(), file, *update)
+			results = append(results, golden...)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %v\n", err)
+			continue
+		}
+		for _, r := range results {
+			where := fmt.Sprintf("%s:%d %s", r.File, r.Line, r.Name)
+			if r.Golden != "" {
+				where = r.Golden
+			}
+			switch {
+			case r.Err != nil:
+				failed++
+				fmt.Printf("FAIL %s: %v\n", where, r.Err)
+			case r.Updated:
+				updated++
+				printf("updated %s\n", where)
+			default:
+				passed++
+				fmt.Printf("ok   %s\n", where)
+			}
+		}
+	}
+	if updated > 0 {
+		printf("%d golden files updated\n", updated)
+	}
+	printf("%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return errorf("tests failed")
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// themeDiff: vingo theme-diff [-upstream yeni-tema/] [-w] base-theme/ customized-theme/
+//
+// Özelleştirilmiş temadaki dosyaları kopyalandıkları temayla karşılaştırır
+// ve değiştirilmiş (override edilmiş) partial'ları listeler. -upstream ile
+// temanın yeni sürümündeki değişiklikler (base -> upstream) özelleştirilmiş
+// dosyalara üç yönlü birleştirilir; -w sonuçları yazar, yoksa sadece rapor.
+func themeDiff(fset *flag.FlagSet, args []string) error {
+	upstream := fset.String("upstream", "", "new version of the theme; its changes are merged")
+	write := fset.Bool("w", false, "write merged files to the customized theme")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 2 {
+		return errUsage("base and customized theme directories required")
+	}
+	base, custom := fset.Arg(0), fset.Arg(1)
+
+	files, err := themeFiles(custom)
+	if err != nil {
+		return err
+	}
+	drifted, conflicted := 0, 0
+	for _, rel := range files {
+		mine, err := os.ReadFile(filepath.Join(custom, rel))
+		if err != nil {
+			return err
+		}
+		orig, err := os.ReadFile(filepath.Join(base, rel))
+		if err != nil {
+			if os.IsNotExist(err) {
+				printf("new         %s\n", rel)
+				continue
+			}
+			return err
+		}
+		changed := !bytes.Equal(mine, orig)
+		if changed {
+			drifted++
+			added, removed := diffStat(splitLines(string(orig)), splitLines(string(mine)))
+			printf("changed     %s (+%d -%d lines)\n", rel, added, removed)
+		}
+		if *upstream == "" {
+			continue
+		}
+
+		theirs, err := os.ReadFile(filepath.Join(*upstream, rel))
+		if err != nil {
+			if os.IsNotExist(err) && changed {
+				printf("  deleted upstream\n")
+				continue
+			}
+			if os.IsNotExist(err) {
+				printf("deleted     %s (upstream)\n", rel)
+				continue
+			}
+			return err
+		}
+		if bytes.Equal(theirs, orig) || bytes.Equal(theirs, mine) {
+			continue // upstream'de değişiklik yok / zaten alınmış
+		}
+		merged, conflicts := merge3(string(orig), string(mine), string(theirs))
+		switch {
+		case !changed:
+			printf("updated     %s\n", rel)
+		case conflicts > 0:
+			conflicted++
+			printf("  conflict: %d hunks, left with markers (<<<<<<<)\n", conflicts)
+		default:
+			printf("  upstream changes merged\n")
+		}
+		if *write {
+			if err := os.WriteFile(filepath.Join(custom, rel), []byte(merged), 0644); err != nil {
+				return errorf("could not write file: %w", err)
+			}
+		}
+	}
+
+	printf("%d files, %d changed", len(files), drifted)
+	if conflicted > 0 {
+		printf(", %d with conflicts", conflicted)
+	}
+	fmt.Println()
+	if *upstream != "" && !*write {
+		fmt.Println(msg("(report only; -w to write)"))
+	}
+	if conflicted > 0 && *write {
+		return errorf("conflicts in %d files, resolve them by hand", conflicted)
+	}
+	return nil
+}
+
+// themeFiles: dir altındaki dosyalar, dir'e göre relative.
+func themeFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files = append(files, rel)
+		return err
+	})
+	return files, err
+}
+
+// splitLines: s'nin satırları, satır sonlarıyla birlikte.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lcsMatch: a'nın her satırı için en uzun ortak alt dizide eşleştiği b
+// satırının indeksi (-1 = eşleşmedi).
+func lcsMatch(a, b []string) []int {
+	n, m := len(a), len(b)
+	dp := make([][]int32, n+1) // dp[i][j]: a[i:] ve b[j:] için LCS uzunluğu
+	for i := range dp {
+		dp[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+// diffStat: base'den changed'e eklenen ve silinen satır sayıları.
+func diffStat(base, changed []string) (added, removed int) {
+	same := 0
+	for _, j := range lcsMatch(base, changed) {
+		if j >= 0 {
+			same++
+		}
+	}
+	return len(changed) - same, len(base) - same
+}
+
+// merge3: base'den mine ve theirs'e yapılan değişiklikleri satır bazında
+// birleştirir. İki tarafın da farklı değiştirdiği yerler diff3 tarzı
+// işaretlerle bırakılır; sayıları döner.
+func merge3(base, mine, theirs string) (string, int) {
+	b, m, t := splitLines(base), splitLines(mine), splitLines(theirs)
+	mm, tm := lcsMatch(b, m), lcsMatch(b, t)
+	var out strings.Builder
+	conflicts := 0
+	i, j, k := 0, 0, 0
+	for {
+		// sıradaki senkron nokta: her iki tarafta da korunan base satırı
+		s := i
+		for s < len(b) && (mm[s] < 0 || tm[s] < 0) {
+			s++
+		}
+		je, ke := len(m), len(t)
+		if s < len(b) {
+			je, ke = mm[s], tm[s]
+		}
+		bc, mc, tc := b[i:s], m[j:je], t[k:ke]
+		switch {
+		case slices.Equal(mc, bc):
+			writeLines(&out, tc)
+		case slices.Equal(tc, bc) || slices.Equal(mc, tc):
+			writeLines(&out, mc)
+		default:
+			conflicts++
+			out.WriteString("<<<<<<< custom\n")
+			writeSection(&out, mc)
+			out.WriteString("||||||| base\n")
+			writeSection(&out, bc)
+			out.WriteString("=======\n")
+			writeSection(&out, tc)
+			out.WriteString(">>>>>>> upstream\n")
+		}
+		if s == len(b) {
+			break
+		}
+		out.WriteString(b[s])
+		i, j, k = s+1, je+1, ke+1
+	}
+	return out.String(), conflicts
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, l := range lines {
+		out.WriteString(l)
+	}
+}
+
+// writeSection: çakışma bölümü; son satır sonu yoksa eklenir ki işaretler
+// kendi satırlarında kalsın.
+func writeSection(out *strings.Builder, lines []string) {
+	writeLines(out, lines)
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		out.WriteString("\n")
+	}
+}
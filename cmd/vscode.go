@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vscodeExt: vingo vscode-ext [--out vingo-vscode]
+//
+// .vgo / .vingo dosyaları için bir VSCode eklentisi üretir: HTML içinde
+// <{ ... }> tag'lerini, keyword'leri, filter'ları ve string'leri
+// renklendiren bir TextMate grammar'ı ve if/for/switch snippet'leri.
+// Klasör ~/.vscode/extensions altına kopyalanarak ya da vsce ile
+// paketlenerek kurulur.
+func vscodeExt(fset *flag.FlagSet, args []string) error {
+	out := fset.String("out", "vingo-vscode", "output directory of the extension")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 0 {
+		return errUsage("unexpected arguments: %v", fset.Args())
+	}
+
+	files := map[string]interface{}{
+		"package.json":                   vscodePackage,
+		"language-configuration.json":    vscodeLanguageConfig,
+		"syntaxes/vingo.tmLanguage.json": vingoGrammar(),
+		"snippets/vingo.json":            vingoSnippets,
+	}
+	for name, v := range files {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(*out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return errorf("could not create directory: %w", err)
+		}
+		if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+			return errorf("could not write file: %w", err)
+		}
+	}
+	printf("%s created ✅\n", *out)
+	printf("Install: copy it to ~/.vscode/extensions/ or package it with `vsce package`\n")
+	return nil
+}
+
+var vscodePackage = map[string]interface{}{
+	"name":        "vingo",
+	"displayName": "Vingo templates",
+	"description": "Syntax highlighting and snippets for vingo templates",
+	"version":     "0.1.0",
+	"publisher":   "vingo",
+	"engines":     map[string]string{"vscode": "^1.75.0"},
+	"categories":  []string{"Programming Languages", "Snippets"},
+	"contributes": map[string]interface{}{
+		"languages": []interface{}{map[string]interface{}{
+			"id":            "vingo",
+			"aliases":       []string{"Vingo", "vingo"},
+			"extensions":    []string{".vgo", ".vingo"},
+			"configuration": "./language-configuration.json",
+		}},
+		"grammars": []interface{}{map[string]interface{}{
+			"language":          "vingo",
+			"scopeName":         "text.html.vingo",
+			"path":              "./syntaxes/vingo.tmLanguage.json",
+			"embeddedLanguages": map[string]string{"source.css": "css", "source.js": "javascript"},
+		}},
+		"snippets": []interface{}{map[string]string{
+			"language": "vingo",
+			"path":     "./snippets/vingo.json",
+		}},
+	},
+}
+
+var vscodeLanguageConfig = map[string]interface{}{
+	"comments": map[string]interface{}{"blockComment": []string{"<!--", "-->"}},
+	"brackets": [][]string{{"<{", "}>"}, {"<", ">"}, {"{", "}"}, {"[", "]"}, {"(", ")"}},
+	"autoClosingPairs": []map[string]string{
+		{"open": "<{", "close": " }>"},
+		{"open": "{", "close": "}"},
+		{"open": "[", "close": "]"},
+		{"open": "(", "close": ")"},
+		{"open": `"`, "close": `"`, "notIn": "string"},
+	},
+	"surroundingPairs": [][]string{{"<{", "}>"}, {`"`, `"`}, {"'", "'"}},
+}
+
+// vingoGrammar: TextMate grammar'ı. Tag'ler HTML grammar'ına inject edilir,
+// böylece attribute değerlerinin içinde de renklendirilir.
+func vingoGrammar() map[string]interface{} {
+	var keywords, endTags []string
+	for _, t := range lspTags {
+		if name, ok := strings.CutPrefix(t.name, "/"); ok {
+			endTags = append(endTags, name)
+		} else {
+			keywords = append(keywords, t.name)
+		}
+	}
+	str := func(q, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"begin": q, "end": q, "name": name,
+			"patterns": []interface{}{map[string]string{"match": `\\.`, "name": "constant.character.escape.vingo"}},
+		}
+	}
+	return map[string]interface{}{
+		"scopeName": "text.html.vingo",
+		"name":      "Vingo",
+		"patterns": []interface{}{
+			map[string]string{"include": "#escaped"},
+			map[string]string{"include": "#tag"},
+			map[string]string{"include": "text.html.basic"},
+		},
+		"injections": map[string]interface{}{
+			"L:text.html.vingo -meta.embedded.vingo": map[string]interface{}{
+				"patterns": []interface{}{
+					map[string]string{"include": "#escaped"},
+					map[string]string{"include": "#tag"},
+				},
+			},
+		},
+		"repository": map[string]interface{}{
+			"escaped": map[string]string{"match": `\\<\{`, "name": "constant.character.escape.vingo"},
+			"tag": map[string]interface{}{
+				"begin":         `<\{`,
+				"end":           `\}>`,
+				"beginCaptures": map[string]interface{}{"0": map[string]string{"name": "punctuation.section.embedded.begin.vingo"}},
+				"endCaptures":   map[string]interface{}{"0": map[string]string{"name": "punctuation.section.embedded.end.vingo"}},
+				"name":          "meta.embedded.vingo",
+				"contentName":   "source.vingo",
+				"patterns": []interface{}{
+					map[string]string{"match": `(?<=<\{)\s*/(` + strings.Join(endTags, "|") + `)\b`, "name": "keyword.control.vingo"},
+					map[string]string{"match": `(?<=<\{)\s*(` + strings.Join(keywords, "|") + `)\b`, "name": "keyword.control.vingo"},
+					map[string]string{"match": `\b(in|and|or|not|build)\b`, "name": "keyword.operator.word.vingo"},
+					map[string]string{"match": `\b(true|false|nil)\b`, "name": "constant.language.vingo"},
+					str(`"`, "string.quoted.double.vingo"),
+					str(`'`, "string.quoted.single.vingo"),
+					map[string]string{"match": `\b\d+(\.\d+)?([a-zµ]+)?\b`, "name": "constant.numeric.vingo"},
+					map[string]interface{}{
+						"match":    `(\|)\s*([A-Za-z_]\w*)`,
+						"captures": map[string]interface{}{"1": map[string]string{"name": "keyword.operator.filter.vingo"}, "2": map[string]string{"name": "entity.name.function.filter.vingo"}},
+					},
+					map[string]string{"match": `\b[A-Za-z_]\w*(?=\s*\()`, "name": "entity.name.function.vingo"},
+					map[string]string{"match": `\b[A-Za-z_]\w*(?=\s*=[^=])`, "name": "variable.parameter.vingo"},
+					map[string]string{"match": `==|!=|<=|>=|<|>|\+|=|:`, "name": "keyword.operator.vingo"},
+					map[string]string{"match": `\b[A-Za-z_]\w*(\.\w+)*`, "name": "variable.other.vingo"},
+				},
+			},
+		},
+	}
+}
+
+var vingoSnippets = map[string]interface{}{
+	"if": map[string]interface{}{
+		"prefix": "if", "description": "if block",
+		"body": []string{"<{ if ${1:cond} }>", "\t$0", "<{ /if }>"},
+	},
+	"if else": map[string]interface{}{
+		"prefix": "ifelse", "description": "if / else block",
+		"body": []string{"<{ if ${1:cond} }>", "\t$2", "<{ else }>", "\t$0", "<{ /if }>"},
+	},
+	"for": map[string]interface{}{
+		"prefix": "for", "description": "for loop",
+		"body": []string{"<{ for ${1:item} in ${2:list} }>", "\t$0", "<{ /for }>"},
+	},
+	"switch": map[string]interface{}{
+		"prefix": "switch", "description": "switch block",
+		"body": []string{"<{ switch ${1:expr} }>", "<{ case ${2:value} }>", "\t$3", "<{ default }>", "\t$0", "<{ /switch }>"},
+	},
+	"block": map[string]interface{}{
+		"prefix": "block", "description": "named block",
+		"body": []string{`<{ block "${1:name}" }>`, "\t$0", "<{ /block }>"},
+	},
+	"include": map[string]interface{}{
+		"prefix": "include", "description": "include a template",
+		"body": []string{`<{ include "${1:partials/header.vgo}" }>$0`},
+	},
+	"output": map[string]interface{}{
+		"prefix": "<{", "description": "output tag",
+		"body": []string{"<{ ${1:name} }>$0"},
+	},
+}
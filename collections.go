@@ -0,0 +1,283 @@
+package vingo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// -------------------- Map helpers --------------------
+//
+//	<{ include "widgets/chart.vgo" opts=merge(chart_defaults, {height: 300}) }>
+//	<{ set opts = defaults(opts, {color: "blue", legend: true}) }>
+//
+// merge(a, b, ...) is a new map with the entries of every argument, later
+// ones winning; merge(a, b, deep=true) merges nested maps too instead of
+// replacing them. defaults(m, fallback) fills the entries missing (or nil)
+// in m from fallback, recursing into nested maps. Undefined arguments count
+// as empty maps; the arguments are never modified.
+
+func init() {
+	builtinFuncs["merge"] = func(c *Call) (interface{}, error) {
+		deep := condTruthy(c.Kwarg("deep", false))
+		out := map[string]interface{}{}
+		for i, a := range c.Args {
+			m, err := mapArg(a, i)
+			if err != nil {
+				return nil, err
+			}
+			mergeInto(out, m, deep)
+		}
+		return out, nil
+	}
+	builtinFuncs["defaults"] = func(c *Call) (interface{}, error) {
+		if len(c.Args) != 2 {
+			return nil, fmt.Errorf("expected a map and its defaults, got %d arguments", len(c.Args))
+		}
+		m, err := mapArg(c.Arg(0), 0)
+		if err != nil {
+			return nil, err
+		}
+		fallback, err := mapArg(c.Arg(1), 1)
+		if err != nil {
+			return nil, err
+		}
+		return withDefaults(m, fallback), nil
+	}
+}
+
+// mapArg: argument i as a map[string]interface{}; nil is an empty map.
+func mapArg(v interface{}, i int) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := toStringMap(v)
+	if !ok {
+		return nil, fmt.Errorf("argument %d: expected a map, got %T", i+1, v)
+	}
+	return m, nil
+}
+
+// toStringMap: v as a map[string]interface{}, converting other maps with
+// string keys. The map is not copied when v already has that type.
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// mergeInto: copies the entries of src into dst; with deep, maps in both
+// are merged into a new map.
+func mergeInto(dst, src map[string]interface{}, deep bool) {
+	for k, v := range src {
+		if deep {
+			old, ok1 := toStringMap(dst[k])
+			sub, ok2 := toStringMap(v)
+			if ok1 && ok2 {
+				merged := make(map[string]interface{}, len(old)+len(sub))
+				mergeInto(merged, old, true)
+				mergeInto(merged, sub, true)
+				v = merged
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// withDefaults: a copy of m whose missing or nil entries come from fallback.
+func withDefaults(m, fallback map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m)+len(fallback))
+	for k, v := range m {
+		out[k] = v
+	}
+	for k, def := range fallback {
+		v, ok := out[k]
+		if !ok || v == nil {
+			out[k] = def
+			continue
+		}
+		sub, ok1 := toStringMap(v)
+		subDef, ok2 := toStringMap(def)
+		if ok1 && ok2 {
+			out[k] = withDefaults(sub, subDef)
+		}
+	}
+	return out
+}
+
+// -------------------- List helpers --------------------
+//
+//	<{ for p in posts | limit:5 }>        the first 5
+//	<{ for p in posts | offset:20 | limit:10 }>
+//	<{ for p in posts | slice:2:5 }>      items 2, 3 and 4
+//	<{ (posts | first).Title }>, <{ name | last }>
+//
+//	<{ tags | join:", " }>, <{ csv_line | split:"," }>
+//	<{ users | pluck:"Name" | join:", " }>
+//	<{ items | length }> items, <{ len(name) }> characters
+//
+// Indexes start at 0; negative slice indexes count from the end
+// (slice:-3 is the last three). Out of range bounds are clamped, so a short
+// list gives fewer items instead of an error. first and last are
+// undefined for an empty list; on a string they give its first or last
+// character. join writes the items like output does (nothing for undefined ones); split without a
+// separator splits at runs of whitespace. pluck takes a field (or a dotted
+// path) out of every item, undefined for items without it. length (also
+// count and len) is the number of items of a list or map, or characters of
+// a string; len(x) is 0 for an undefined x (filters leave undefined values
+// undefined).
+
+func init() {
+	builtinFuncs["first"] = func(c *Call) (interface{}, error) {
+		return edgeItem(c.Arg(0), true), nil
+	}
+	builtinFuncs["last"] = func(c *Call) (interface{}, error) {
+		return edgeItem(c.Arg(0), false), nil
+	}
+	builtinFuncs["limit"] = func(c *Call) (interface{}, error) {
+		n, err := intArg(c.Arg(1), "limit")
+		if err != nil {
+			return nil, err
+		}
+		items := toList(c.Arg(0))
+		return items[:max(0, min(n, len(items)))], nil
+	}
+	builtinFuncs["offset"] = func(c *Call) (interface{}, error) {
+		n, err := intArg(c.Arg(1), "offset")
+		if err != nil {
+			return nil, err
+		}
+		items := toList(c.Arg(0))
+		return items[max(0, min(n, len(items))):], nil
+	}
+	builtinFuncs["join"] = func(c *Call) (interface{}, error) {
+		var b strings.Builder
+		for i, it := range toList(c.Arg(0)) {
+			if i > 0 {
+				b.WriteString(argString(c.Arg(1)))
+			}
+			if it != nil {
+				b.WriteString(c.s.format(it))
+			}
+		}
+		return b.String(), nil
+	}
+	builtinFuncs["split"] = func(c *Call) (interface{}, error) {
+		var parts []string
+		if sep := argString(c.Arg(1)); sep != "" {
+			parts = strings.Split(argString(c.Arg(0)), sep)
+		} else {
+			parts = strings.Fields(argString(c.Arg(0)))
+		}
+		out := make([]interface{}, len(parts))
+		for i, p := range parts {
+			out[i] = p
+		}
+		return out, nil
+	}
+	builtinFuncs["pluck"] = func(c *Call) (interface{}, error) {
+		field := argString(c.Arg(1))
+		if field == "" {
+			return nil, fmt.Errorf("pluck needs a field name")
+		}
+		path := strings.Split(field, ".")
+		items := toList(c.Arg(0))
+		out := make([]interface{}, len(items))
+		for i, it := range items {
+			out[i], _ = walkPath(it, path, c.s.fieldTag())
+		}
+		return out, nil
+	}
+	builtinFuncs["length"] = lengthFunc
+	builtinFuncs["count"] = lengthFunc
+	builtinFuncs["len"] = lengthFunc
+	builtinFuncs["slice"] = func(c *Call) (interface{}, error) {
+		items := toList(c.Arg(0))
+		start, err := intArg(c.Arg(1), "start")
+		if err != nil {
+			return nil, err
+		}
+		end := len(items)
+		if len(c.Args) > 2 {
+			if end, err = intArg(c.Arg(2), "end"); err != nil {
+				return nil, err
+			}
+		}
+		start, end = sliceBound(start, len(items)), sliceBound(end, len(items))
+		if start >= end {
+			return []interface{}{}, nil
+		}
+		return items[start:end], nil
+	}
+}
+
+func lengthFunc(c *Call) (interface{}, error) {
+	v := c.Arg(0)
+	if v == nil {
+		return 0, nil
+	}
+	if s, ok := v.(string); ok {
+		return utf8.RuneCountInString(s), nil
+	}
+	switch rv := indirect(reflect.ValueOf(v)); rv.Kind() {
+	case reflect.Invalid:
+		return 0, nil
+	case reflect.String:
+		return utf8.RuneCountInString(rv.String()), nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), nil
+	}
+	return nil, fmt.Errorf("cannot take the length of %T", v)
+}
+
+// intArg: v as an int; what names the argument for the error.
+func intArg(v interface{}, what string) (int, error) {
+	n, ok := asNumber(v)
+	if !ok || n.kind != 'i' {
+		return 0, fmt.Errorf("%s must be an integer, got %v", what, v)
+	}
+	return int(n.i), nil
+}
+
+// sliceBound: slice index i of a list of length n, from the end when
+// negative, clamped to [0, n].
+func sliceBound(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return max(0, min(i, n))
+}
+
+// edgeItem: the first (or last) item of a list or character of a string,
+// nil if there is none.
+func edgeItem(v interface{}, first bool) interface{} {
+	if s, ok := v.(string); ok {
+		r := []rune(s)
+		if len(r) == 0 {
+			return nil
+		}
+		if first {
+			return string(r[0])
+		}
+		return string(r[len(r)-1])
+	}
+	items := toList(v)
+	if len(items) == 0 {
+		return nil
+	}
+	if first {
+		return items[0]
+	}
+	return items[len(items)-1]
+}
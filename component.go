@@ -0,0 +1,158 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// -------------------- Components --------------------
+//
+//	<{ component "modal" title="Delete?" }>
+//	  <p>This can't be undone.</p>
+//	  <{ slot "footer" }><button>Delete</button><{ /slot }>
+//	<{ /component }>
+//
+// renders the template components/modal.vgo (see Engine.Components) with
+// the keyword arguments as variables. The body of the tag is rendered
+// first, in the scope of the caller: slot tags fill the named slots, the
+// rest is the default slot. The component template places them with the
+// slot function:
+//
+//	<div class="modal"><h2><{ title }></h2>
+//	<{ slot() }>
+//	<footer><{ slot("footer") | "<button>OK</button>" }></footer></div>
+//
+// slot(name) is undefined for a slot the caller did not fill (the default
+// slot too when the body is only whitespace). Like an include, the
+// component sees the variables of the caller.
+
+// ComponentNode: <{ component }> block.
+type ComponentNode struct {
+	Name string
+	Body []Node
+
+	nodePos
+	vars []kwarg
+}
+
+// SlotNode: <{ slot }> block in the body of a component tag.
+type SlotNode struct {
+	Name string
+	Body []Node
+
+	nodePos
+}
+
+func init() {
+	builtinFuncs["slot"] = func(c *Call) (interface{}, error) {
+		if len(c.s.slots) == 0 {
+			return nil, nil
+		}
+		v, ok := c.s.slots[len(c.s.slots)-1][argString(c.Arg(0))]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	}
+}
+
+func (n *ComponentNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	file := s.engine.componentFile(n.Name)
+	if !s.enterInclude(n.template, file) {
+		return
+	}
+	defer s.leaveInclude()
+
+	// slots, in the scope of the caller
+	prev := s.fills
+	s.fills = map[string]string{}
+	body := getBuffer(0)
+	defer putBuffer(body)
+	evalNodes(s, n.Body, sc, body)
+	fills := s.fills
+	s.fills = prev
+	if strings.TrimSpace(body.String()) != "" {
+		fills[""] = body.String()
+	}
+
+	tpl, err := s.engine.load(file, s.locale, nil)
+	if err != nil {
+		s.fail(fmt.Errorf("vingo: component %q: %w", n.Name, err))
+		return
+	}
+	if len(n.vars) > 0 {
+		vars := make(map[string]interface{}, len(n.vars))
+		for _, kw := range n.vars {
+			vars[kw.name], _ = kw.val.eval(s, sc)
+		}
+		sc = sc.child(vars)
+	}
+	s.slots = append(s.slots, fills)
+	evalNodes(s, tpl.Nodes, sc, out)
+	s.slots = s.slots[:len(s.slots)-1]
+}
+
+func (n *SlotNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if s.fills == nil {
+		s.fail(fmt.Errorf("vingo: slot %q outside of a component tag", n.Name))
+		return
+	}
+	b := getBuffer(0)
+	defer putBuffer(b)
+	evalNodes(s, n.Body, sc, b)
+	s.fills[n.Name] += b.String()
+}
+
+// componentFile: resolved template of the component called name.
+func (e *Engine) componentFile(name string) string {
+	dir := e.Components
+	if dir == "" {
+		dir = "components"
+	}
+	if path.Ext(name) == "" {
+		name += ".vgo"
+	}
+	return e.resolve(path.Join(dir, name))
+}
+
+func parseComponent(tokens []*Token, start int) (*ComponentNode, int, error) {
+	// tokens[start] is TComponent with Value `"name" [name=expr ...]`
+	args, kwargs, err := parseTagArgs(tokens[start].Value)
+	if err != nil || len(args) != 1 {
+		return nil, 0, tokenError(tokens[start], "invalid component tag: %s", tokens[start].Raw)
+	}
+	name := literalName(args[0])
+	if name == "" {
+		return nil, 0, tokenError(tokens[start], "component name must be a string: %s", tokens[start].Raw)
+	}
+	body, i, err := parseBody(tokens, start+1, "component", TEndComponent)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == len(tokens) {
+		return nil, 0, tokenError(tokens[start], "unclosed component %q", name)
+	}
+	return &ComponentNode{Name: name, Body: body, nodePos: posOf(tokens[start]), vars: kwargs}, i + 1, nil
+}
+
+func parseSlot(tokens []*Token, start int) (*SlotNode, int, error) {
+	// tokens[start] is TSlot with Value `"name"`
+	args, kwargs, err := parseTagArgs(tokens[start].Value)
+	if err != nil || len(args) != 1 || len(kwargs) != 0 {
+		return nil, 0, tokenError(tokens[start], "invalid slot tag: %s", tokens[start].Raw)
+	}
+	name := literalName(args[0])
+	if name == "" {
+		return nil, 0, tokenError(tokens[start], "slot name must be a string: %s", tokens[start].Raw)
+	}
+	body, i, err := parseBody(tokens, start+1, "slot", TEndSlot)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == len(tokens) {
+		return nil, 0, tokenError(tokens[start], "unclosed slot %q", name)
+	}
+	return &SlotNode{Name: name, Body: body, nodePos: posOf(tokens[start])}, i + 1, nil
+}
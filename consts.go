@@ -0,0 +1,247 @@
+package vingo
+
+import "slices"
+
+// -------------------- Constants --------------------
+//
+//	e.SetConst("MAX_ITEMS", 20)
+//	<{ if count > MAX_ITEMS }> ... <{ /if }>
+//
+// constants are folded into expressions when a template is compiled: the
+// name becomes a literal, and comparisons / not / and / or whose operands
+// are all literals are computed once. Paths into a constant (LIMITS.Cart)
+// fold too. Loop variables with the same name shadow a constant, as does a
+// variable of a set tag from the tag on.
+//
+//	<{ if build "enterprise" }> ... <{ else }> ... <{ /if }>
+//
+// is true when "enterprise" is one of Engine.BuildTags. Like constants it
+// is resolved at compile time, and if branches whose condition folded to a
+// literal are stripped, so OSS and enterprise variants can share one file
+// without the unused branch in the compiled template.
+
+// SetConst: registers a constant for templates compiled by e. Templates
+// compiled earlier are dropped so they pick up the new value.
+func (e *Engine) SetConst(name string, value interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.consts == nil {
+		e.consts = map[string]interface{}{}
+	}
+	e.consts[name] = value
+	e.cache.clear()
+}
+
+// fold: replaces constants and build tags in the expressions of nodes and
+// strips dead if branches; with a locale, translations are resolved too.
+// Returns the new node list.
+func (e *Engine) fold(nodes []Node, locale string) []Node {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	f := &folder{consts: e.consts, tags: e.BuildTags, fieldTag: e.FieldTag, state: &renderState{engine: e}}
+	if _, shadowed := e.funcs["t"]; locale != "" && !shadowed {
+		f.translate = func(c *callExpr) Expr { return e.translateCall(c, locale) }
+	}
+	return f.nodes(nodes)
+}
+
+type folder struct {
+	consts    map[string]interface{}
+	tags      []string
+	fieldTag  string                 // Engine.FieldTag
+	state     *renderState           // engine settings of conditions (Engine.Truthy)
+	shadow    map[string]int         // loop and set variables in scope
+	translate func(c *callExpr) Expr // calls of t, nil: kept
+}
+
+func (f *folder) nodes(nodes []Node) []Node {
+	out := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *VarNode:
+			n.expr = f.expr(n.expr)
+		case *IfNode:
+			out = append(out, f.ifNode(n)...)
+			continue
+		case *ForNode:
+			n.list = f.expr(n.list)
+			vars := []string{n.ItemVar, n.IndexVar, "loop"}
+			f.bind(vars, 1)
+			if n.where != nil {
+				n.where = f.expr(n.where)
+			}
+			if n.sortBy != nil {
+				n.sortBy = f.expr(n.sortBy)
+			}
+			n.Body = f.nodes(n.Body)
+			f.bind(vars, -1)
+		case *SwitchNode:
+			n.expr = f.expr(n.expr)
+			f.bind([]string{switchValue}, 1)
+			for i := range n.Cases {
+				n.Cases[i].cond = f.expr(n.Cases[i].cond)
+				n.Cases[i].Body = f.nodes(n.Cases[i].Body)
+			}
+			n.Default = f.nodes(n.Default)
+			f.bind([]string{switchValue}, -1)
+		case *BlockNode:
+			n.Body = f.nodes(n.Body)
+		case *CacheNode:
+			n.key = f.expr(n.key)
+			n.vary = f.expr(n.vary)
+			n.per = f.expr(n.per)
+			n.ttl = f.expr(n.ttl)
+			n.stale = f.expr(n.stale)
+			n.Body = f.nodes(n.Body)
+		case *CSVNode:
+			n.delimiter = f.expr(n.delimiter)
+			n.header = f.expr(n.header)
+			n.crlf = f.expr(n.crlf)
+			n.Body = f.nodes(n.Body)
+		case *SectionNode:
+			n.replace = f.expr(n.replace)
+			n.Body = f.nodes(n.Body)
+		case *ComponentNode:
+			for i := range n.vars {
+				n.vars[i].val = f.expr(n.vars[i].val)
+			}
+			n.Body = f.nodes(n.Body)
+		case *SlotNode:
+			n.Body = f.nodes(n.Body)
+		case *OnceNode:
+			if n.key != nil {
+				n.key = f.expr(n.key)
+			}
+			n.Body = f.nodes(n.Body)
+		case *RowNode:
+			for i := range n.fields {
+				n.fields[i] = f.expr(n.fields[i])
+			}
+		case *IncludeNode:
+			if n.path != nil {
+				n.path = f.expr(n.path)
+			}
+			for i := range n.vars {
+				n.vars[i].val = f.expr(n.vars[i].val)
+			}
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// ifNode: folds n; branches with a false literal condition are dropped,
+// one with a true literal condition becomes the else. Returns the nodes
+// replacing n: n itself, or the body that is always taken.
+func (f *folder) ifNode(n *IfNode) []Node {
+	var branches []IfBranch
+	taken := false
+	for _, b := range n.Branches {
+		b.cond = f.expr(b.cond)
+		b.Body = f.nodes(b.Body)
+		lit, ok := b.cond.(*litExpr)
+		if !ok {
+			branches = append(branches, b)
+			continue
+		}
+		if f.state.truthy(lit.val) {
+			n.Else, taken = b.Body, true
+			break
+		}
+	}
+	if !taken {
+		n.Else = f.nodes(n.Else)
+	}
+	n.Branches = branches
+	if len(branches) == 0 {
+		return n.Else
+	}
+	return []Node{n}
+}
+
+// bind: adds (d = 1) or removes (d = -1) names from the shadowed set.
+func (f *folder) bind(names []string, d int) {
+	if f.shadow == nil {
+		f.shadow = map[string]int{}
+	}
+	for _, name := range names {
+		if name != "" {
+			f.shadow[name] += d
+		}
+	}
+}
+
+func (f *folder) expr(x Expr) Expr {
+	switch x := x.(type) {
+	case *pathExpr:
+		if f.shadow[x.parts[0]] > 0 {
+			return x
+		}
+		if c, ok := f.consts[x.parts[0]]; ok {
+			if v, ok := walkPath(c, x.parts[1:], f.fieldTag); ok {
+				return &litExpr{val: v}
+			}
+		}
+	case *buildExpr:
+		return &litExpr{val: slices.Contains(f.tags, x.tag)}
+	case *listExpr:
+		for i, it := range x.items {
+			x.items[i] = f.expr(it)
+		}
+	case *mapExpr:
+		for i, v := range x.vals {
+			x.vals[i] = f.expr(v)
+		}
+	case *callExpr:
+		f.call(x)
+		if x.name == "t" && f.translate != nil {
+			return f.translate(x)
+		}
+	case *filterExpr:
+		x.x = f.expr(x.x)
+		f.call(x.call)
+	case *setExpr:
+		x.x = f.expr(x.x)
+		// the variable lives until the end of the render
+		f.bind([]string{x.name}, 1)
+	case *switchExpr:
+		x.x = f.expr(x.x)
+		for i := range x.cases {
+			c := &x.cases[i]
+			for _, cond := range c.conds {
+				cond.cond = f.expr(cond.cond)
+			}
+			c.val = f.expr(c.val)
+		}
+		if x.def != nil {
+			x.def = f.expr(x.def)
+		}
+	case *binaryExpr:
+		x.left, x.right = f.expr(x.left), f.expr(x.right)
+		if isLiteral(x.left) && isLiteral(x.right) && x.foldable() {
+			v, _ := x.eval(nil, nil)
+			return &litExpr{val: v}
+		}
+	case *notExpr:
+		x.x = f.expr(x.x)
+		if isLiteral(x.x) {
+			v, _ := x.eval(nil, nil)
+			return &litExpr{val: v}
+		}
+	}
+	return x
+}
+
+func (f *folder) call(c *callExpr) {
+	for i, a := range c.args {
+		c.args[i] = f.expr(a)
+	}
+	for i := range c.kwargs {
+		c.kwargs[i].val = f.expr(c.kwargs[i].val)
+	}
+}
+
+func isLiteral(x Expr) bool {
+	_, ok := x.(*litExpr)
+	return ok
+}
@@ -0,0 +1,110 @@
+package vingo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -------------------- Conversion filters --------------------
+//
+//	<{ if request.query.page | toint > 1 }>          "10" > 2 compares as numbers
+//	<{ row.price | tofloat:0 }>                     default for invalid values
+//	<{ if post.date | todate:"02.01.2006" < cutoff }> compares as times
+//
+// coerce string-typed data (query parameters, CSV imports, form values)
+// inside templates. toint and tofloat give their argument (0 without one)
+// for values that are not numbers; toint truncates "3.9" to 3. todate
+// parses with a Go layout, without one RFC 3339 and the common
+// "2006-01-02 15:04:05" and "2006-01-02" forms are tried; it gives
+// undefined for values that don't parse. Numbers and times pass through.
+
+func init() {
+	builtinFuncs["toint"] = toIntFunc
+	builtinFuncs["tofloat"] = toFloatFunc
+	builtinFuncs["todate"] = toDateFunc
+}
+
+func toIntFunc(c *Call) (interface{}, error) {
+	switch v := c.Arg(0).(type) {
+	case int:
+		return v, nil
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n, nil
+		}
+	}
+	if f, ok := numberOf(c.Arg(0)); ok && !math.IsNaN(f) && math.Abs(f) <= math.MaxInt64 {
+		return int(f), nil
+	}
+	return orDefault(c.Arg(1), 0), nil
+}
+
+func toFloatFunc(c *Call) (interface{}, error) {
+	if f, ok := numberOf(c.Arg(0)); ok {
+		return f, nil
+	}
+	return orDefault(c.Arg(1), 0.0), nil
+}
+
+// numberOf: toFloat, accepting surrounding spaces but not booleans.
+func numberOf(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case nil, bool:
+		return 0, false
+	case string:
+		v = strings.TrimSpace(t)
+	}
+	return toFloat(v)
+}
+
+func orDefault(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+// dateLayouts: layouts todate tries without an argument.
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func toDateFunc(c *Call) (interface{}, error) {
+	switch v := c.Arg(0).(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+		return nil, nil
+	case nil:
+		return nil, nil
+	}
+	s := strings.TrimSpace(argString(c.Arg(0)))
+	if len(c.Args) > 1 {
+		layout, ok := c.Arg(1).(string)
+		if !ok || layout == "" {
+			return nil, fmt.Errorf("layout must be a string, got %v", c.Arg(1))
+		}
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+		return nil, nil
+	}
+	if t, ok := parseDate(s); ok {
+		return t, nil
+	}
+	return nil, nil
+}
+
+// parseDate: s parsed with the first of dateLayouts that fits.
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
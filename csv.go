@@ -0,0 +1,152 @@
+package vingo
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+)
+
+// -------------------- CSV / TSV output --------------------
+//
+//	<{ csv header=["Name", "Email", "Total"] }>
+//	<{ for u in users }>
+//	  <{ row u.Name u.Email u.Total }>
+//	<{ /for }>
+//	<{ /csv }>
+//
+// Inside a csv block only row tags write output; the text and variables
+// between them (the layout of the template) are dropped, so exports can be
+// indented like any other template. A row writes its arguments as one
+// record, quoted and escaped like encoding/csv does. Options of the block:
+//
+//	delimiter  field separator, one character (default ","; "\t" for TSV)
+//	header     list of column names, written before the first row
+//	crlf       true ends records with "\r\n" instead of "\n"
+//
+// row is only a tag inside a csv block; elsewhere <{ row | join:"," }> is
+// a variable called row, like the rows of batch. A partial included in a
+// csv block wraps its rows in a csv block of its own; options it doesn't
+// set are taken from the enclosing block:
+//
+//	<{ csv }><{ row item.Name item.Price }><{ /csv }>
+
+// CSVNode: <{ csv }> block.
+type CSVNode struct {
+	Args string // raw tag arguments
+	Body []Node
+
+	nodePos
+	delimiter Expr // optional
+	header    Expr // optional list of column names
+	crlf      Expr // optional
+}
+
+// RowNode: <{ row }> tag, one record of a csv block.
+type RowNode struct {
+	Args string // raw tag arguments
+
+	nodePos
+	fields []Expr
+}
+
+// csvDialect: output settings of the enclosing csv block.
+type csvDialect struct {
+	comma rune
+	crlf  bool
+}
+
+var defaultCSVDialect = &csvDialect{comma: ','}
+
+func (n *CSVNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	d := &csvDialect{comma: ','}
+	if s.csv != nil {
+		*d = *s.csv
+	}
+	if v := optValue(s, n.delimiter, sc); v != nil {
+		r := []rune(argString(v))
+		if len(r) != 1 {
+			s.fail(fmt.Errorf("vingo: csv delimiter must be one character, got %q", argString(v)))
+			return
+		}
+		d.comma = r[0]
+	}
+	d.crlf = condTruthy(optValue(s, n.crlf, sc))
+	if v := optValue(s, n.header, sc); v != nil {
+		header := toList(v)
+		fields := make([]string, len(header))
+		for i, h := range header {
+			fields[i] = argString(h)
+		}
+		if err := d.write(out, fields); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+
+	prev := s.csv
+	s.csv = d
+	evalNodes(s, n.Body, sc, out)
+	s.csv = prev
+}
+
+func (n *RowNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	fields := make([]string, len(n.fields))
+	for i, x := range n.fields {
+		fields[i] = argString(optValue(s, x, sc))
+	}
+	d := s.csv
+	if d == nil {
+		d = defaultCSVDialect
+	}
+	if err := d.write(out, fields); err != nil {
+		s.fail(err)
+	}
+}
+
+// write: fields as one record.
+func (d *csvDialect) write(out *bytes.Buffer, fields []string) error {
+	w := csv.NewWriter(out)
+	w.Comma, w.UseCRLF = d.comma, d.crlf
+	if err := w.Write(fields); err != nil {
+		return fmt.Errorf("vingo: csv: %w", err)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func parseCSV(tokens []*Token, start int) (*CSVNode, int, error) {
+	// tokens[start] is TCSV with Value `[delimiter=","] [header=[...]] [crlf=true]`
+	args, kwargs, err := parseTagArgs(tokens[start].Value)
+	if err != nil || len(args) != 0 {
+		return nil, 0, tokenError(tokens[start], "invalid csv tag: %s", tokens[start].Raw)
+	}
+	node := &CSVNode{Args: tokens[start].Value, nodePos: posOf(tokens[start])}
+	for _, kw := range kwargs {
+		switch kw.name {
+		case "delimiter":
+			node.delimiter = kw.val
+		case "header":
+			node.header = kw.val
+		case "crlf":
+			node.crlf = kw.val
+		default:
+			return nil, 0, tokenError(tokens[start], "unknown csv option %q in: %s", kw.name, tokens[start].Raw)
+		}
+	}
+
+	body, i, err := parseClosed(tokens, start, "csv", TEndCSV)
+	if err != nil {
+		return nil, 0, err
+	}
+	node.Body = body
+	return node, i, nil
+}
+
+func parseRow(t *Token) (*RowNode, error) {
+	// t.Value is `expr expr ...`
+	args, kwargs, err := parseTagArgs(t.Value)
+	if err != nil || len(kwargs) != 0 {
+		return nil, tokenError(t, "invalid row tag: %s", t.Raw)
+	}
+	return &RowNode{Args: t.Value, nodePos: posOf(t), fields: args}, nil
+}
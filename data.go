@@ -0,0 +1,148 @@
+package vingo
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/coderiantest/vingo/internal/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// -------------------- Data files --------------------
+//
+// Render data read from JSON, YAML or TOML, for the CLI and for renders
+// driven by data files instead of Go code.
+
+// DecodeData: parses render data in format "json", "yaml" or "toml". The
+// document must be an object / mapping at the top level.
+func DecodeData(b []byte, format string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(b, &data)
+	case "yaml":
+		err = yaml.Unmarshal(b, &data)
+	case "toml":
+		data, err = toml.Decode(string(b))
+	default:
+		return nil, fmt.Errorf("vingo: unknown data format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vingo: %s data: %w", format, err)
+	}
+	if data == nil { // empty document
+		data = map[string]interface{}{}
+	}
+	return data, nil
+}
+
+// ReadDataFile: reads a data file, the format is taken from its extension.
+func ReadDataFile(path string) (map[string]interface{}, error) {
+	format := DataFormat(path)
+	if format == "" {
+		return nil, fmt.Errorf("vingo: %s: unknown data file extension", path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := DecodeData(b, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// DataFormat: data format of a file by extension (.json, .yaml, .yml,
+// .toml), "" if unknown.
+func DataFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// SplitFrontMatter: separates the front matter at the start of a template,
+// YAML between "---" lines or TOML between "+++" lines, from its body.
+// Without front matter data is nil and body is src.
+//
+//	---
+//	title: About us
+//	---
+//	<h1><{ title }></h1>
+func SplitFrontMatter(src []byte) (data map[string]interface{}, body []byte, err error) {
+	s := strings.TrimPrefix(string(src), "\ufeff")
+	for _, fm := range []struct{ delim, format string }{{"---", "yaml"}, {"+++", "toml"}} {
+		first, rest, ok := strings.Cut(s, "\n")
+		if !ok || strings.TrimRight(first, "\r") != fm.delim {
+			continue
+		}
+		// closing delimiter on a line of its own
+		for i := 0; i < len(rest); {
+			line, _, _ := strings.Cut(rest[i:], "\n")
+			end := min(i+len(line)+1, len(rest))
+			if strings.TrimRight(line, "\r") == fm.delim {
+				data, err := DecodeData([]byte(rest[:i]), fm.format)
+				if err != nil {
+					return nil, nil, err
+				}
+				return data, []byte(rest[end:]), nil
+			}
+			i = end
+		}
+		return nil, nil, fmt.Errorf("vingo: front matter: missing closing %s", fm.delim)
+	}
+	return nil, src, nil
+}
+
+// DataMap: converts render data given as any into the map templates read
+// from. Maps with string keys are copied, so adding to the result leaves
+// data unchanged. Structs (or pointers to structs) expose their exported
+// fields, including the ones promoted from embedded structs, nil yields an
+// empty map.
+func DataMap(data any) (map[string]interface{}, error) {
+	if data == nil {
+		return map[string]interface{}{}, nil
+	}
+	if m, ok := data.(map[string]interface{}); ok {
+		c := make(map[string]interface{}, len(m))
+		maps.Copy(c, m)
+		return c, nil
+	}
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, nil
+	case rv.Kind() == reflect.Struct:
+		fields := typeInfoOf(rv.Type(), "").fields
+		m := make(map[string]interface{}, len(fields))
+		for name, idx := range fields {
+			f, err := rv.FieldByIndexErr(idx)
+			if err != nil {
+				continue // promoted through a nil embedded pointer
+			}
+			m[name] = f.Interface()
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("vingo: unsupported data type %T", data)
+}
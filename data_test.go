@@ -0,0 +1,57 @@
+package vingo
+
+import "testing"
+
+func TestDataMapCopy(t *testing.T) {
+	data := map[string]interface{}{"a": 1}
+	m, err := DataMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m["b"] = 2
+	if _, ok := data["b"]; ok || len(data) != 1 {
+		t.Errorf("DataMap result shares its map with data: %v", data)
+	}
+}
+
+type Base struct {
+	Promoted string
+	Shadowed string
+}
+
+type byValue struct {
+	Base
+	Shadowed string
+}
+
+type byPointer struct {
+	*Base
+	Own string
+}
+
+// TestDataMapPromoted: fields promoted from embedded structs and embedded
+// pointers are template variables.
+func TestDataMapPromoted(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+		want string
+	}{
+		{"embedded struct", byValue{Base: Base{Promoted: "p", Shadowed: "base"}, Shadowed: "outer"}, "p outer"},
+		{"pointer to struct", &byValue{Base: Base{Promoted: "p"}, Shadowed: "outer"}, "p outer"},
+		{"embedded pointer", byPointer{Base: &Base{Promoted: "p", Shadowed: "s"}}, "p s"},
+		{"nil embedded pointer", byPointer{Own: "o"}, " "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := DataMap(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderSource(New(), `<{ Promoted }> <{ Shadowed }>`, m)
+			if err != nil || got != tt.want {
+				t.Errorf("render = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
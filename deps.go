@@ -0,0 +1,109 @@
+package vingo
+
+import (
+	"fmt"
+	"sort"
+)
+
+// -------------------- Dependency graph --------------------
+//
+// Which templates include which others (or use them as components), for build systems that rebuild
+// only the pages affected by a change and for visualizing the structure
+// of layouts and partials (`vingo deps --dot`). Paths are resolved like
+// Render resolves them: absolute, relative includes joined to the
+// directory of their includer.
+
+// DependencyGraph: direct includes of each template, path -> included
+// paths (sorted).
+type DependencyGraph map[string][]string
+
+// Dependencies: templates file includes, directly or through other
+// includes, sorted.
+func (e *Engine) Dependencies(file string) ([]string, error) {
+	path := e.resolve(file)
+	g, err := e.DependencyGraph(path)
+	if err != nil {
+		return nil, err
+	}
+	return reachable(g, path), nil
+}
+
+// DependencyGraph: graph of files and every template they include,
+// directly or not.
+func (e *Engine) DependencyGraph(files ...string) (DependencyGraph, error) {
+	g := DependencyGraph{}
+	var visit func(path string) error
+	visit = func(path string) error {
+		if _, ok := g[path]; ok {
+			return nil
+		}
+		tpl, err := e.getOrCompile(path)
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		var direct []string
+		walkNodes(tpl.Nodes, func(n Node) {
+			file := ""
+			switch n := n.(type) {
+			case *IncludeNode:
+				if n.path == nil {
+					file = n.file
+				}
+			case *ComponentNode:
+				file = e.componentFile(n.Name)
+			}
+			if file != "" && !seen[file] {
+				seen[file] = true
+				direct = append(direct, file)
+			}
+		})
+		sort.Strings(direct)
+		g[path] = direct
+		for _, dep := range direct {
+			if err := visit(dep); err != nil {
+				return fmt.Errorf("vingo: %s: include: %w", path, err)
+			}
+		}
+		return nil
+	}
+	for _, f := range files {
+		if err := visit(e.resolve(f)); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Dependents: templates of the graph that include path (absolute, like
+// the keys of the graph), directly or through other includes, sorted.
+// Those are the templates to rebuild when path changes.
+func (g DependencyGraph) Dependents(path string) []string {
+	reverse := DependencyGraph{}
+	for from, deps := range g {
+		for _, to := range deps {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	return reachable(reverse, path)
+}
+
+// reachable: paths reachable from path following edges, without path itself.
+func reachable(edges DependencyGraph, path string) []string {
+	seen := map[string]bool{path: true}
+	var out []string
+	stack := []string{path}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range edges[p] {
+			if !seen[next] {
+				seen[next] = true
+				out = append(out, next)
+				stack = append(stack, next)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
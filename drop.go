@@ -0,0 +1,186 @@
+package vingo
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// -------------------- Liquid-style drops --------------------
+//
+// Data layers written for Liquid expose "drops": objects whose properties
+// are computed by methods when a template reads them (product.title,
+// product.compare_at_price). A Drop is any value resolving its properties
+// itself; paths through it call Lookup instead of reading fields. NewDrop
+// adapts a Go value the Liquid way:
+//
+//	data := map[string]interface{}{"product": vingo.NewDrop(productDrop)}
+//
+//	<{ product.compare_at_price }>   calls productDrop.CompareAtPrice()
+//
+// so storefront templates keep their snake_case paths and the data layer
+// keeps computing only what a template uses.
+
+// Drop: value whose properties are resolved on access.
+type Drop interface {
+	// Lookup returns the property called name, ok is false if there is none.
+	Lookup(name string) (value interface{}, ok bool)
+}
+
+// MethodDrop: Drop backed by the fields and methods of a Go value.
+//
+// Property names match exported members ignoring case and underscores
+// (compare_at_price, CompareAtPrice; url, URL). Methods must take no
+// arguments and return one value, or a value and an error; an error makes
+// the property undefined. A method is called once per drop, its result is
+// kept for later reads. Structs (and pointers to structs) returned by
+// properties are wrapped as drops too, as are the structs in returned
+// slices, so nested objects use the same names. If the value implements
+// Drop itself, its Lookup is asked for names without a member, like
+// Liquid's liquid_method_missing.
+type MethodDrop struct {
+	v reflect.Value
+
+	mu    sync.Mutex
+	cache map[string]interface{}
+}
+
+// NewDrop: MethodDrop for v.
+func NewDrop(v interface{}) *MethodDrop {
+	return &MethodDrop{v: reflect.ValueOf(v)}
+}
+
+func (d *MethodDrop) Lookup(name string) (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if v, ok := d.cache[name]; ok {
+		return v, true
+	}
+	v, ok := d.resolve(name)
+	if !ok {
+		return nil, false
+	}
+	if d.cache == nil {
+		d.cache = map[string]interface{}{}
+	}
+	d.cache[name] = v
+	return v, true
+}
+
+// resolve: the property called name, uncached.
+func (d *MethodDrop) resolve(name string) (interface{}, bool) {
+	if !d.v.IsValid() {
+		return nil, false
+	}
+	m, ok := dropMembersOf(d.v.Type())[dropKey(name)]
+	if !ok {
+		if fallback, ok := d.v.Interface().(Drop); ok {
+			return fallback.Lookup(name)
+		}
+		return nil, false
+	}
+	var rv reflect.Value
+	if m.field != nil {
+		rv = d.v
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return nil, false
+			}
+			rv = rv.Elem()
+		}
+		f, err := rv.FieldByIndexErr(m.field)
+		if err != nil {
+			return nil, false
+		}
+		rv = f
+	} else {
+		if d.v.Kind() == reflect.Pointer && d.v.IsNil() {
+			return nil, false
+		}
+		out := d.v.Method(m.method).Call(nil)
+		if m.err && !out[1].IsNil() {
+			return nil, false
+		}
+		rv = out[0]
+	}
+	return wrapDrop(rv), true
+}
+
+// wrapDrop: interface value of rv, structs and slices of structs wrapped
+// in MethodDrops.
+func wrapDrop(rv reflect.Value) interface{} {
+	for rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if _, ok := rv.Interface().(Drop); ok {
+		return rv.Interface()
+	}
+	switch {
+	case isStructLike(rv.Type()):
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil
+		}
+		return &MethodDrop{v: rv}
+	case (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && isStructLike(rv.Type().Elem()):
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = wrapDrop(rv.Index(i))
+		}
+		return items
+	}
+	return rv.Interface()
+}
+
+// isStructLike: struct or pointer to struct.
+func isStructLike(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// dropMember: how a property of a MethodDrop is read.
+type dropMember struct {
+	field  []int // field index path; nil: method
+	method int
+	err    bool // the method also returns an error
+}
+
+var dropMembers sync.Map // reflect.Type -> map[string]dropMember
+
+var errorType = reflect.TypeFor[error]()
+
+// dropMembersOf: properties of t by dropKey; methods win over fields.
+func dropMembersOf(t reflect.Type) map[string]dropMember {
+	if m, ok := dropMembers.Load(t); ok {
+		return m.(map[string]dropMember)
+	}
+	members := map[string]dropMember{}
+	ti := typeInfoOf(t, "")
+	for name, idx := range ti.fields {
+		members[dropKey(name)] = dropMember{field: idx}
+	}
+	for i := 0; i < t.NumMethod(); i++ {
+		mt := t.Method(i).Type
+		switch {
+		case mt.NumIn() != 1:
+		case mt.NumOut() == 1:
+			members[dropKey(t.Method(i).Name)] = dropMember{method: i}
+		case mt.NumOut() == 2 && mt.Out(1) == errorType:
+			members[dropKey(t.Method(i).Name)] = dropMember{method: i, err: true}
+		}
+	}
+	actual, _ := dropMembers.LoadOrStore(t, members)
+	return actual.(map[string]dropMember)
+}
+
+// dropKey: name without case and underscores.
+func dropKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
@@ -0,0 +1,61 @@
+package vingo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/unicode/norm"
+)
+
+// -------------------- Output encoding --------------------
+
+// OutputOptions: post-processing applied to the rendered output, for files
+// consumed by Windows tools or legacy (non UTF-8) mail systems. Characters
+// the target charset cannot represent are replaced by its substitution byte.
+type OutputOptions struct {
+	CRLF    bool   // convert "\n" line endings to "\r\n"
+	BOM     bool   // prefix the output with a UTF-8 byte order mark
+	Charset string // target charset, e.g. "iso-8859-9", "windows-1254"; "" keeps UTF-8
+
+	// NFC: normalizes the output (template text and values alike) to
+	// Unicode NFC, so text from templates and data stored in different
+	// forms ("i̇" as i + U+0307 or precomposed) compares, searches and
+	// renders the same. Invalid UTF-8 is left as it is.
+	NFC bool
+}
+
+var errBOMCharset = errors.New("vingo: BOM is only supported for UTF-8 output")
+
+// encodeOutput: applies o to a rendered UTF-8 string.
+func (o OutputOptions) encodeOutput(out string) (string, error) {
+	if o.NFC {
+		out = norm.NFC.String(out)
+	}
+	if o.CRLF {
+		out = strings.ReplaceAll(strings.ReplaceAll(out, "\r\n", "\n"), "\n", "\r\n")
+	}
+
+	utf8 := o.Charset == "" || strings.EqualFold(o.Charset, "utf-8") || strings.EqualFold(o.Charset, "utf8")
+	if o.BOM {
+		if !utf8 {
+			return "", errBOMCharset
+		}
+		out = "\ufeff" + out
+	}
+	if utf8 {
+		return out, nil
+	}
+
+	enc, err := htmlindex.Get(o.Charset)
+	if err != nil {
+		return "", fmt.Errorf("vingo: unknown charset %q", o.Charset)
+	}
+	b, err := encoding.ReplaceUnsupported(enc.NewEncoder()).String(out)
+	if err != nil {
+		return "", fmt.Errorf("vingo: encoding output as %s: %w", o.Charset, err)
+	}
+	return b, nil
+}
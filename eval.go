@@ -1,233 +1,270 @@
 package vingo
 
 import (
+	"cmp"
+	"database/sql/driver"
 	"fmt"
+	"math"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // -------------------- Helpers / utilities --------------------
 
-// lookup: dot notation support for map/struct
-func lookup(data map[string]interface{}, path string) (interface{}, bool) {
-	// if path is literal string "..." or number or boolean, don't treat as lookup
-	p := strings.TrimSpace(path)
-	if p == "" {
-		return nil, false
+// scope: variables visible to a node. Loops and switch cases push a child
+// scope holding only their own variables, shadowing the outer ones, instead
+// of copying the whole data map.
+type scope struct {
+	vars   map[string]interface{}
+	parent *scope
+}
+
+func (sc *scope) child(vars map[string]interface{}) *scope {
+	return &scope{vars: vars, parent: sc}
+}
+
+// set: assigns name in this scope.
+func (sc *scope) set(name string, v interface{}) {
+	if sc.vars == nil {
+		sc.vars = map[string]interface{}{}
 	}
-	// quoted string?
-	if (strings.HasPrefix(p, "\"") && strings.HasSuffix(p, "\"")) || (strings.HasPrefix(p, "'") && strings.HasSuffix(p, "'")) {
-		unq, err := strconv.Unquote(p)
-		if err == nil {
-			return unq, true
+	sc.vars[name] = v
+}
+
+// snapshot: copy of the scope unaffected by later writes; for loops
+// overwrite their variables in place on every iteration. The root data map
+// is shared, not copied.
+func (sc *scope) snapshot() *scope {
+	if sc.parent == nil {
+		return sc
+	}
+	vars := map[string]interface{}{}
+	for ; sc.parent != nil; sc = sc.parent {
+		for k, v := range sc.vars {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+			}
 		}
 	}
-	// numeric literal?
-	if i, err := strconv.Atoi(p); err == nil {
-		return i, true
+	return sc.child(vars)
+}
+
+// flatten: every variable visible in the scope, inner ones shadowing outer
+// ones, as one map.
+func (sc *scope) flatten() map[string]interface{} {
+	vars := map[string]interface{}{}
+	for ; sc != nil; sc = sc.parent {
+		for k, v := range sc.vars {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+			}
+		}
 	}
-	if f, err := strconv.ParseFloat(p, 64); err == nil {
-		return f, true
+	return vars
+}
+
+// get: value of the innermost variable called name.
+func (sc *scope) get(name string) (interface{}, bool) {
+	for ; sc != nil; sc = sc.parent {
+		if v, ok := sc.vars[name]; ok {
+			return v, true
+		}
 	}
-	if p == "true" {
-		return true, true
+	return nil, false
+}
+
+// lookup: dot notation support for map/struct, parts is the split path;
+// tag is the struct tag naming fields besides `vingo` (see Engine.FieldTag).
+func (sc *scope) lookup(parts []string, tag string) (interface{}, bool) {
+	cur, ok := sc.get(parts[0])
+	if !ok {
+		return nil, false
 	}
-	if p == "false" {
-		return false, true
+	return walkPath(cur, parts[1:], tag)
+}
+
+// walkPath: follows path (field names / map keys) from cur. Pointers and
+// interfaces are followed at every segment; a nil pointer is undefined.
+func walkPath(cur interface{}, path []string, tag string) (interface{}, bool) {
+	for i, seg := range path {
+		node, ok := cur.(map[string]interface{})
+		if !ok {
+			if d, ok := cur.(Drop); ok {
+				if cur, ok = d.Lookup(seg); !ok {
+					return nil, false
+				}
+				continue
+			}
+			// walk the rest as reflect values, so structs along the path
+			// are not copied into interfaces
+			return reflectPath(reflect.ValueOf(cur), path[i:], tag)
+		}
+		if cur, ok = node[seg]; !ok {
+			return nil, false
+		}
 	}
+	return derefValue(cur)
+}
 
-	var cur interface{} = data
-	parts := strings.Split(p, ".")
-	for _, seg := range parts {
-		switch node := cur.(type) {
-		case map[string]interface{}:
-			v, ok := node[seg]
-			if !ok {
-				return nil, false
+// derefValue: v with pointers to non-struct values (*string of a nullable
+// column, ...) followed; undefined for a nil pointer.
+func derefValue(v interface{}) (interface{}, bool) {
+	switch v.(type) {
+	case nil, string, int, bool, float64, map[string]interface{}, []interface{}:
+		return v, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		return v, true
+	}
+	if rv = indirect(rv); !rv.IsValid() {
+		return nil, false
+	}
+	return rv.Interface(), true
+}
+
+// indirect: rv with interfaces unwrapped and pointers followed; a pointer
+// to a struct or to a Drop is kept for its pointer methods. Invalid for a
+// nil pointer or interface.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() {
+		switch rv.Kind() {
+		case reflect.Interface:
+			rv = rv.Elem()
+		case reflect.Pointer:
+			if rv.IsNil() {
+				return reflect.Value{}
 			}
-			cur = v
+			if rv.Elem().Kind() == reflect.Struct || typeInfoOf(rv.Type(), "").drop {
+				return rv
+			}
+			rv = rv.Elem()
 		default:
-			rv := reflect.ValueOf(cur)
-			switch rv.Kind() {
-			case reflect.Map:
-				if rv.Type().Key().Kind() == reflect.String {
-					mv := rv.MapIndex(reflect.ValueOf(seg))
-					if !mv.IsValid() {
-						return nil, false
-					}
-					cur = mv.Interface()
-				} else {
-					return nil, false
-				}
-			case reflect.Struct:
-				f := rv.FieldByName(seg)
-				if f.IsValid() {
-					cur = f.Interface()
-				} else {
-					// try method? (not implemented)
-					return nil, false
-				}
-			default:
+			return rv
+		}
+	}
+	return rv
+}
+
+// reflectPath: follows path through maps, structs and pointers from rv.
+func reflectPath(rv reflect.Value, path []string, tag string) (interface{}, bool) {
+	for i, seg := range path {
+		if rv = indirect(rv); !rv.IsValid() {
+			return nil, false
+		}
+		if typeInfoOf(rv.Type(), "").drop && rv.CanInterface() {
+			return walkPath(rv.Interface(), path[i:], tag)
+		}
+		if rv.Kind() == reflect.Map {
+			if rv.Type().Key().Kind() != reflect.String {
 				return nil, false
 			}
+			rv = rv.MapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()))
+			continue
+		}
+		var ok bool
+		if rv, ok = member(rv, seg, tag); !ok {
+			return nil, false
 		}
 	}
-	return cur, true
+	if rv = indirect(rv); !rv.IsValid() || !rv.CanInterface() {
+		return nil, false
+	}
+	return rv.Interface(), true
 }
 
-func lookupVal(data map[string]interface{}, path string) interface{} {
-	v, _ := lookup(data, path)
-	return v
+// typeInfo: reflection metadata of a type, computed once per type so paths
+// into structs don't repeat FieldByName/MethodByName on every access.
+type typeInfo struct {
+	fields  map[string][]int // exported field (promoted ones too) -> index path
+	methods map[string]int   // exported method without arguments and with one result -> index
+	drop    bool             // implements Drop
 }
 
-func shallowCopyMap(m map[string]interface{}) map[string]interface{} {
-	n := make(map[string]interface{}, len(m)+4)
-	for k, v := range m {
-		n[k] = v
-	}
-	return n
+// typeInfoKey: a type and the struct tag naming its fields besides `vingo`.
+type typeInfoKey struct {
+	t   reflect.Type
+	tag string
 }
 
-// -------------------- Expression Evaluator (basit) --------------------
-//
-// Supports:
-// - Comparisons: ==, !=, >, <, >=, <=
-// - Logical: and, or (left-to-right, no operator precedence beyond that)
-// - Parentheses not supported in this simple evaluator (could be added)
-// - Left and right operands can be identifiers (dot notation), quoted strings, numbers, booleans.
+var typeInfos sync.Map // typeInfoKey -> *typeInfo
 
-var compOpRe = regexp.MustCompile(`\s*(==|!=|>=|<=|>|<)\s*`)
+var dropType = reflect.TypeFor[Drop]()
 
-func evalCondition(expr string, data map[string]interface{}) (bool, error) {
-	// split by " and " / " or " preserving order
-	// implement left-to-right evaluation
-	tokens := splitLogical(expr)
-	if len(tokens) == 0 {
-		// treat empty as false
-		return false, nil
+// typeInfoOf: metadata of t. Fields are found by their Go name, the name
+// in their `vingo:"name"` tag and, unless tag is "", the name in that tag
+// (`json:"first_name"`); Go names win over tag names.
+func typeInfoOf(t reflect.Type, tag string) *typeInfo {
+	key := typeInfoKey{t, tag}
+	if ti, ok := typeInfos.Load(key); ok {
+		return ti.(*typeInfo)
 	}
-	// tokens like: [cond, op, cond, op, cond...], where op is "and"/"or"
-	// evaluate first cond
-	res, err := evalSimpleCond(strings.TrimSpace(tokens[0]), data)
-	if err != nil {
-		return false, err
-	}
-	i := 1
-	for i < len(tokens)-0 {
-		op := strings.TrimSpace(tokens[i])
-		nextExpr := strings.TrimSpace(tokens[i+1])
-		nextRes, err := evalSimpleCond(nextExpr, data)
-		if err != nil {
-			return false, err
-		}
-		if op == "and" {
-			res = res && nextRes
-		} else if op == "or" {
-			res = res || nextRes
-		} else {
-			return false, fmt.Errorf("unknown logical operator %s", op)
-		}
-		i += 2
-		if i >= len(tokens) {
-			break
+	ti := &typeInfo{fields: map[string][]int{}, methods: map[string]int{}, drop: t.Implements(dropType)}
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.Type.NumIn() == 1 && m.Type.NumOut() == 1 {
+			ti.methods[m.Name] = i
 		}
 	}
-	return res, nil
-}
-
-func splitLogical(expr string) []string {
-	// naive split: find " and " and " or " tokens
-	parts := []string{}
-	cur := ""
-	low := strings.TrimSpace(expr)
-	words := strings.Fields(low)
-	// rebuild by scanning tokens
-	i := 0
-	for i < len(words) {
-		w := words[i]
-		if w == "and" || w == "or" {
-			parts = append(parts, strings.TrimSpace(cur))
-			parts = append(parts, w)
-			cur = ""
-		} else {
-			if cur == "" {
-				cur = w
-			} else {
-				cur += " " + w
+	st := t
+	for st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st.Kind() == reflect.Struct {
+		var tagged []reflect.StructField
+		for _, f := range reflect.VisibleFields(st) {
+			if !f.IsExported() {
+				continue
+			}
+			// FieldByName resolves depth and ambiguity like the Go selector
+			if sf, ok := st.FieldByName(f.Name); ok {
+				ti.fields[f.Name] = sf.Index
+				tagged = append(tagged, sf)
+			}
+		}
+		for _, name := range []string{"vingo", tag} {
+			if name == "" {
+				continue
+			}
+			for _, f := range tagged {
+				key, _, _ := strings.Cut(f.Tag.Get(name), ",")
+				if _, taken := ti.fields[key]; key != "" && key != "-" && !taken {
+					ti.fields[key] = f.Index
+				}
 			}
 		}
-		i++
-	}
-	if cur != "" {
-		parts = append(parts, strings.TrimSpace(cur))
 	}
-	return parts
+	actual, _ := typeInfos.LoadOrStore(key, ti)
+	return actual.(*typeInfo)
 }
 
-func evalSimpleCond(cond string, data map[string]interface{}) (bool, error) {
-	// If condition contains comparison operator -> split
-	if compOpRe.MatchString(cond) {
-		// loc := compOpRe.FindStringIndex(cond)
-		op := compOpRe.FindStringSubmatch(cond)[1]
-		parts := compOpRe.Split(cond, 2)
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid comparison in '%s'", cond)
+// member: field or method called name of a struct (or pointer to one).
+func member(rv reflect.Value, name, tag string) (reflect.Value, bool) {
+	ti := typeInfoOf(rv.Type(), tag)
+	if idx, ok := ti.fields[name]; ok {
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
 		}
-		left := strings.TrimSpace(parts[0])
-		right := strings.TrimSpace(parts[1])
-		lv, lok := lookup(data, left)
-		if !lok {
-			// try literal
-			lv = literalFromString(left)
+		f, err := rv.FieldByIndexErr(idx)
+		if err != nil {
+			// nil embedded pointer on the way
+			return reflect.Value{}, false
 		}
-		rv, rok := lookup(data, right)
-		if !rok {
-			rv = literalFromString(right)
+		return f, true
+	}
+	if i, ok := ti.methods[name]; ok {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return reflect.Value{}, false
 		}
-		return compareValues(lv, rv, op)
-	}
-	// no operator => truthy check of the expression (variable or literal)
-	v, ok := lookup(data, cond)
-	if ok {
-		return condTruthy(v), nil
-	}
-	// maybe it's literal
-	v2 := literalFromString(cond)
-	return condTruthy(v2), nil
-}
-
-func evalConditionWithValue(condExpr string, value interface{}, data map[string]interface{}) (bool, error) {
-	// For switch-case convenience: if condExpr is a literal or simple comparison referencing 'value' or '.' shorthand
-	// We'll replace occurrences of "value" or "." with actual value by injecting into data map as special var "__switch__"
-	tmp := shallowCopyMap(data)
-	tmp["__switch__"] = value
-	// allow shorthand: if condExpr equals plain string/number, compare with value
-	// But to reuse evalSimpleCond, we accept expressions like "__switch__ == 5" or simply "5" (then compare)
-	// If condExpr has no operator, treat as equality to value.
-	if compOpRe.MatchString(condExpr) {
-		// eval normally but with lookup resolving identifiers possibly
-		return evalCondition(condExpr, tmp)
-	}
-	// no operator: compare value stringified to condExpr literal or to evaluated lookup
-	// try literal
-	lit := literalFromString(strings.TrimSpace(condExpr))
-	// compare value vs lit
-	ok, err := compareValues(value, lit, "==")
-	if err == nil && ok {
-		return true, nil
-	}
-	// try comparing string form
-	if fmt.Sprintf("%v", value) == fmt.Sprintf("%v", lit) {
-		return true, nil
-	}
-	// else try evaluating cond as expression with __switch__ variable
-	res, err := evalCondition(condExpr, tmp)
-	if err == nil {
-		return res, nil
-	}
-	return false, nil
+		return rv.Method(i).Call(nil)[0], true
+	}
+	return reflect.Value{}, false
 }
 
 func literalFromString(s string) interface{} {
@@ -259,55 +296,222 @@ func literalFromString(s string) interface{} {
 	return s
 }
 
+// addValues: a + b; numbers are added, anything else is joined as text.
+func addValues(a, b interface{}) interface{} {
+	if ai, ok := a.(int); ok {
+		if bi, ok := b.(int); ok {
+			return ai + bi
+		}
+	}
+	_, aStr := a.(string)
+	_, bStr := b.(string)
+	if !aStr && !bStr {
+		af, aNum := toFloat(a)
+		bf, bNum := toFloat(b)
+		if aNum && bNum {
+			return af + bf
+		}
+	}
+	return argString(a) + argString(b)
+}
+
+// valuesEqual: == comparison, falling back to comparing string forms.
+func valuesEqual(a, b interface{}) bool {
+	if ok, err := compareValues(a, b, "=="); err == nil && ok {
+		return true
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
+
+// compareValues: a op b. Integers compare exactly as int64 / uint64, other
+// numbers (numeric strings too) as float64, times and strings in their own
+// order; a time compares with a date string and a duration with "90m".
+// == and != work between any values, falling back to their string forms.
+// Ordering comparisons are false when a or b is nil (undefined), and an
+// error for values of incompatible types.
 func compareValues(a interface{}, b interface{}, op string) (bool, error) {
-	// first try numeric comparison
-	af, aIsNum := toFloat(a)
-	bf, bIsNum := toFloat(b)
-	if aIsNum && bIsNum {
-		switch op {
-		case "==":
-			return af == bf, nil
-		case "!=":
-			return af != bf, nil
-		case ">":
-			return af > bf, nil
-		case "<":
-			return af < bf, nil
-		case ">=":
-			return af >= bf, nil
-		case "<=":
-			return af <= bf, nil
+	if at, bt, ok := timePair(a, b); ok {
+		return compareResult(at.Compare(bt), op)
+	}
+	if ad, bd, ok := durationPair(a, b); ok {
+		return compareResult(cmp.Compare(ad, bd), op)
+	}
+	if an, ok := asNumber(a); ok {
+		if bn, ok := asNumber(b); ok {
+			return compareResult(an.cmp(bn), op)
 		}
 	}
-	// boolean
-	if ab, ok := a.(bool); ok {
-		if bb, ok2 := b.(bool); ok2 {
-			switch op {
-			case "==":
-				return ab == bb, nil
-			case "!=":
-				return ab != bb, nil
-			}
+	if as, ok := stringOf(a); ok {
+		if bs, ok := stringOf(b); ok {
+			return compareResult(strings.Compare(as, bs), op)
+		}
+	}
+	switch op {
+	case "==":
+		return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b), nil
+	case "!=":
+		return fmt.Sprintf("%v", a) != fmt.Sprintf("%v", b), nil
+	}
+	if a == nil || b == nil {
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot compare %T %s %T", a, op, b)
+}
+
+// timePair: a and b as times when one of them is a time.Time and the other
+// a time or a date string (see dateLayouts).
+func timePair(a, b interface{}) (time.Time, time.Time, bool) {
+	at, aTime := a.(time.Time)
+	bt, bTime := b.(time.Time)
+	switch {
+	case aTime && bTime:
+		return at, bt, true
+	case aTime:
+		if s, ok := b.(string); ok {
+			bt, ok = parseDate(strings.TrimSpace(s))
+			return at, bt, ok
+		}
+	case bTime:
+		if s, ok := a.(string); ok {
+			at, ok = parseDate(strings.TrimSpace(s))
+			return at, bt, ok
 		}
 	}
-	// string compare
-	as := fmt.Sprintf("%v", a)
-	bs := fmt.Sprintf("%v", b)
+	return at, bt, false
+}
+
+// durationPair: a and b as durations when one of them is a time.Duration
+// and the other a string like "90m"; durations compare with numbers as
+// nanoseconds.
+func durationPair(a, b interface{}) (time.Duration, time.Duration, bool) {
+	ad, aDur := a.(time.Duration)
+	bd, bDur := b.(time.Duration)
+	var err error
+	switch {
+	case aDur && !bDur:
+		if s, ok := b.(string); ok {
+			bd, err = time.ParseDuration(strings.TrimSpace(s))
+			return ad, bd, err == nil
+		}
+	case bDur && !aDur:
+		if s, ok := a.(string); ok {
+			ad, err = time.ParseDuration(strings.TrimSpace(s))
+			return ad, bd, err == nil
+		}
+	}
+	return ad, bd, false
+}
+
+// compareResult: op applied to the result c of a three-way comparison.
+func compareResult(c int, op string) (bool, error) {
 	switch op {
 	case "==":
-		return as == bs, nil
+		return c == 0, nil
 	case "!=":
-		return as != bs, nil
+		return c != 0, nil
 	case ">":
-		return as > bs, nil
+		return c > 0, nil
 	case "<":
-		return as < bs, nil
+		return c < 0, nil
 	case ">=":
-		return as >= bs, nil
+		return c >= 0, nil
 	case "<=":
-		return as <= bs, nil
+		return c <= 0, nil
 	}
-	return false, fmt.Errorf("unsupported comparison between %T and %T", a, b)
+	return false, fmt.Errorf("vingo: unknown comparison operator %q", op)
+}
+
+// number: a value compared as a number; integers are kept exact.
+type number struct {
+	kind byte // 'i': i, 'u': u (above MaxInt64), 'f': f
+	i    int64
+	u    uint64
+	f    float64
+}
+
+// asNumber: v as a number: integer and float kinds, and strings (or
+// Stringers, e.g. decimal types) holding a number.
+func asNumber(v interface{}) (number, bool) {
+	switch t := v.(type) {
+	case int:
+		return number{kind: 'i', i: int64(t)}, true
+	case int64:
+		return number{kind: 'i', i: t}, true
+	case float64:
+		return number{kind: 'f', f: t}, true
+	case string:
+		return parseNumber(t)
+	case bool, nil:
+		return number{}, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{kind: 'i', i: rv.Int()}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			return number{kind: 'u', u: u}, true
+		}
+		return number{kind: 'i', i: int64(rv.Uint())}, true
+	case reflect.Float32, reflect.Float64:
+		return number{kind: 'f', f: rv.Float()}, true
+	case reflect.String:
+		return parseNumber(rv.String())
+	}
+	if st, ok := v.(fmt.Stringer); ok {
+		return parseNumber(st.String())
+	}
+	return number{}, false
+}
+
+func parseNumber(s string) (number, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return number{kind: 'i', i: i}, true
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return number{kind: 'u', u: u}, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return number{kind: 'f', f: f}, true
+	}
+	return number{}, false
+}
+
+// float: n as a float64, rounded for large integers.
+func (n number) float() float64 {
+	switch n.kind {
+	case 'i':
+		return float64(n.i)
+	case 'u':
+		return float64(n.u)
+	}
+	return n.f
+}
+
+// cmp: -1, 0 or +1 as n is less than, equal to or greater than m.
+func (n number) cmp(m number) int {
+	switch {
+	case n.kind == 'i' && m.kind == 'i':
+		return cmp.Compare(n.i, m.i)
+	case n.kind == 'u' && m.kind == 'u':
+		return cmp.Compare(n.u, m.u)
+	case n.kind == 'i' && m.kind == 'u':
+		return -1 // m is above MaxInt64
+	case n.kind == 'u' && m.kind == 'i':
+		return 1
+	}
+	return cmp.Compare(n.float(), m.float())
+}
+
+// stringOf: v as a string, for string kinds.
+func stringOf(v interface{}) (string, bool) {
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
 }
 
 func toFloat(v interface{}) (float64, bool) {
@@ -349,6 +553,23 @@ func toFloat(v interface{}) (float64, bool) {
 
 // -------------------- Truthy --------------------
 
+// Truther: a type deciding its own truthiness in conditions, e.g. an option
+// type that is false when empty. Without it structs are always true.
+type Truther interface {
+	VingoTruthy() bool
+}
+
+// truthy: condition value of v, asking Engine.Truthy first. s is nil when
+// constants are folded.
+func (s *renderState) truthy(v interface{}) bool {
+	if s != nil && s.engine.Truthy != nil {
+		if t, ok := s.engine.Truthy(v); ok {
+			return t
+		}
+	}
+	return condTruthy(v)
+}
+
 func condTruthy(v interface{}) bool {
 	if v == nil {
 		return false
@@ -364,12 +585,22 @@ func condTruthy(v interface{}) bool {
 		return reflect.ValueOf(v).Uint() != 0
 	case float32, float64:
 		return reflect.ValueOf(v).Float() != 0
+	case Truther:
+		return t.VingoTruthy()
+	case driver.Valuer:
+		// sql.NullString and friends: false when NULL
+		val, err := t.Value()
+		return err == nil && condTruthy(val)
+	case time.Time:
+		return !t.IsZero()
 	default:
 		// slices/maps: non-empty => true
 		rv := reflect.ValueOf(v)
 		switch rv.Kind() {
 		case reflect.Slice, reflect.Array, reflect.Map:
 			return rv.Len() > 0
+		case reflect.Pointer:
+			return !rv.IsNil()
 		default:
 			return true
 		}
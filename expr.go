@@ -0,0 +1,876 @@
+package vingo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -------------------- Expressions --------------------
+//
+// Output tags and if/switch/case conditions are parsed into a small
+// expression tree at compile time, rendering is a plain tree walk:
+//   - literals: "str", 'str', 42, 1.5, true, false, [a, b, c], durations 90s, 1h30m,
+//     maps {"title": t, size: 2} (bare keys are strings)
+//   - variables with dot notation: user.Name
+//   - function calls with positional and keyword arguments: image("a.jpg", widths=[480, 960])
+//   - build tags: build "enterprise", see Engine.BuildTags
+//   - filters, binding tighter than operators: name | upper, title | truncate:20
+//     is the call truncate(title, 20); any function can be used as a filter
+//   - +: adds numbers, joins anything else as text ("sidebar:" + user.ID)
+//   - comparisons: ==, !=, >, <, >=, <=
+//   - logical: not (or !), and, or - in that order of precedence, parentheses group
+//   - switch: switch status case "paid": "green" case "late", "due": "red" default: "gray",
+//     case values match like the cases of the switch tag (case > 100: ... too);
+//     undefined without a match or default
+//
+// The set tag assigns the value of an expression to a variable of the
+// render: <{ set badge = switch status case "paid": "green" default: "gray" }>.
+// It is visible to the rest of the render, including includes; loop
+// variables and include arguments of the same name shadow it.
+//
+// In == and != comparisons an undefined bare name stands for itself, so
+// `status == paid` compares against the string "paid". >, <, >= and <= are
+// false when a side is undefined or nil: <{ if user.Age > 18 }> without a
+// user is not an error.
+
+// Expr: parsed expression. eval reports false as second value when the
+// expression refers to an undefined variable.
+type Expr interface {
+	eval(s *renderState, sc *scope) (interface{}, bool)
+}
+
+type litExpr struct {
+	val interface{}
+}
+
+func (e *litExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	return e.val, true
+}
+
+type pathExpr struct {
+	path  string
+	parts []string // path split on "."
+}
+
+func newPathExpr(path string) *pathExpr {
+	return &pathExpr{path: path, parts: strings.Split(path, ".")}
+}
+
+func (e *pathExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	return sc.lookup(e.parts, s.fieldTag())
+}
+
+type listExpr struct {
+	items []Expr
+}
+
+func (e *listExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	out := make([]interface{}, len(e.items))
+	for i, it := range e.items {
+		out[i], _ = it.eval(s, sc)
+	}
+	return out, true
+}
+
+// mapExpr: {key: value, ...}; evaluates to a new map[string]interface{}.
+type mapExpr struct {
+	keys []string
+	vals []Expr
+}
+
+func (e *mapExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	out := make(map[string]interface{}, len(e.keys))
+	for i, k := range e.keys {
+		out[k], _ = e.vals[i].eval(s, sc)
+	}
+	return out, true
+}
+
+type kwarg struct {
+	name string
+	val  Expr
+}
+
+type callExpr struct {
+	name   string
+	args   []Expr
+	kwargs []kwarg
+}
+
+func (e *callExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	return e.call(s, sc, nil)
+}
+
+// call: calls the function with the evaluated arguments, prefixed by the
+// values in first (the piped value of a filter).
+func (e *callExpr) call(s *renderState, sc *scope, first []interface{}) (interface{}, bool) {
+	args := make([]interface{}, len(first)+len(e.args))
+	copy(args, first)
+	for i, a := range e.args {
+		args[len(first)+i], _ = a.eval(s, sc)
+	}
+	var kwargs map[string]interface{}
+	if len(e.kwargs) > 0 {
+		kwargs = make(map[string]interface{}, len(e.kwargs))
+		for _, kw := range e.kwargs {
+			kwargs[kw.name], _ = kw.val.eval(s, sc)
+		}
+	}
+	return callFunc(s, e.name, args, kwargs)
+}
+
+// callFunc: calls the template function name; errors fail the render.
+func callFunc(s *renderState, name string, args []interface{}, kwargs map[string]interface{}) (interface{}, bool) {
+	s.op()
+	fn := s.engine.lookupFunc(name)
+	if fn == nil {
+		s.fail(fmt.Errorf("vingo: unknown function %q", name))
+		return nil, false
+	}
+	v, err := fn(&Call{Args: args, Kwargs: kwargs, s: s})
+	if err != nil {
+		s.fail(fmt.Errorf("vingo: %s(): %w", name, err))
+		return nil, false
+	}
+	return v, true
+}
+
+// setExpr: the set tag, `set name = x`; writes nothing.
+type setExpr struct {
+	name string
+	x    Expr
+}
+
+func (e *setExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	v, _ := e.x.eval(s, sc)
+	target := s.globals
+	if target == nil {
+		// partials rendered by helpers have no render-wide scope
+		target = sc
+	}
+	target.set(e.name, v)
+	return "", true
+}
+
+// switchExpr: `switch x case a: v case b, c: w default: d`.
+type switchExpr struct {
+	x     Expr
+	cases []switchExprCase
+	def   Expr // nil: undefined
+}
+
+type switchExprCase struct {
+	conds []*SwitchCase
+	val   Expr
+}
+
+func (e *switchExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	v, _ := e.x.eval(s, sc)
+	sc = sc.child(map[string]interface{}{switchValue: v})
+	for _, c := range e.cases {
+		for _, cond := range c.conds {
+			if cond.matches(s, v, sc) {
+				return c.val.eval(s, sc)
+			}
+		}
+	}
+	if e.def == nil {
+		return nil, false
+	}
+	return e.def.eval(s, sc)
+}
+
+// buildExpr: `build "tag"`, folded to a literal when compiled.
+type buildExpr struct {
+	tag string
+}
+
+func (e *buildExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	return slices.Contains(s.engine.BuildTags, e.tag), true
+}
+
+// filterExpr: x | name:arg..., the call name(x, arg...). Undefined values
+// are passed on without calling the filter, so a default still applies.
+type filterExpr struct {
+	x    Expr
+	call *callExpr
+}
+
+func (e *filterExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	v, ok := e.x.eval(s, sc)
+	if !ok && e.call.name != "default" {
+		return nil, false
+	}
+	return e.call.call(s, sc, []interface{}{v})
+}
+
+// binaryExpr: comparison or logical and/or.
+type binaryExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e *binaryExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	s.op()
+	switch e.op {
+	case "and":
+		return evalTruthy(s, e.left, sc) && evalTruthy(s, e.right, sc), true
+	case "or":
+		return evalTruthy(s, e.left, sc) || evalTruthy(s, e.right, sc), true
+	case "+":
+		l, _ := e.left.eval(s, sc)
+		r, _ := e.right.eval(s, sc)
+		return addValues(l, r), true
+	case "matches":
+		l, _ := e.left.eval(s, sc)
+		r, _ := e.right.eval(s, sc)
+		ok, err := matchValue(l, r)
+		if err != nil {
+			s.fail(err)
+		}
+		return ok, true
+	}
+	var l, r interface{}
+	if ordering(e.op) {
+		l, _ = e.left.eval(s, sc)
+		r, _ = e.right.eval(s, sc)
+	} else {
+		l, r = operand(s, e.left, sc), operand(s, e.right, sc)
+	}
+	ok, err := compareValues(l, r, e.op)
+	if err != nil {
+		s.fail(errorAt(nodeWhere(s.node), err))
+	}
+	return ok, true
+}
+
+// foldable: whether e, with literal operands, can be evaluated at compile
+// time; a comparison that fails is left for the render to report.
+func (e *binaryExpr) foldable() bool {
+	switch e.op {
+	case "and", "or", "+":
+		return true
+	case "matches":
+		_, err := matchValue(e.left.(*litExpr).val, e.right.(*litExpr).val)
+		return err == nil
+	}
+	_, err := compareValues(e.left.(*litExpr).val, e.right.(*litExpr).val, e.op)
+	return err == nil
+}
+
+type notExpr struct {
+	x Expr
+}
+
+func (e *notExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	return !evalTruthy(s, e.x, sc), true
+}
+
+// evalTruthy: condition value of x; undefined is false.
+func evalTruthy(s *renderState, x Expr, sc *scope) bool {
+	v, ok := x.eval(s, sc)
+	return ok && s.truthy(v)
+}
+
+// ordering: op is one of the ordering comparisons >, <, >=, <=.
+func ordering(op string) bool {
+	switch op {
+	case ">", "<", ">=", "<=":
+		return true
+	}
+	return false
+}
+
+// operand: value of one side of an == or != comparison, see the bare name
+// rule above.
+func operand(s *renderState, x Expr, sc *scope) interface{} {
+	v, ok := x.eval(s, sc)
+	if !ok {
+		if p, isPath := x.(*pathExpr); isPath {
+			return literalFromString(p.path)
+		}
+	}
+	return v
+}
+
+// -------------------- Expression lexer --------------------
+
+type exprTokKind int
+
+const (
+	etEOF exprTokKind = iota
+	etIdent
+	etNumber
+	etString
+	etDuration
+	etPunct
+)
+
+type exprTok struct {
+	kind exprTokKind
+	val  string // ident / punct text, raw number, unquoted string
+	pos  int    // byte offset in the expression source
+}
+
+// lexExpr: splits an expression into tokens; the last token is always etEOF.
+func lexExpr(src string) ([]exprTok, error) {
+	var toks []exprTok
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentChar(src[i]) || (src[i] == '.' && i+1 < len(src) && isIdentChar(src[i+1]))) {
+				i++
+			}
+			toks = append(toks, exprTok{kind: etIdent, val: src[start:i], pos: start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9') {
+				i++
+			}
+			if i < len(src) && isIdentStart(src[i]) {
+				// duration: 5m, 1h30m, 1.5s
+				for i < len(src) && (isIdentChar(src[i]) || src[i] == '.') {
+					i++
+				}
+				if _, err := time.ParseDuration(src[start:i]); err != nil {
+					return nil, fmt.Errorf("invalid duration %q at offset %d", src[start:i], start)
+				}
+				toks = append(toks, exprTok{kind: etDuration, val: src[start:i], pos: start})
+				continue
+			}
+			toks = append(toks, exprTok{kind: etNumber, val: src[start:i], pos: start})
+		case c == '"' || c == '\'':
+			str, n, err := scanQuoted(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at offset %d", err, i)
+			}
+			toks = append(toks, exprTok{kind: etString, val: str, pos: i})
+			i += n
+		default:
+			op := string(c)
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "==", "!=", ">=", "<=":
+					op = two
+				}
+			}
+			if !strings.Contains("()[]{},=|:<>!+-*/%", op[:1]) {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			toks = append(toks, exprTok{kind: etPunct, val: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(toks, exprTok{kind: etEOF, pos: len(src)}), nil
+}
+
+// scanQuoted: reads a quoted string at the start of s, returning the
+// unquoted value and the number of bytes consumed.
+func scanQuoted(s string) (string, int, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			raw := s[:i+1]
+			if q == '"' {
+				unq, err := strconv.Unquote(raw)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid string %s", raw)
+				}
+				return unq, i + 1, nil
+			}
+			return strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(raw[1:i]), i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// -------------------- Expression parser --------------------
+
+type exprParser struct {
+	toks  []exprTok
+	pos   int
+	depth int // open parentheses and brackets
+}
+
+// parseExpr: parses a complete expression.
+func parseExpr(src string) (Expr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != etEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.val, t.pos)
+	}
+	return e, nil
+}
+
+// parseOutputTag: parses the body of an output tag, `expr` or `expr | "default"`.
+// src is the expression source without the default part.
+func parseOutputTag(tag string) (expr Expr, src string, def string, err error) {
+	toks, err := lexExpr(tag)
+	if err != nil {
+		return nil, "", "", err
+	}
+	p := &exprParser{toks: toks}
+	if expr, err = p.parseExpr(); err != nil {
+		return nil, "", "", err
+	}
+	end := p.peek().pos
+	if p.accept("|") {
+		t := p.next()
+		if t.kind != etString {
+			return nil, "", "", fmt.Errorf("expected default string after '|' at offset %d", t.pos)
+		}
+		def = t.val
+	}
+	if t := p.peek(); t.kind != etEOF {
+		return nil, "", "", fmt.Errorf("unexpected %q at offset %d", t.val, t.pos)
+	}
+	return expr, strings.TrimSpace(tag[:end]), def, nil
+}
+
+// parseTagArgs: arguments of a tag like `cache "key" vary=[a, b] per="5m"`,
+// whitespace separated expressions followed by name=value pairs.
+func parseTagArgs(src string) ([]Expr, []kwarg, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &exprParser{toks: toks}
+	var args []Expr
+	var kwargs []kwarg
+	for p.peek().kind != etEOF {
+		if t := p.peek(); t.kind == etIdent && p.toks[p.pos+1].kind == etPunct && p.toks[p.pos+1].val == "=" {
+			p.pos += 2
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, nil, err
+			}
+			kwargs = append(kwargs, kwarg{name: t.val, val: v})
+			continue
+		}
+		if len(kwargs) > 0 {
+			return nil, nil, fmt.Errorf("positional argument after keyword argument at offset %d", p.peek().pos)
+		}
+		a, err := p.parseExpr()
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, a)
+	}
+	return args, kwargs, nil
+}
+
+func (p *exprParser) peek() exprTok {
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() exprTok {
+	t := p.toks[p.pos]
+	if t.kind != etEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept: consumes the next token if it is the punctuation op.
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == etPunct && t.val == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		if t.kind == etEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at offset %d, got %q", op, t.pos, t.val)
+	}
+	return nil
+}
+
+func (p *exprParser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+// acceptWord: consumes the next token if it is the keyword w.
+func (p *exprParser) acceptWord(w string) bool {
+	if t := p.peek(); t.kind == etIdent && t.val == w {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptWord("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptWord("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (Expr, error) {
+	if p.acceptWord("not") || p.accept("!") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (Expr, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == etPunct {
+		switch t.val {
+		case "==", "!=", ">", "<", ">=", "<=":
+			p.next()
+			right, err := p.parseAdd()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryExpr{op: t.val, left: left, right: right}, nil
+		}
+	}
+	if p.acceptWord("matches") {
+		right, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryExpr{op: "matches", left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAdd() (Expr, error) {
+	left, err := p.parseFilters()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("+") {
+		right, err := p.parseFilters()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: "+", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFilters: operand followed by `| name` or `| name:arg:arg` filters.
+// Outside of parentheses and lists, `| name:arg,arg` works too. A "|"
+// followed by a string is left alone, it is the default of an output tag.
+func (p *exprParser) parseFilters() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == etPunct && p.peek().val == "|" && p.toks[p.pos+1].kind == etIdent {
+		p.next()
+		name := p.next()
+		if strings.Contains(name.val, ".") {
+			return nil, fmt.Errorf("invalid filter name %q at offset %d", name.val, name.pos)
+		}
+		call := &callExpr{name: name.val}
+		for p.accept(":") || len(call.args) > 0 && p.depth == 0 && p.accept(",") {
+			a, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, a)
+		}
+		x = &filterExpr{x: x, call: call}
+	}
+	return x, nil
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	if t := p.peek(); t.kind == etPunct && t.val == "-" {
+		p.next()
+		n := p.next()
+		if n.kind != etNumber {
+			return nil, fmt.Errorf("expected number after '-' at offset %d", t.pos)
+		}
+		return &litExpr{val: literalFromString("-" + n.val)}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case etString:
+		return &litExpr{val: t.val}, nil
+	case etNumber:
+		return &litExpr{val: literalFromString(t.val)}, nil
+	case etDuration:
+		d, _ := time.ParseDuration(t.val) // checked by the lexer
+		return &litExpr{val: d}, nil
+	case etIdent:
+		switch t.val {
+		case "true":
+			return &litExpr{val: true}, nil
+		case "false":
+			return &litExpr{val: false}, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(t)
+		}
+		if t.val == "build" && p.peek().kind == etString {
+			return &buildExpr{tag: p.next().val}, nil
+		}
+		if next := p.peek(); t.val == "switch" && (next.kind != etEOF && next.kind != etPunct || next.val == "[" || next.val == "!") {
+			return p.parseSwitch()
+		}
+		return newPathExpr(t.val), nil
+	case etPunct:
+		switch t.val {
+		case "(":
+			p.depth++
+			defer func() { p.depth-- }()
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		case "[":
+			p.depth++
+			defer func() { p.depth-- }()
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listExpr{items: items}, nil
+		case "{":
+			p.depth++
+			defer func() { p.depth-- }()
+			return p.parseMap()
+		}
+	case etEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.val, t.pos)
+}
+
+// parseSwitch: the rest of a switch expression, after "switch".
+func (p *exprParser) parseSwitch() (Expr, error) {
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	sw := &switchExpr{x: x}
+	for p.acceptWord("case") {
+		var c switchExprCase
+		// case values end at ":", which must not start filter arguments
+		p.depth++
+		for {
+			cond, err := p.parseCase()
+			if err != nil {
+				p.depth--
+				return nil, err
+			}
+			c.conds = append(c.conds, &SwitchCase{cond: cond})
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.depth--
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if c.val, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		sw.cases = append(sw.cases, c)
+	}
+	if len(sw.cases) == 0 {
+		return nil, fmt.Errorf("switch expression without case at offset %d", p.peek().pos)
+	}
+	if p.acceptWord("default") {
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if sw.def, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return sw, nil
+}
+
+// parseCase: a case value of a switch expression; `> 100` is short for
+// `__switch__ > 100`.
+func (p *exprParser) parseCase() (Expr, error) {
+	if t := p.peek(); t.kind == etPunct {
+		switch t.val {
+		case "==", "!=", ">", "<", ">=", "<=":
+			p.next()
+			right, err := p.parseAdd()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryExpr{op: t.val, left: newPathExpr(switchValue), right: right}, nil
+		}
+	}
+	return p.parseExpr()
+}
+
+// parseSet: the body of a set tag, `name = expr`.
+func parseSet(src string) (*setExpr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) < 3 || toks[0].kind != etIdent || strings.Contains(toks[0].val, ".") || toks[1].kind != etPunct || toks[1].val != "=" {
+		return nil, fmt.Errorf("expected `set name = value`")
+	}
+	p := &exprParser{toks: toks, pos: 2}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != etEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.val, t.pos)
+	}
+	return &setExpr{name: toks[0].val, x: x}, nil
+}
+
+// parseMap: the entries of a map literal up to "}"; keys are strings or
+// bare names.
+func (p *exprParser) parseMap() (Expr, error) {
+	m := &mapExpr{}
+	for !p.accept("}") {
+		if len(m.keys) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if p.accept("}") { // trailing comma
+				break
+			}
+		}
+		k := p.next()
+		if k.kind != etString && (k.kind != etIdent || strings.Contains(k.val, ".")) {
+			return nil, fmt.Errorf("expected a map key at offset %d", k.pos)
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, k.val)
+		m.vals = append(m.vals, v)
+	}
+	return m, nil
+}
+
+// parseList: comma separated expressions up to the closing punctuation.
+func (p *exprParser) parseList(closing string) ([]Expr, error) {
+	var items []Expr
+	for !p.accept(closing) {
+		if len(items) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if p.accept(closing) { // trailing comma
+				break
+			}
+		}
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, nil
+}
+
+// parseCall: arguments of name( ... ); keyword arguments (k=v) follow positional ones.
+func (p *exprParser) parseCall(name exprTok) (Expr, error) {
+	if strings.Contains(name.val, ".") {
+		return nil, fmt.Errorf("invalid function name %q at offset %d", name.val, name.pos)
+	}
+	call := &callExpr{name: name.val}
+	p.depth++
+	defer func() { p.depth-- }()
+	for !p.accept(")") {
+		if len(call.args)+len(call.kwargs) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if t := p.peek(); t.kind == etIdent && p.toks[p.pos+1].kind == etPunct && p.toks[p.pos+1].val == "=" {
+			p.pos += 2
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.kwargs = append(call.kwargs, kwarg{name: t.val, val: v})
+			continue
+		}
+		if len(call.kwargs) > 0 {
+			return nil, fmt.Errorf("positional argument after keyword argument at offset %d", p.peek().pos)
+		}
+		a, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, a)
+	}
+	return call, nil
+}
@@ -0,0 +1,97 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// -------------------- Flash messages --------------------
+//
+// One-time notifications of the post/redirect/get pattern ("Saved.") are
+// read from Engine.Flashes, usually backed by the session of the request:
+//
+//	<{ flash() }>         every pending message
+//	<{ flash("error") }>  only messages of that kind
+//
+// Messages are consumed from the provider once per render, with the render
+// context (RenderContext(r.Context(), ...) gives the provider the request).
+// Every message is rendered at most once per render, so a layout can show
+// the errors at the top and the rest further down.
+
+// Flash: one flash message.
+type Flash struct {
+	Kind    string // "success", "error", ...; used as CSS class suffix
+	Message string
+}
+
+// FlashProvider: source of flash messages.
+type FlashProvider interface {
+	// Flashes returns the pending messages of the session ctx belongs to
+	// and removes them from it.
+	Flashes(ctx context.Context) ([]Flash, error)
+}
+
+// FlashFunc: adapter to use a function as FlashProvider.
+type FlashFunc func(ctx context.Context) ([]Flash, error)
+
+func (f FlashFunc) Flashes(ctx context.Context) ([]Flash, error) {
+	return f(ctx)
+}
+
+func init() {
+	builtinFuncs["flash"] = flashFunc
+}
+
+// flashPartial: the default flash markup; rendered with the variable
+// flashes (the messages).
+const flashPartial = `<div class="flashes"><{ for f in flashes }>` +
+	`<div class="flash flash-<{ f.Kind | escape }>" role="alert"><{ f.Message | escape }></div>` +
+	`<{ /for }></div>`
+
+var flashNodes = sync.OnceValue(func() []Node {
+	tokens, err := tokenize(flashPartial)
+	if err != nil {
+		panic(err)
+	}
+	nodes, err := compileTokens(tokens)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+})
+
+func flashFunc(c *Call) (interface{}, error) {
+	msgs, err := c.s.takeFlashes(argString(c.Arg(0)))
+	if err != nil || len(msgs) == 0 {
+		return "", err
+	}
+	out := &bytes.Buffer{}
+	evalNodes(c.s, flashNodes(), &scope{vars: map[string]interface{}{"flashes": msgs}}, out)
+	return out.String(), nil
+}
+
+// takeFlashes: the messages of kind ("" = all) not rendered yet in this
+// render; the provider is asked on the first call.
+func (s *renderState) takeFlashes(kind string) ([]Flash, error) {
+	if !s.flashesRead {
+		s.flashesRead = true
+		if p := s.engine.Flashes; p != nil {
+			msgs, err := p.Flashes(s.ctx)
+			if err != nil {
+				return nil, err
+			}
+			s.flashes = msgs
+		}
+	}
+	var taken, rest []Flash
+	for _, f := range s.flashes {
+		if kind == "" || f.Kind == kind {
+			taken = append(taken, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	s.flashes = rest
+	return taken, nil
+}
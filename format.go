@@ -0,0 +1,258 @@
+package vingo
+
+import (
+	"strings"
+)
+
+// -------------------- Formatter --------------------
+//
+// Format rewrites a template in the canonical style:
+//   - one space inside the delimiters and between the words of a tag:
+//     <{if  x}> -> <{ if x }>
+//   - double quoted strings in tags: 'a' -> "a" (unless the string holds
+//     quotes or escapes)
+//   - one space around filter pipes: <{ name|upper }> -> <{ name | upper }>
+//   - the lines of a block body are indented one level (two spaces, or a
+//     tab in tab indented templates) deeper than the line opening the
+//     block, keeping their indentation relative to each other
+//   - else / elseif / case / default and closing tags starting their line
+//     are indented like the line that opened their block
+//
+// Apart from indentation, text outside tags is never changed, so the
+// rendered output only differs in leading whitespace.
+
+// Format: src in the canonical style. Templates that don't tokenize are
+// returned with a *SyntaxError.
+func Format(src []byte) ([]byte, error) {
+	if _, err := tokenize(string(src)); err != nil {
+		return nil, err
+	}
+	out := indentBlocks(formatTags(string(src)))
+	return []byte(out), nil
+}
+
+// tagSpan: a tag of a template source; body is src[start+2 : end-2].
+type tagSpan struct {
+	start, end int
+}
+
+// tagSpans: the tags of src, skipping escaped delimiters.
+func tagSpans(src string) []tagSpan {
+	var spans []tagSpan
+	i := 0
+	for {
+		j := strings.Index(src[i:], "<{")
+		if j < 0 {
+			return spans
+		}
+		j += i
+		if j > 0 && src[j-1] == '\\' {
+			i = j + 2
+			continue
+		}
+		end := scanTagEnd(src, j+2)
+		if end < 0 {
+			return spans
+		}
+		spans = append(spans, tagSpan{start: j, end: end + 2})
+		i = end + 2
+	}
+}
+
+// formatTags: src with every tag normalized.
+func formatTags(src string) string {
+	var b strings.Builder
+	last := 0
+	for _, sp := range tagSpans(src) {
+		b.WriteString(src[last:sp.start])
+		b.WriteString("<{ ")
+		if body := normalizeTag(src[sp.start+2 : sp.end-2]); body != "" {
+			b.WriteString(body)
+			b.WriteByte(' ')
+		}
+		b.WriteString("}>")
+		last = sp.end
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// normalizeTag: body with whitespace outside strings collapsed to single
+// spaces and single quoted strings turned into double quoted ones.
+func normalizeTag(body string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			space = true
+			continue
+		}
+		if c == '|' {
+			// one space on both sides of a filter pipe
+			space = true
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = c == '|'
+		if c != '"' && c != '\'' {
+			b.WriteByte(c)
+			continue
+		}
+		k := i + 1
+		for k < len(body) && body[k] != c {
+			if body[k] == '\\' {
+				k++
+			}
+			k++
+		}
+		if k >= len(body) {
+			// unbalanced quote: kept as it is, like the tokenizer does
+			b.WriteString(body[i:])
+			break
+		}
+		if s := body[i+1 : k]; c == '\'' && !strings.ContainsAny(s, `"\`) {
+			b.WriteString(`"` + s + `"`)
+		} else {
+			// escapes would change meaning; left single quoted
+			b.WriteString(body[i : k+1])
+		}
+		i = k
+	}
+	return b.String()
+}
+
+// indentBlocks: indents the lines of block bodies one unit (see
+// indentUnit) deeper than the line opening the block, keeping the relative
+// indentation of the lines of a body (of each branch of an if or switch
+// on its own); middle and closing tags starting their line are indented
+// like the opening line. Lines continuing a multi-line tag and blank lines
+// are left as they are.
+func indentBlocks(src string) string {
+	type block struct {
+		line int // line of the opening tag
+		base int // shortest indentation of the body lines, -1 = no body line
+	}
+	type line struct {
+		start, text int  // offsets of the line and of its first non-blank byte
+		owner       int  // block whose body holds the line, -1 = none
+		align       bool // starts with a middle or closing tag of owner
+		keep        bool // blank, or continues a multi-line tag
+	}
+	var (
+		blocks []block
+		lines  []line
+		open   []int // indexes in blocks of the open blocks
+	)
+	// lineOf: index of the line holding off, a line already read
+	lineOf := func(off int) int {
+		i := len(lines) - 1
+		for i > 0 && lines[i].start > off {
+			i--
+		}
+		return i
+	}
+	spans := tagSpans(src)
+	si := 0
+	for start := 0; start <= len(src); {
+		ln := line{start: start, owner: -1}
+		for ; si < len(spans) && spans[si].start < start; si++ {
+			sp := spans[si]
+			if sp.end > start {
+				ln.keep = true
+			}
+			switch tagType(src, sp) {
+			case TIf, TFor, TSwitch, TBlock, TCache, TCSV, TSection, TComponent, TSlot, TOnce:
+				open = append(open, len(blocks))
+				blocks = append(blocks, block{line: lineOf(sp.start), base: -1})
+			case TElseIf, TElse, TCase, TDefault:
+				// each branch body is indented on its own
+				if len(open) > 0 {
+					top := &open[len(open)-1]
+					blocks = append(blocks, block{line: blocks[*top].line, base: -1})
+					*top = len(blocks) - 1
+				}
+			case TEndIf, TEndFor, TEndSwitch, TEndBlock, TEndCache, TEndCSV, TEndSection, TEndComponent, TEndSlot, TEndOnce:
+				if len(open) > 0 {
+					open = open[:len(open)-1]
+				}
+			}
+		}
+		end := strings.IndexByte(src[start:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += start
+		}
+		ln.text = start + len(src[start:end]) - len(strings.TrimLeft(src[start:end], " \t"))
+		if ln.text == end || strings.TrimSpace(src[ln.text:end]) == "" {
+			ln.keep = true
+		}
+		if len(open) > 0 {
+			ln.owner = open[len(open)-1]
+		}
+		if si < len(spans) && spans[si].start == ln.text {
+			switch tagType(src, spans[si]) {
+			case TElseIf, TElse, TCase, TDefault, TEndIf, TEndFor, TEndSwitch, TEndBlock, TEndCache, TEndCSV, TEndSection, TEndComponent, TEndSlot, TEndOnce:
+				ln.align = true
+			}
+		}
+		if !ln.keep && !ln.align && ln.owner >= 0 {
+			if b := &blocks[ln.owner]; b.base < 0 || ln.text-start < b.base {
+				b.base = ln.text - start
+			}
+		}
+		lines = append(lines, ln)
+		start = end + 1
+	}
+
+	unit := indentUnit(src)
+	indents := make([]string, len(lines))
+	var b strings.Builder
+	for i, ln := range lines {
+		indents[i] = src[ln.start:ln.text]
+		if !ln.keep && ln.owner >= 0 {
+			bl := blocks[ln.owner]
+			if ln.align {
+				indents[i] = indents[bl.line]
+			} else {
+				indents[i] = indents[bl.line] + unit + indents[i][bl.base:]
+			}
+		}
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(indents[i])
+		end := strings.IndexByte(src[ln.text:], '\n')
+		if end < 0 {
+			b.WriteString(src[ln.text:])
+		} else {
+			b.WriteString(src[ln.text : ln.text+end])
+		}
+	}
+	return b.String()
+}
+
+// tagType: type of the tag sp of src.
+func tagType(src string, sp tagSpan) TokenType {
+	return classifyTag(strings.TrimSpace(src[sp.start+2 : sp.end-2])).Type
+}
+
+// indentUnit: one level of indentation: a tab if the first indented line
+// of src starts with one, two spaces otherwise.
+func indentUnit(src string) string {
+	for _, l := range strings.Split(src, "\n") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		switch l[0] {
+		case '\t':
+			return "\t"
+		case ' ':
+			return "  "
+		}
+	}
+	return "  "
+}
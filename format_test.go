@@ -0,0 +1,46 @@
+package vingo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFormatGolden: Format of every testdata/fmt/*.vgo matches the .golden
+// file next to it, and formatting that again changes nothing.
+func TestFormatGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "fmt", "*.vgo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata/fmt/*.vgo files")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(file, ".vgo") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Format(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Format(%s) =\n%s\nwant\n%s", file, got, want)
+			}
+			again, err := Format(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("Format is not idempotent on %s:\n%s", file, again)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package vingo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -------------------- Bot protection of forms --------------------
+//
+// <{ honeypot() }> inside a public form emits two hidden fields: a decoy
+// text field humans never see (bots fill in every field) and a signed
+// timestamp (bots submit faster than humans type). FormGuard.Middleware
+// rejects submissions with a filled decoy, a missing or forged timestamp,
+// or one outside MinAge..MaxAge.
+//
+//	guard := &vingo.FormGuard{Secret: key}
+//	engine.FormGuard = guard
+//	http.Handle("/contact", guard.Middleware(contactHandler))
+//
+//	<form method="post"><{ honeypot() }> ... </form>
+
+// FormGuard: honeypot and time-trap settings, shared by the honeypot()
+// helper (through Engine.FormGuard) and the validating Middleware.
+type FormGuard struct {
+	Secret []byte // HMAC key of the timestamps; required
+
+	Field  string        // name of the decoy field ("" = "website")
+	MinAge time.Duration // minimum form age at submission (0 = 2s)
+	MaxAge time.Duration // maximum form age (0 = 24h)
+
+	// Reject answers rejected submissions (nil = 400 Bad Request).
+	Reject http.Handler
+}
+
+// formGuardToken: form field of the signed timestamp.
+const formGuardToken = "_vingo_t"
+
+func init() {
+	builtinFuncs["honeypot"] = func(c *Call) (interface{}, error) {
+		g := c.Engine().FormGuard
+		if g == nil {
+			return nil, errors.New("Engine.FormGuard is not set")
+		}
+		return g.fields(time.Now())
+	}
+}
+
+// fields: the hidden inputs emitted by honeypot().
+func (g *FormGuard) fields(now time.Time) (string, error) {
+	if len(g.Secret) == 0 {
+		return "", errors.New("FormGuard.Secret is empty")
+	}
+	return `<div style="position:absolute;left:-10000px" aria-hidden="true">` +
+		`<input type="text" name="` + html.EscapeString(g.field()) + `" value="" tabindex="-1" autocomplete="off"></div>` +
+		`<input type="hidden" name="` + formGuardToken + `" value="` + g.token(now) + `">`, nil
+}
+
+// Middleware: rejects POST, PUT and PATCH submissions that fail Check;
+// other requests pass through.
+func (g *FormGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
+			if err := g.Check(r); err != nil {
+				if g.Reject != nil {
+					g.Reject.ServeHTTP(w, r)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Check: reports why the form submitted with r looks automated, nil if it
+// passes the honeypot and the time trap.
+func (g *FormGuard) Check(r *http.Request) error {
+	if r.PostFormValue(g.field()) != "" {
+		return errors.New("vingo: honeypot field filled in")
+	}
+	return g.checkToken(r.PostFormValue(formGuardToken), time.Now())
+}
+
+// token: "<unix seconds>.<hmac>" for now.
+func (g *FormGuard) token(now time.Time) string {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	return ts + "." + g.sign(ts)
+}
+
+func (g *FormGuard) checkToken(tok string, now time.Time) error {
+	ts, sig, ok := strings.Cut(tok, ".")
+	if !ok || len(g.Secret) == 0 || !hmac.Equal([]byte(sig), []byte(g.sign(ts))) {
+		return errors.New("vingo: missing or invalid form token")
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("vingo: missing or invalid form token")
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	minAge, maxAge := g.MinAge, g.MaxAge
+	if minAge == 0 {
+		minAge = 2 * time.Second
+	}
+	if maxAge == 0 {
+		maxAge = 24 * time.Hour
+	}
+	if age < minAge || age > maxAge {
+		return fmt.Errorf("vingo: form submitted %v after rendering", age.Round(time.Second))
+	}
+	return nil
+}
+
+func (g *FormGuard) sign(ts string) string {
+	mac := hmac.New(sha256.New, g.Secret)
+	mac.Write([]byte(ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *FormGuard) field() string {
+	if g.Field != "" {
+		return g.Field
+	}
+	return "website"
+}
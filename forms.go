@@ -0,0 +1,425 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// -------------------- Form errors --------------------
+//
+// Validation errors are passed to templates as the "errors" variable: a map
+// from field name to its messages. Values may be strings, errors or lists
+// of them, so map[string][]string, map[string]string, url.Values and
+// map[string]error all work.
+//
+//	<input name="email"<{ if has_error("email") }> aria-invalid="true"<{ /if }>>
+//	<{ for msg in errors_for("email") }><p class="error"><{ msg | escape }></p><{ /for }>
+//	<{ error_list("email") }>
+//
+// error_list renders the default error-list partial for a field, or for
+// every field without an argument; it renders nothing when there are no
+// errors.
+//
+// Values of a failed submission are passed back as the "old" variable, a
+// map from field name to the submitted value (url.Values works as is, the
+// first value of a field is used), so forms re-render with the user's input:
+//
+//	<input name="email" value="<{ old("email", user.Email) | escape }>">
+//
+// old returns the default (nil without one) for fields that were not
+// submitted.
+
+func init() {
+	builtinFuncs["errors_for"] = func(c *Call) (interface{}, error) {
+		return formErrors(c.Data(), argString(c.Arg(0))), nil
+	}
+	builtinFuncs["has_error"] = func(c *Call) (interface{}, error) {
+		return len(formErrors(c.Data(), argString(c.Arg(0)))) > 0, nil
+	}
+	builtinFuncs["error_list"] = errorListFunc
+	builtinFuncs["old"] = func(c *Call) (interface{}, error) {
+		return fieldValue(c, argString(c.Arg(0)), c.Arg(1)), nil
+	}
+}
+
+// formErrorsKey: data variable holding the errors map.
+const formErrorsKey = "errors"
+
+// formOldKey: data variable holding the previously submitted values.
+const formOldKey = "old"
+
+// errorListPartial: the default error list; rendered with the variables
+// errors (the messages) and field ("" for all fields).
+const errorListPartial = `<ul class="errors"<{ if field }> data-field="<{ field | escape }>"<{ /if }>>` +
+	`<{ for msg in errors }><li><{ msg | escape }></li><{ /for }></ul>`
+
+var errorListNodes = sync.OnceValue(func() []Node {
+	tokens, err := tokenize(errorListPartial)
+	if err != nil {
+		panic(err)
+	}
+	nodes, err := compileTokens(tokens)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+})
+
+func errorListFunc(c *Call) (interface{}, error) {
+	field := argString(c.Arg(0))
+	msgs := formErrors(c.Data(), field)
+	if len(msgs) == 0 {
+		return "", nil
+	}
+	out := &bytes.Buffer{}
+	vars := map[string]interface{}{"errors": msgs, "field": field}
+	evalNodes(c.s, errorListNodes(), &scope{vars: vars}, out)
+	return out.String(), nil
+}
+
+// formErrors: messages for field in the errors map of data; every message,
+// ordered by field, when field is "".
+func formErrors(data map[string]interface{}, field string) []string {
+	if field != "" {
+		v, _ := formField(data, formErrorsKey, field)
+		return errorMessages(v)
+	}
+	rv := formMap(data, formErrorsKey)
+	if !rv.IsValid() {
+		return nil
+	}
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	var msgs []string
+	for _, k := range keys {
+		msgs = append(msgs, errorMessages(rv.MapIndex(k).Interface())...)
+	}
+	return msgs
+}
+
+// errorMessages: the messages of one errors map value.
+func errorMessages(v interface{}) []string {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if t == "" {
+			return nil
+		}
+		return []string{t}
+	case error:
+		return []string{t.Error()}
+	case []string:
+		return t
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{argString(v)}
+	}
+	var msgs []string
+	for i := 0; i < rv.Len(); i++ {
+		msgs = append(msgs, errorMessages(rv.Index(i).Interface())...)
+	}
+	return msgs
+}
+
+// formMap: the string-keyed map in data variable key, the zero Value if
+// there is none.
+func formMap(data map[string]interface{}, key string) reflect.Value {
+	rv := reflect.ValueOf(data[key])
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}
+	}
+	return rv
+}
+
+// formField: entry field of the map in data variable key.
+func formField(data map[string]interface{}, key, field string) (interface{}, bool) {
+	rv := formMap(data, key)
+	if !rv.IsValid() {
+		return nil, false
+	}
+	v := rv.MapIndex(reflect.ValueOf(field).Convert(rv.Type().Key()))
+	if !v.IsValid() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// -------------------- Form helpers --------------------
+//
+//	<{ input("email", user.Email, type="email", required=true) }>
+//	<{ textarea("bio", user.Bio, rows=5) }>
+//	<{ select("country", countries, user.Country) }>
+//	<{ checkbox("newsletter", user.Newsletter) }>
+//
+// render form fields whose value is repopulated from old (a submitted value
+// wins over the one passed) and which get FormOptions.ErrorClass and
+// aria-invalid="true" when errors has messages for the field. Keyword
+// arguments become attributes, as do the entries of a map passed after the
+// positional arguments (input("q", q, attrs)); everything is escaped.
+//
+// select options are a list of values, a list of [value, label] pairs or
+// of maps with value and label, or a map from value to label (in value
+// order). With multiple=true the selected value may be a list.
+//
+// After a submission (old is set) an unchecked checkbox isn't in old, so
+// checkbox takes its state from old alone.
+
+// FormOptions: configuration of the form helpers.
+type FormOptions struct {
+	ErrorClass string // class added to fields with errors, default "is-invalid"
+}
+
+func init() {
+	builtinFuncs["input"] = inputFunc
+	builtinFuncs["textarea"] = textareaFunc
+	builtinFuncs["select"] = selectFunc
+	builtinFuncs["checkbox"] = checkboxFunc
+}
+
+// fieldAttrs: attributes of a field: keyword arguments, the map in
+// c.Arg(attrsArg) if there is one, and the error class.
+func fieldAttrs(c *Call, name string, attrsArg int) map[string]interface{} {
+	attrs := map[string]interface{}{}
+	if rv := reflect.ValueOf(c.Arg(attrsArg)); rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		iter := rv.MapRange()
+		for iter.Next() {
+			attrs[iter.Key().String()] = iter.Value().Interface()
+		}
+	}
+	for k, v := range c.Kwargs {
+		attrs[k] = v
+	}
+	if len(formErrors(c.Data(), name)) > 0 {
+		class := c.Engine().Forms.ErrorClass
+		if class == "" {
+			class = "is-invalid"
+		}
+		if v := argString(attrs["class"]); v != "" {
+			class = v + " " + class
+		}
+		attrs["class"] = class
+		attrs["aria-invalid"] = "true"
+	}
+	return attrs
+}
+
+// fieldValue: the submitted value of name, def if it wasn't submitted.
+func fieldValue(c *Call, name string, def interface{}) interface{} {
+	v, ok := formField(c.Data(), formOldKey, name)
+	if !ok {
+		return def
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		if rv.Len() == 0 {
+			return def
+		}
+		return rv.Index(0).Interface()
+	}
+	return v
+}
+
+func fieldName(c *Call) (string, error) {
+	name := argString(c.Arg(0))
+	if name == "" {
+		return "", fmt.Errorf("missing field name")
+	}
+	return name, nil
+}
+
+func inputFunc(c *Call) (interface{}, error) {
+	name, err := fieldName(c)
+	if err != nil {
+		return nil, err
+	}
+	attrs := fieldAttrs(c, name, 2)
+	typ := argString(attrs["type"])
+	if typ == "" {
+		typ = "text"
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<input type="%s" name="%s"`, html.EscapeString(typ), html.EscapeString(name))
+	// password fields are never repopulated
+	if typ != "password" {
+		if v := fieldValue(c, name, c.Arg(1)); v != nil {
+			fmt.Fprintf(b, ` value="%s"`, html.EscapeString(argString(v)))
+		}
+	}
+	writeAttrs(b, attrs, "type", "name", "value")
+	b.WriteString(">")
+	return b.String(), nil
+}
+
+func textareaFunc(c *Call) (interface{}, error) {
+	name, err := fieldName(c)
+	if err != nil {
+		return nil, err
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<textarea name="%s"`, html.EscapeString(name))
+	writeAttrs(b, fieldAttrs(c, name, 2), "name")
+	b.WriteString(">")
+	if v := fieldValue(c, name, c.Arg(1)); v != nil {
+		b.WriteString(html.EscapeString(argString(v)))
+	}
+	b.WriteString("</textarea>")
+	return b.String(), nil
+}
+
+func checkboxFunc(c *Call) (interface{}, error) {
+	name, err := fieldName(c)
+	if err != nil {
+		return nil, err
+	}
+	attrs := fieldAttrs(c, name, 2)
+	value := argString(attrs["value"])
+	if value == "" {
+		value = "on"
+	}
+	checked := condTruthy(c.Arg(1))
+	if formMap(c.Data(), formOldKey).IsValid() {
+		checked = false
+		v, _ := formField(c.Data(), formOldKey, name)
+		for _, s := range formValues(v) {
+			checked = checked || s == value
+		}
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<input type="checkbox" name="%s" value="%s"`, html.EscapeString(name), html.EscapeString(value))
+	if checked {
+		b.WriteString(" checked")
+	}
+	writeAttrs(b, attrs, "type", "name", "value", "checked")
+	b.WriteString(">")
+	return b.String(), nil
+}
+
+func selectFunc(c *Call) (interface{}, error) {
+	name, err := fieldName(c)
+	if err != nil {
+		return nil, err
+	}
+	options, err := selectOptions(c.Arg(1))
+	if err != nil {
+		return nil, err
+	}
+	attrs := fieldAttrs(c, name, 3)
+	selected := map[string]bool{}
+	if v, ok := formField(c.Data(), formOldKey, name); ok {
+		for _, s := range formValues(v) {
+			selected[s] = true
+		}
+	} else {
+		for _, s := range formValues(c.Arg(2)) {
+			selected[s] = true
+		}
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<select name="%s"`, html.EscapeString(name))
+	writeAttrs(b, attrs, "name")
+	b.WriteString(">")
+	for _, o := range options {
+		fmt.Fprintf(b, `<option value="%s"`, html.EscapeString(o.value))
+		if selected[o.value] {
+			b.WriteString(" selected")
+		}
+		fmt.Fprintf(b, ">%s</option>", html.EscapeString(o.label))
+	}
+	b.WriteString("</select>")
+	return b.String(), nil
+}
+
+type selectOption struct {
+	value, label string
+}
+
+// selectOptions: the options of select from a list or a map.
+func selectOptions(v interface{}) ([]selectOption, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	var options []selectOption
+	switch rv.Kind() {
+	case reflect.Invalid:
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return argString(keys[i].Interface()) < argString(keys[j].Interface()) })
+		for _, k := range keys {
+			options = append(options, selectOption{argString(k.Interface()), argString(rv.MapIndex(k).Interface())})
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			o, err := selectOptionOf(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, o)
+		}
+	default:
+		return nil, fmt.Errorf("options must be a list or a map, got %T", v)
+	}
+	return options, nil
+}
+
+// selectOptionOf: one option of a list: a value, [value, label] or a map
+// with value and label.
+func selectOptionOf(v interface{}) (selectOption, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		if rv.Len() != 2 {
+			return selectOption{}, fmt.Errorf("option pairs must be [value, label], got %d elements", rv.Len())
+		}
+		return selectOption{argString(rv.Index(0).Interface()), argString(rv.Index(1).Interface())}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		get := func(k string) interface{} {
+			if e := rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())); e.IsValid() {
+				return e.Interface()
+			}
+			return nil
+		}
+		value := argString(get("value"))
+		label := get("label")
+		if label == nil {
+			label = value
+		}
+		return selectOption{value, argString(label)}, nil
+	}
+	s := argString(v)
+	return selectOption{s, s}, nil
+}
+
+// formValues: v as a list of strings; a single value is a list of one.
+func formValues(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		vals := make([]string, rv.Len())
+		for i := range vals {
+			vals[i] = argString(rv.Index(i).Interface())
+		}
+		return vals
+	}
+	return []string{argString(v)}
+}
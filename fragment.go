@@ -0,0 +1,324 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -------------------- Fragment cache --------------------
+//
+//	<{ cache "sidebar:" + user.ID ttl=5m }> ... <{ /cache }>
+//	<{ cache "sidebar" vary=[user.Role, locale] per=5m }> ... <{ /cache }>
+//
+// caches the rendered body under the key. vary adds values (role, locale,
+// A/B bucket...) to the key, so a fragment is shared between all renders
+// with the same values. per=5m puts the current 5 minute time bucket into
+// the key, so the fragment is rebuilt at most once per bucket. ttl (default:
+// per, otherwise no expiry) bounds how long an entry is kept. Durations are
+// duration literals (90s, 1h30m), strings in the same format or numbers of
+// seconds.
+//
+//	<{ cache "top-products" ttl=1m stale=10m }> ... <{ /cache }>
+//
+// stale enables stale-while-revalidate: for stale after the ttl ran out the
+// old fragment is still served immediately while a background goroutine
+// renders a fresh one, so slow partials never block a request once warm.
+// The refresh sees the same data as the render that triggered it; that data
+// must not be modified after Render returns.
+
+// CacheNode: <{ cache }> block.
+type CacheNode struct {
+	Key  string // raw tag arguments
+	Body []Node
+
+	nodePos
+	key   Expr
+	vary  Expr // optional list of values
+	per   Expr // optional time bucket duration
+	ttl   Expr // optional entry lifetime
+	stale Expr // optional stale-while-revalidate window
+}
+
+func (n *CacheNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	args := cacheArgs{
+		key:   optValue(s, n.key, sc),
+		vary:  optValue(s, n.vary, sc),
+		per:   optValue(s, n.per, sc),
+		ttl:   optValue(s, n.ttl, sc),
+		stale: optValue(s, n.stale, sc),
+	}
+	body := func(s *renderState, out *bytes.Buffer) {
+		evalNodes(s, n.Body, sc, out)
+	}
+	detach := func() fragmentBody {
+		sc := sc.snapshot()
+		return func(s *renderState, out *bytes.Buffer) {
+			evalNodes(s, n.Body, sc, out)
+		}
+	}
+	renderFragment(s, out, args, body, detach)
+}
+
+// optValue: value of an optional tag argument, nil if absent or undefined.
+func optValue(s *renderState, x Expr, sc *scope) interface{} {
+	if x == nil {
+		return nil
+	}
+	v, _ := x.eval(s, sc)
+	return v
+}
+
+// cacheArgs: evaluated arguments of a cache tag; nil options are not set.
+type cacheArgs struct {
+	key, vary, per, ttl, stale interface{}
+}
+
+// fragmentBody: renders the body of a cache tag.
+type fragmentBody func(s *renderState, out *bytes.Buffer)
+
+// renderFragment: writes the cached fragment for args, rendering body on a
+// miss. detach returns a body that is safe to run after the current render
+// has finished, for stale-while-revalidate refreshes.
+func renderFragment(s *renderState, out *bytes.Buffer, args cacheArgs, body fragmentBody, detach func() fragmentBody) {
+	key, ttl, stale, err := args.resolve()
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	// stale entries are stored for ttl+stale; one with at most stale left
+	// has outlived its ttl
+	store := s.engine.fragmentStore()
+	if frag, ok, err := store.Get(s.ctx, key); err == nil && ok {
+		if stale > 0 {
+			if left, ok, err := store.TTL(s.ctx, key); err == nil && ok && left > 0 && left <= stale {
+				refreshFragment(s, key, ttl+stale, detach)
+			}
+		}
+		out.WriteString(frag)
+		return
+	}
+	start := out.Len()
+	body(s, out)
+	if s.err == nil {
+		store.Set(s.ctx, key, string(out.Bytes()[start:]), ttl+stale)
+	}
+}
+
+// refreshFragment: re-renders a fragment in the background and replaces the
+// entry under key. At most one refresh per key runs at a time; a failed
+// refresh keeps the stale entry.
+func refreshFragment(s *renderState, key string, ttl time.Duration, detach func() fragmentBody) {
+	e := s.engine
+	if _, busy := e.refreshing.LoadOrStore(key, true); busy {
+		return
+	}
+	bg := &renderState{ctx: context.WithoutCancel(s.ctx), engine: e, data: s.data, locale: s.locale, csv: s.csv}
+	body := detach()
+	go func() {
+		defer e.refreshing.Delete(key)
+		// the render holding the lock is over
+		e.reloadMu.RLock()
+		defer e.reloadMu.RUnlock()
+		out := getBuffer(0)
+		defer putBuffer(out)
+		defer bg.recoverPanic()
+		body(bg, out)
+		if bg.err == nil {
+			e.fragmentStore().Set(bg.ctx, key, out.String(), ttl)
+		}
+	}()
+}
+
+// resolve: full key of the fragment for this render, its ttl and stale window.
+func (a cacheArgs) resolve() (key string, ttl, stale time.Duration, err error) {
+	b := &strings.Builder{}
+	b.WriteString(argString(a.key))
+	if a.vary != nil {
+		for _, it := range toList(a.vary) {
+			b.WriteByte('|')
+			b.WriteString(argString(it))
+		}
+	}
+
+	var per time.Duration
+	if a.per != nil {
+		if per, err = toDuration(a.per); err != nil {
+			return "", 0, 0, fmt.Errorf("vingo: cache per: %w", err)
+		}
+		if per > 0 {
+			b.WriteString("|@")
+			b.WriteString(strconv.FormatInt(time.Now().Truncate(per).Unix(), 10))
+		}
+	}
+	ttl = per
+	if a.ttl != nil {
+		if ttl, err = toDuration(a.ttl); err != nil {
+			return "", 0, 0, fmt.Errorf("vingo: cache ttl: %w", err)
+		}
+	}
+	if a.stale != nil && ttl > 0 {
+		if stale, err = toDuration(a.stale); err != nil {
+			return "", 0, 0, fmt.Errorf("vingo: cache stale: %w", err)
+		}
+	}
+	return b.String(), ttl, stale, nil
+}
+
+// toDuration: "5m" style duration or number of seconds.
+func toDuration(v interface{}) (time.Duration, error) {
+	switch t := v.(type) {
+	case time.Duration:
+		return t, nil
+	case string:
+		return time.ParseDuration(t)
+	}
+	if f, ok := toFloat(v); ok {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	return 0, fmt.Errorf("invalid duration %v", v)
+}
+
+// FragmentStore: storage of cached fragments. The default is an in-memory
+// MemoryStore per engine; multi-instance deployments can plug in a shared
+// store (Redis, Memcached) through Engine.Fragments. Store errors don't
+// fail the render: a failing Get is treated as a miss, a failing Set is
+// ignored.
+type FragmentStore interface {
+	// Get returns the fragment stored under key, ok is false on a miss.
+	Get(ctx context.Context, key string) (value string, ok bool, err error)
+	// Set stores value under key; ttl 0 means no expiry.
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	// TTL reports the remaining lifetime of key (0: no expiry), ok is
+	// false if the key is missing.
+	TTL(ctx context.Context, key string) (ttl time.Duration, ok bool, err error)
+}
+
+// fragmentStore: Engine.Fragments or the engine's own memory store.
+func (e *Engine) fragmentStore() FragmentStore {
+	if e.Fragments != nil {
+		return e.Fragments
+	}
+	return &e.fragments
+}
+
+// MemoryStore: in-process FragmentStore. The zero value is ready to use.
+// Expired fragments are swept as new ones are stored, and the store holds
+// at most MaxEntries fragments, so keys that are never read again (per=
+// time buckets, vary values) don't make it grow without bound.
+type MemoryStore struct {
+	// MaxEntries: fragments kept at most (0 = DefaultMaxFragments); when
+	// the store is full, storing a new key drops an arbitrary one.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[string]fragment
+	writes  int // Sets until the next sweep
+}
+
+// DefaultMaxFragments: MemoryStore.MaxEntries when it is not set.
+const DefaultMaxFragments = 10000
+
+// minSweepInterval: Sets between two sweeps of a small store.
+const minSweepInterval = 64
+
+type fragment struct {
+	out     string
+	expires time.Time // zero: never
+}
+
+// NewMemoryStore: empty in-memory fragment store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// get: live entry for key, dropping it if expired. Caller holds c.mu.
+func (c *MemoryStore) get(key string) (fragment, bool) {
+	f, ok := c.entries[key]
+	if ok && f.expired(time.Now()) {
+		delete(c.entries, key)
+		return fragment{}, false
+	}
+	return f, ok
+}
+
+func (f fragment) expired(now time.Time) bool {
+	return !f.expires.IsZero() && !now.Before(f.expires)
+}
+
+func (c *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.get(key)
+	return f.out, ok, nil
+}
+
+func (c *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]fragment{}
+	}
+	// sweeping after as many Sets as entries were left by the last sweep
+	// keeps Set amortized O(1)
+	if c.writes--; c.writes <= 0 {
+		c.sweep()
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries() {
+		c.evict()
+	}
+	f := fragment{out: value}
+	if ttl > 0 {
+		f.expires = time.Now().Add(ttl)
+	}
+	c.entries[key] = f
+	return nil
+}
+
+func (c *MemoryStore) maxEntries() int {
+	if c.MaxEntries > 0 {
+		return c.MaxEntries
+	}
+	return DefaultMaxFragments
+}
+
+// sweep: drops the expired entries. Caller holds c.mu.
+func (c *MemoryStore) sweep() {
+	now := time.Now()
+	for key, f := range c.entries {
+		if f.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+	c.writes = max(len(c.entries), minSweepInterval)
+}
+
+// evict: drops one entry, whichever the map yields first. Caller holds
+// c.mu.
+func (c *MemoryStore) evict() {
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+func (c *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, ok := c.get(key)
+	if !ok || f.expires.IsZero() {
+		return 0, ok, nil
+	}
+	return time.Until(f.expires), true, nil
+}
+
+// Clear: removes every fragment; Engine.Flush calls it on stores that have it.
+func (c *MemoryStore) Clear() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,70 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryStore()
+	c.Set(ctx, "short", "a", 20*time.Millisecond)
+	c.Set(ctx, "forever", "b", 0)
+
+	if v, ok, _ := c.Get(ctx, "short"); !ok || v != "a" {
+		t.Fatalf("Get(short) = %q, %v; want a hit", v, ok)
+	}
+	if ttl, ok, _ := c.TTL(ctx, "short"); !ok || ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Errorf("TTL(short) = %v, %v", ttl, ok)
+	}
+	if ttl, ok, _ := c.TTL(ctx, "forever"); !ok || ttl != 0 {
+		t.Errorf("TTL(forever) = %v, %v; want 0, true", ttl, ok)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "short"); ok {
+		t.Error("Get(short) after expiry: hit")
+	}
+	if _, ok, _ := c.TTL(ctx, "short"); ok {
+		t.Error("TTL(short) after expiry: found")
+	}
+	if v, ok, _ := c.Get(ctx, "forever"); !ok || v != "b" {
+		t.Errorf("Get(forever) = %q, %v; want a hit", v, ok)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	// keys like the per= buckets of a cache tag: each written once, never
+	// read again
+	ctx := context.Background()
+	c := NewMemoryStore()
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 500; i++ {
+			c.Set(ctx, fmt.Sprintf("page|@%d|%d", round, i), "x", 5*time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(c.entries); n > 1000 {
+		t.Errorf("%d entries after 2500 expiring Sets, want expired ones swept", n)
+	}
+}
+
+func TestMemoryStoreMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	c := &MemoryStore{MaxEntries: 10}
+	for i := 0; i < 100; i++ {
+		c.Set(ctx, fmt.Sprint(i), "x", 0)
+	}
+	if n := len(c.entries); n != 10 {
+		t.Errorf("%d entries, want MaxEntries (10)", n)
+	}
+	if _, ok, _ := c.Get(ctx, "99"); !ok {
+		t.Error("the last fragment stored was dropped")
+	}
+	// overwriting a key of a full store drops nothing
+	c.Set(ctx, "99", "y", 0)
+	if n := len(c.entries); n != 10 {
+		t.Errorf("%d entries after overwriting, want 10", n)
+	}
+}
@@ -0,0 +1,175 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// -------------------- Template functions --------------------
+
+// Func: a function callable from templates, e.g. <{ image("hero.jpg") }>.
+// A returned error aborts the render.
+type Func func(c *Call) (interface{}, error)
+
+// Call: arguments of one function call. Positional arguments are in Args,
+// keyword arguments (name=value) in Kwargs.
+type Call struct {
+	Args   []interface{}
+	Kwargs map[string]interface{}
+
+	s *renderState
+}
+
+// Context: context of the render the call belongs to.
+func (c *Call) Context() context.Context {
+	return c.s.ctx
+}
+
+// Engine: engine running the render.
+func (c *Call) Engine() *Engine {
+	return c.s.engine
+}
+
+// Data: data passed to the render the call belongs to.
+func (c *Call) Data() map[string]interface{} {
+	return c.s.data
+}
+
+// Arg: i'th positional argument, nil if missing.
+func (c *Call) Arg(i int) interface{} {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return nil
+}
+
+// Kwarg: keyword argument name, def if it was not passed.
+func (c *Call) Kwarg(name string, def interface{}) interface{} {
+	if v, ok := c.Kwargs[name]; ok {
+		return v
+	}
+	return def
+}
+
+// builtinFuncs: functions available in every engine; helpers register
+// themselves from init.
+var builtinFuncs = map[string]Func{}
+
+func init() {
+	// the text filters of the first versions, usable as `| upper`
+	for _, name := range []string{"upper", "lower", "escape"} {
+		name := name
+		builtinFuncs[name] = func(c *Call) (interface{}, error) {
+			return applyFilter(name, argString(c.Arg(0))), nil
+		}
+	}
+	// dict("a", 1, "b", 2) / dict(a=1) and list(1, 2) build values like
+	// the {"a": 1} and [1, 2] literals
+	builtinFuncs["dict"] = func(c *Call) (interface{}, error) {
+		if len(c.Args)%2 != 0 {
+			return nil, fmt.Errorf("expected key, value pairs, got %d arguments", len(c.Args))
+		}
+		m := make(map[string]interface{}, len(c.Args)/2+len(c.Kwargs))
+		for i := 0; i < len(c.Args); i += 2 {
+			m[argString(c.Args[i])] = c.Args[i+1]
+		}
+		for k, v := range c.Kwargs {
+			m[k] = v
+		}
+		return m, nil
+	}
+	builtinFuncs["list"] = func(c *Call) (interface{}, error) {
+		return append([]interface{}{}, c.Args...), nil
+	}
+	// x | default:fallback and default(x, fallback): the fallback when x is
+	// undefined or empty; unlike other filters it gets undefined values
+	builtinFuncs["default"] = func(c *Call) (interface{}, error) {
+		if isEmpty(c.Arg(0)) {
+			return c.Arg(1), nil
+		}
+		return c.Arg(0), nil
+	}
+}
+
+// isEmpty: reports whether v is nil, "" or an empty list or map.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if s, ok := v.(string); ok {
+		return s == ""
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// AddFunc: makes fn callable as name(...) in templates rendered by e.
+// Engine functions shadow built-in ones with the same name.
+func (e *Engine) AddFunc(name string, fn Func) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.funcs == nil {
+		e.funcs = map[string]Func{}
+	}
+	e.funcs[name] = fn
+}
+
+// FuncNames: names of the functions callable in templates rendered by e,
+// built-in and added ones, sorted. Every function can also be used as a
+// filter.
+func (e *Engine) FuncNames() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(builtinFuncs)+len(e.funcs))
+	for name := range builtinFuncs {
+		names = append(names, name)
+	}
+	for name := range e.funcs {
+		if _, ok := builtinFuncs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Engine) lookupFunc(name string) Func {
+	e.mu.RLock()
+	fn, ok := e.funcs[name]
+	e.mu.RUnlock()
+	if ok {
+		return fn
+	}
+	return builtinFuncs[name]
+}
+
+// argString: string form of a function argument.
+func argString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Formatter: output text of a value; ok=false leaves v to the default %v
+// formatting.
+type Formatter func(v interface{}) (s string, ok bool)
+
+// SetFormatter: makes f decide how non-string values are written by output
+// tags, e.g. UUIDs, decimals or option types that print badly with %v. nil
+// restores the default.
+func (e *Engine) SetFormatter(f Formatter) {
+	if f == nil {
+		e.formatter.Store(nil)
+		return
+	}
+	e.formatter.Store(&f)
+}
@@ -0,0 +1,915 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// -------------------- Code generation --------------------
+//
+//	vingo generate -pkg views -o views/views_gen.go views/*.vgo
+//
+// compiles templates to Go, one function per file, so a service renders
+// them without reading or parsing template files at runtime:
+//
+//	func ProductList(ctx context.Context, e *vingo.Engine, data map[string]interface{}) (out string, err error)
+//
+// A template declaring its parameters gets them instead of the data map:
+//
+//	<{ import "example.com/shop/models" }>
+//	<{ params user *models.User, products []models.Product }>
+//
+//	func ProductList(ctx context.Context, e *vingo.Engine, user *models.User, products []models.Product) (out string, err error)
+//
+// Paths on the parameters become Go selectors checked by the compiler
+// (user.Address.City; a method without arguments ending a path is called,
+// a nil pointer on the way is a *RenderError) and loops over them range
+// over the Go slice. Using a variable that is neither a parameter, a loop
+// or include variable nor assigned by a set tag is a generation error.
+//
+// Control flow becomes Go code and loop variables Go locals; expressions go
+// through the Runtime API. e supplies functions, output options and the
+// fragment store (nil: the default engine). Templates are named relative
+// to Root in the generated code, files outside it relative to the template
+// being generated, so the output doesn't depend on where it is generated.
+
+// Generate: writes Go source for files to w, in package pkg.
+func (e *Engine) Generate(w io.Writer, pkg string, files ...string) error {
+	g := &generator{e: e, b: &bytes.Buffer{}, imports: map[string]bool{}}
+	names := map[string]string{}
+	for _, file := range files {
+		path := e.resolve(file)
+		tpl, err := e.getOrCompile(path)
+		if err != nil {
+			return err
+		}
+		g.files = []string{path}
+		g.dir = filepath.Dir(path)
+		name := funcName(file)
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("vingo: %s and %s both generate %s", prev, file, name)
+		}
+		names[name] = file
+		if err := g.template(name, tpl); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	std := []string{"context"}
+	if g.usesBytes {
+		std = append(std, "bytes")
+	}
+	if g.usesTime {
+		std = append(std, "time")
+	}
+	other := []string{"github.com/coderiantest/vingo"}
+	for path := range g.imports {
+		if !slices.Contains(std, path) && !slices.Contains(other, path) {
+			other = append(other, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	head := &bytes.Buffer{}
+	fmt.Fprintf(head, "// Code generated by vingo generate. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(head, "import (\n%s\n\n%s\n)\n", quoteAll(std), quoteAll(other))
+	head.Write(g.b.Bytes())
+	src, err := format.Source(head.Bytes())
+	if err != nil {
+		return fmt.Errorf("vingo: formatting generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// quoteAll: paths as Go strings, one per line.
+func quoteAll(paths []string) string {
+	q := make([]string, len(paths))
+	for i, p := range paths {
+		q[i] = strconv.Quote(p)
+	}
+	return strings.Join(q, "\n")
+}
+
+// funcName: Go function name for a template file, "product_list.vgo" -> "ProductList".
+func funcName(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	b := &strings.Builder{}
+	upper := true
+	for _, r := range base {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "T" + name
+	}
+	return name
+}
+
+type generator struct {
+	e         *Engine
+	files     []string // template being generated and the includes it is in
+	dir       string   // directory of the template being generated
+	b         *bytes.Buffer
+	locals    []map[string]string // template variable -> Go variable, innermost last
+	typed     map[string]bool     // Go variables of declared types: parameters and what they are looped or passed as
+	sets      map[string]bool     // variables of set tags, in templates with params
+	strict    bool                // the template has params: other variables are errors
+	imports   map[string]bool     // paths of the import tags of the templates
+	n         int                 // counter for unique Go names
+	at        string              // "file:line:col" of the tag being generated, for errors
+	usesBytes bool
+	usesTime  bool
+}
+
+func (g *generator) template(name string, tpl *Template) error {
+	var params []goParam
+	for _, d := range tpl.decls {
+		params = append(params, d.params...)
+		if d.Kind == "import" {
+			g.imports[d.path] = true
+		}
+	}
+	fmt.Fprintf(g.b, "\n// %s renders %s.\n", name, g.relPath(tpl.Filepath))
+	g.strict, g.typed, g.sets = len(params) > 0, nil, nil
+	if !g.strict {
+		fmt.Fprintf(g.b, "func %s(ctx context.Context, e *vingo.Engine, data map[string]interface{}) (out string, err error) {\n", name)
+		fmt.Fprintf(g.b, "r, w := vingo.NewRuntime(ctx, e, data, %d)\n", tpl.size)
+	} else {
+		vars := map[string]string{}
+		g.typed, g.sets = map[string]bool{}, setNames(tpl.Nodes)
+		sig := make([]string, len(params))
+		entries := make([]string, len(params))
+		for i, p := range params {
+			if _, ok := vars[p.name]; ok {
+				return fmt.Errorf("vingo: parameter %s declared twice", p.name)
+			}
+			vars[p.name], g.typed[p.name] = p.name, true
+			sig[i] = p.name + " " + p.typ
+			entries[i] = strconv.Quote(p.name) + ": " + p.name
+		}
+		fmt.Fprintf(g.b, "func %s(ctx context.Context, e *vingo.Engine, %s) (out string, err error) {\n", name, strings.Join(sig, ", "))
+		fmt.Fprintf(g.b, "data := map[string]interface{}{%s}\n", strings.Join(entries, ", "))
+		fmt.Fprintf(g.b, "r, w := vingo.NewRuntime(ctx, e, data, %d)\n", tpl.size)
+		g.push(vars)
+		defer g.pop()
+	}
+	g.b.WriteString("defer r.Recover(&err)\n")
+	if err := g.nodes(tpl.Nodes); err != nil {
+		return err
+	}
+	g.b.WriteString("return r.Finish(w)\n}\n")
+	return nil
+}
+
+// relPath: name of the template at path in generated code, relative to
+// Root, or to the directory of the template being generated for files
+// outside Root.
+func (g *generator) relPath(path string) string {
+	name := g.e.relName(path)
+	if filepath.IsAbs(name) {
+		if rel, err := filepath.Rel(g.dir, path); err == nil {
+			name = rel
+		}
+	}
+	return filepath.ToSlash(name)
+}
+
+// setNames: the variables assigned by set tags in nodes.
+func setNames(nodes []Node) map[string]bool {
+	names := map[string]bool{}
+	walkNodes(nodes, func(n Node) {
+		if v, ok := n.(*VarNode); ok {
+			if x, err := nodeExpr(v.expr, v.Name); err == nil {
+				if set, ok := x.(*setExpr); ok {
+					names[set.name] = true
+				}
+			}
+		}
+	})
+	return names
+}
+
+// declared: reports whether the first name of p is a variable; in
+// templates without params, every name is.
+func (g *generator) declared(p *pathExpr) bool {
+	if !g.strict {
+		return true
+	}
+	if _, ok := g.local(p.parts[0]); ok {
+		return true
+	}
+	return g.sets[p.parts[0]]
+}
+
+// selector: Go selector of p if it starts with a typed variable (ok false
+// otherwise).
+func (g *generator) selector(p *pathExpr) (sel string, ok bool, err error) {
+	v, ok := g.local(p.parts[0])
+	if !ok || !g.typed[v] {
+		return "", false, nil
+	}
+	for _, part := range p.parts[1:] {
+		if !token.IsIdentifier(part) {
+			return "", true, fmt.Errorf("vingo: %s: %s: %q is not a Go field", g.at, p.path, part)
+		}
+	}
+	return strings.Join(append([]string{v}, p.parts[1:]...), "."), true, nil
+}
+
+// tmp: unique Go name with the given prefix.
+func (g *generator) tmp(prefix string) string {
+	g.n++
+	return prefix + strconv.Itoa(g.n)
+}
+
+// push: opens a Go block scope binding the template variables in vars.
+func (g *generator) push(vars map[string]string) {
+	g.locals = append(g.locals, vars)
+}
+
+func (g *generator) pop() {
+	g.locals = g.locals[:len(g.locals)-1]
+}
+
+func (g *generator) local(name string) (string, bool) {
+	for i := len(g.locals) - 1; i >= 0; i-- {
+		if v, ok := g.locals[i][name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (g *generator) nodes(nodes []Node) error {
+	for _, n := range nodes {
+		if err := g.node(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) node(n Node) error {
+	if p, ok := n.(positioned); ok {
+		pos := p.position()
+		g.at = formatWhere(g.relPath(pos.template), pos.line, pos.col)
+	}
+	switch n := n.(type) {
+	case *TextNode:
+		if n.Text != "" {
+			fmt.Fprintf(g.b, "w.WriteString(%s)\n", strconv.Quote(n.Text))
+		}
+	case *VarNode:
+		x, err := nodeExpr(n.expr, n.Name)
+		if err != nil {
+			return err
+		}
+		if set, ok := x.(*setExpr); ok {
+			v, err := g.expr(set.x)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(g.b, "data = r.Set(data, %s, %s)\n", strconv.Quote(set.name), v)
+			return nil
+		}
+		v, err := g.expr(x)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(g.b, "r.Write(w, %s, %s)\n", v, strconv.Quote(n.Default))
+	case *IfNode:
+		for i, br := range n.Branches {
+			x, err := nodeExpr(br.cond, br.Expr)
+			if err != nil {
+				return err
+			}
+			c, err := g.expr(x)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				g.b.WriteString("} else ")
+			}
+			fmt.Fprintf(g.b, "if r.Truthy(%s) {\n", c)
+			if err := g.nodes(br.Body); err != nil {
+				return err
+			}
+		}
+		if len(n.Else) > 0 {
+			g.b.WriteString("} else {\n")
+			if err := g.nodes(n.Else); err != nil {
+				return err
+			}
+		}
+		g.b.WriteString("}\n")
+	case *ForNode:
+		return g.forNode(n)
+	case *SwitchNode:
+		return g.switchNode(n)
+	case *BlockNode:
+		fmt.Fprintf(g.b, "// block %s\n", n.Name)
+		return g.nodes(n.Body)
+	case *CacheNode:
+		return g.cacheNode(n)
+	case *IncludeNode:
+		return g.include(n)
+	default:
+		return fmt.Errorf("vingo: cannot generate code for %T", n)
+	}
+	return nil
+}
+
+func (g *generator) forNode(n *ForNode) error {
+	x, err := nodeExpr(n.list, n.ListExpr)
+	if err != nil {
+		return err
+	}
+	items, i, item := g.tmp("items"), g.tmp("i"), g.tmp("item")
+	typed := false
+	if p, ok := x.(*pathExpr); ok && n.where == nil && n.sortBy == nil {
+		// a typed slice is ranged over as it is
+		sel, ok, err := g.selector(p)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Fprintf(g.b, "%s := %s\n", items, sel)
+			typed = true
+		}
+	}
+	if !typed {
+		list, err := g.expr(x)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(g.b, "%s := r.Items(%s)\n", items, list)
+		if n.where != nil {
+			if err := g.where(n, items); err != nil {
+				return err
+			}
+		}
+		if n.sortBy != nil {
+			if err := g.sortBy(n, items); err != nil {
+				return err
+			}
+		}
+	}
+	g.b.WriteString("r.BeginLoop()\n")
+	fmt.Fprintf(g.b, "for %s, %s := range %s {\n", i, item, items)
+	g.b.WriteString("if r.Stopped() {\nbreak\n}\n")
+	vars := map[string]string{"loop": g.tmp("loop")}
+	fmt.Fprintf(g.b, "%s := r.Loop(%s, len(%s))\n", vars["loop"], i, items)
+	if n.IndexVar != "" {
+		vars[n.IndexVar] = "v_" + n.IndexVar
+		fmt.Fprintf(g.b, "%s := %s\n", vars[n.IndexVar], i)
+	}
+	vars[n.ItemVar] = "v_" + n.ItemVar
+	if typed {
+		// unique, so an untyped loop variable of the same name isn't taken for it
+		vars[n.ItemVar] = g.tmp("v_" + n.ItemVar + "_")
+		g.typed[vars[n.ItemVar]] = true
+	}
+	fmt.Fprintf(g.b, "%s := %s\n", vars[n.ItemVar], item)
+	// the body may not use every loop variable
+	fmt.Fprintf(g.b, "_, _ = %s, %s\n", vars["loop"], vars[n.ItemVar])
+	if n.IndexVar != "" {
+		fmt.Fprintf(g.b, "_ = %s\n", vars[n.IndexVar])
+	}
+	g.push(vars)
+	defer g.pop()
+	if err := g.nodes(n.Body); err != nil {
+		return err
+	}
+	g.b.WriteString("}\nr.EndLoop()\n")
+	return nil
+}
+
+// where: filters items, the Go variable of the loop items, by the where
+// clause of n.
+func (g *generator) where(n *ForNode, items string) error {
+	item, kept := "v_"+n.ItemVar, g.tmp("kept")
+	fmt.Fprintf(g.b, "var %s []interface{}\n", kept)
+	fmt.Fprintf(g.b, "for _, %s := range %s {\n", item, items)
+	g.b.WriteString("if !r.Iterate() {\nbreak\n}\n")
+	g.push(map[string]string{n.ItemVar: item})
+	cond, err := g.expr(n.where)
+	g.pop()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.b, "if r.Truthy(%s) {\n%s = append(%s, %s)\n}\n}\n", cond, kept, kept, item)
+	fmt.Fprintf(g.b, "%s = %s\n", items, kept)
+	return nil
+}
+
+// sortBy: sorts items, the Go variable of the loop items, by the sort
+// clause of n.
+func (g *generator) sortBy(n *ForNode, items string) error {
+	item, i, keys := "v_"+n.ItemVar, g.tmp("i"), g.tmp("keys")
+	fmt.Fprintf(g.b, "%s := make([]interface{}, len(%s))\n", keys, items)
+	fmt.Fprintf(g.b, "for %s, %s := range %s {\n", i, item, items)
+	if n.where == nil {
+		// items the where pass kept were counted already
+		g.b.WriteString("if !r.Iterate() {\nbreak\n}\n")
+	}
+	g.push(map[string]string{n.ItemVar: item})
+	key, err := g.expr(n.sortBy)
+	g.pop()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.b, "%s[%s] = %s\n}\n", keys, i, key)
+	fmt.Fprintf(g.b, "%s = r.Sort(%s, %s, %t)\n", items, items, keys, n.Desc)
+	return nil
+}
+
+func (g *generator) switchNode(n *SwitchNode) error {
+	x, err := nodeExpr(n.expr, n.Expr)
+	if err != nil {
+		return err
+	}
+	val, err := g.expr(x)
+	if err != nil {
+		return err
+	}
+	sw := g.tmp("sw")
+	fmt.Fprintf(g.b, "{\n%s := %s\n", sw, val)
+	g.push(map[string]string{switchValue: sw})
+	defer g.pop()
+	g.b.WriteString("switch {\n")
+	for _, c := range n.Cases {
+		x, err := nodeExpr(c.cond, caseSource(c.Cond))
+		if err != nil {
+			return err
+		}
+		cond, err := g.caseCond(sw, x)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(g.b, "case %s:\n", cond)
+		if err := g.nodes(c.Body); err != nil {
+			return err
+		}
+	}
+	if len(n.Default) > 0 {
+		g.b.WriteString("default:\n")
+		if err := g.nodes(n.Default); err != nil {
+			return err
+		}
+	}
+	g.b.WriteString("}\n}\n")
+	return nil
+}
+
+// caseCond: Go condition of a switch case x against the switch value in
+// the Go variable sw, see SwitchCase.matches.
+func (g *generator) caseCond(sw string, x Expr) (string, error) {
+	switch x := x.(type) {
+	case *binaryExpr, *notExpr:
+		v, err := g.expr(x)
+		if err != nil {
+			return "", err
+		}
+		return "r.Truthy(" + v + ")", nil
+	case *pathExpr:
+		lit, err := goLiteral(literalFromString(x.path))
+		if err != nil {
+			return "", err
+		}
+		if !g.declared(x) {
+			return fmt.Sprintf("r.Equal(%s, %s)", sw, lit), nil
+		}
+		v, err := g.expr(x)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("r.Equal(%s, %s) || r.Truthy(%s)", sw, lit, v), nil
+	}
+	v, err := g.expr(x)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("r.Equal(%s, %s)", sw, v), nil
+}
+
+// switchExpr: a switch expression as a function literal called in place.
+func (g *generator) switchExpr(x *switchExpr) (string, error) {
+	val, err := g.expr(x.x)
+	if err != nil {
+		return "", err
+	}
+	sw := g.tmp("sw")
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "func() interface{} {\n%s := %s\n_ = %s\n", sw, val, sw)
+	g.push(map[string]string{switchValue: sw})
+	defer g.pop()
+	for _, c := range x.cases {
+		conds := make([]string, len(c.conds))
+		for i, cond := range c.conds {
+			if conds[i], err = g.caseCond(sw, cond.cond); err != nil {
+				return "", err
+			}
+		}
+		v, err := g.expr(c.val)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(b, "if (%s) {\nreturn %s\n}\n", strings.Join(conds, ") || ("), v)
+	}
+	def := "nil"
+	if x.def != nil {
+		if def, err = g.expr(x.def); err != nil {
+			return "", err
+		}
+	}
+	fmt.Fprintf(b, "return %s\n}()", def)
+	return b.String(), nil
+}
+
+func (g *generator) cacheNode(n *CacheNode) error {
+	args := make([]string, 5)
+	for i, x := range []Expr{n.key, n.vary, n.per, n.ttl, n.stale} {
+		args[i] = "nil"
+		if x != nil {
+			v, err := g.expr(x)
+			if err != nil {
+				return err
+			}
+			args[i] = v
+		}
+	}
+	g.usesBytes = true
+	fmt.Fprintf(g.b, "r.Cache(w, %s, func(r *vingo.Runtime, w *bytes.Buffer) {\n", strings.Join(args, ", "))
+	if err := g.nodes(n.Body); err != nil {
+		return err
+	}
+	g.b.WriteString("})\n")
+	return nil
+}
+
+// include: generates the included template in place, its keyword
+// arguments becoming Go locals.
+func (g *generator) include(n *IncludeNode) error {
+	if n.path != nil {
+		return fmt.Errorf("vingo: line %d: dynamic includes can't be generated", n.line)
+	}
+	file := n.file
+	if file == "" {
+		file = g.e.resolve(n.Path)
+	}
+	if slices.Contains(g.files, file) {
+		return fmt.Errorf("vingo: include cycle: %s", strings.Join(append(g.files, file), " -> "))
+	}
+	body := n.body
+	if body == nil {
+		tpl, err := g.e.getOrCompile(file)
+		if err != nil {
+			return fmt.Errorf("vingo: include %q: %w", n.Path, err)
+		}
+		body = tpl.Nodes
+	}
+	if g.strict {
+		for name := range setNames(body) {
+			g.sets[name] = true
+		}
+	}
+	fmt.Fprintf(g.b, "{ // include %s\n", n.Path)
+	vars := map[string]string{}
+	for _, kw := range n.vars {
+		vars[kw.name] = g.tmp("inc")
+		v, typed, err := "", false, error(nil)
+		if p, ok := kw.val.(*pathExpr); ok {
+			// typed values stay typed in the included template
+			v, typed, err = g.selector(p)
+		}
+		if !typed && err == nil {
+			v, err = g.expr(kw.val)
+		}
+		if err != nil {
+			return err
+		}
+		if typed {
+			g.typed[vars[kw.name]] = true
+		}
+		fmt.Fprintf(g.b, "%s := %s\n_ = %s\n", vars[kw.name], v, vars[kw.name])
+	}
+	g.push(vars)
+	g.files = append(g.files, file)
+	defer func() {
+		g.pop()
+		g.files = g.files[:len(g.files)-1]
+	}()
+	if err := g.nodes(body); err != nil {
+		return err
+	}
+	g.b.WriteString("}\n")
+	return nil
+}
+
+// nodeExpr: compiled expression of a node, parsing src for hand-built nodes.
+func nodeExpr(compiled Expr, src string) (Expr, error) {
+	if compiled != nil {
+		return compiled, nil
+	}
+	return parseExpr(src)
+}
+
+// expr: Go expression of type interface{} evaluating x; undefined is nil.
+func (g *generator) expr(x Expr) (string, error) {
+	switch x := x.(type) {
+	case *litExpr:
+		if d, ok := x.val.(time.Duration); ok {
+			g.usesTime = true
+			return fmt.Sprintf("time.Duration(%d)", int64(d)), nil
+		}
+		return goLiteral(x.val)
+	case *pathExpr:
+		if sel, ok, err := g.selector(x); ok || err != nil {
+			return "r.Member(" + sel + ")", err
+		}
+		if v, ok := g.local(x.path); ok {
+			return v, nil
+		}
+		if !g.declared(x) {
+			return "", fmt.Errorf("vingo: %s: undeclared variable %s", g.at, x.parts[0])
+		}
+		return "r.Value(" + g.lookup(x, "") + ")", nil
+	case *listExpr:
+		items, err := g.exprs(x.items)
+		if err != nil {
+			return "", err
+		}
+		return "[]interface{}{" + items + "}", nil
+	case *mapExpr:
+		entries := make([]string, len(x.keys))
+		for i, k := range x.keys {
+			v, err := g.expr(x.vals[i])
+			if err != nil {
+				return "", err
+			}
+			entries[i] = strconv.Quote(k) + ": " + v
+		}
+		return "map[string]interface{}{" + strings.Join(entries, ", ") + "}", nil
+	case *callExpr:
+		args, err := g.exprs(x.args)
+		if err != nil {
+			return "", err
+		}
+		argList := "nil"
+		if len(x.args) > 0 {
+			argList = "[]interface{}{" + args + "}"
+		}
+		kwList := "nil"
+		if len(x.kwargs) > 0 {
+			kw := make([]string, len(x.kwargs))
+			for i, k := range x.kwargs {
+				v, err := g.expr(k.val)
+				if err != nil {
+					return "", err
+				}
+				kw[i] = strconv.Quote(k.name) + ": " + v
+			}
+			kwList = "map[string]interface{}{" + strings.Join(kw, ", ") + "}"
+		}
+		return fmt.Sprintf("r.Call(%s, %s, %s)", strconv.Quote(x.name), argList, kwList), nil
+	case *filterExpr:
+		in, err := g.expr(x.x)
+		if err != nil {
+			return "", err
+		}
+		args, err := g.exprs(x.call.args)
+		if err != nil {
+			return "", err
+		}
+		if args != "" {
+			args = ", " + args
+		}
+		return fmt.Sprintf("r.Filter(%s, %s%s)", strconv.Quote(x.call.name), in, args), nil
+	case *binaryExpr:
+		switch x.op {
+		case "and", "or":
+			l, err := g.expr(x.left)
+			if err != nil {
+				return "", err
+			}
+			r, err := g.expr(x.right)
+			if err != nil {
+				return "", err
+			}
+			op := "&&"
+			if x.op == "or" {
+				op = "||"
+			}
+			return fmt.Sprintf("(r.Truthy(%s) %s r.Truthy(%s))", l, op, r), nil
+		case "+":
+			l, err := g.expr(x.left)
+			if err != nil {
+				return "", err
+			}
+			r, err := g.expr(x.right)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("r.Add(%s, %s)", l, r), nil
+		case "matches":
+			l, err := g.expr(x.left)
+			if err != nil {
+				return "", err
+			}
+			r, err := g.expr(x.right)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("r.Matches(%s, %s)", l, r), nil
+		}
+		operand := g.operand
+		if ordering(x.op) {
+			// undefined sides are nil, the comparison is false
+			operand = g.expr
+		}
+		l, err := operand(x.left)
+		if err != nil {
+			return "", err
+		}
+		r, err := operand(x.right)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("r.Compare(%s, %s, %s, %s)", l, r, strconv.Quote(x.op), strconv.Quote(g.at)), nil
+	case *notExpr:
+		v, err := g.expr(x.x)
+		if err != nil {
+			return "", err
+		}
+		return "!r.Truthy(" + v + ")", nil
+	case *switchExpr:
+		return g.switchExpr(x)
+	}
+	return "", fmt.Errorf("vingo: cannot generate code for expression %T", x)
+}
+
+// exprs: comma separated Go expressions for xs.
+func (g *generator) exprs(xs []Expr) (string, error) {
+	out := make([]string, len(xs))
+	for i, x := range xs {
+		v, err := g.expr(x)
+		if err != nil {
+			return "", err
+		}
+		out[i] = v
+	}
+	return strings.Join(out, ", "), nil
+}
+
+// operand: side of an == or != comparison; an undefined bare name stands
+// for itself.
+func (g *generator) operand(x Expr) (string, error) {
+	p, ok := x.(*pathExpr)
+	if !ok {
+		return g.expr(x)
+	}
+	if _, ok, _ := g.selector(p); ok {
+		return g.expr(x)
+	}
+	lit, err := goLiteral(literalFromString(p.path))
+	if err != nil {
+		return "", err
+	}
+	if !g.declared(p) {
+		return lit, nil
+	}
+	return g.lookup(p, lit), nil
+}
+
+// lookup: Go call resolving the path of p, returning (value, ok); with a
+// non-empty or, a single value falling back to the Go expression or.
+func (g *generator) lookup(p *pathExpr, or string) string {
+	quoted := func(parts []string) string {
+		q := make([]string, len(parts))
+		for i, s := range parts {
+			q[i] = strconv.Quote(s)
+		}
+		return strings.Join(q, ", ")
+	}
+	if v, ok := g.local(p.parts[0]); ok {
+		args := v
+		if rest := p.parts[1:]; len(rest) > 0 {
+			args += ", " + quoted(rest)
+		}
+		if or != "" {
+			return fmt.Sprintf("r.PathOr(%s, %s)", or, args)
+		}
+		return fmt.Sprintf("r.Path(%s)", args)
+	}
+	if or != "" {
+		return fmt.Sprintf("r.VarOr(%s, data, %s)", or, quoted(p.parts))
+	}
+	return fmt.Sprintf("r.Var(data, %s)", quoted(p.parts))
+}
+
+// goLiteral: Go source of a literal value of an expression.
+func goLiteral(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return strconv.Quote(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int:
+		return strconv.Itoa(t), nil
+	case float64:
+		return "float64(" + strconv.FormatFloat(t, 'g', -1, 64) + ")", nil
+	}
+	return "", fmt.Errorf("vingo: cannot generate literal of type %T", v)
+}
+
+// DeclNode: <{ params }> or <{ import }> tag, read by Generate.
+type DeclNode struct {
+	Kind  string // "params" or "import"
+	Value string // parameter list or quoted import path
+	Line  int
+
+	params []goParam
+	path   string
+}
+
+// goParam: a parameter of a params tag.
+type goParam struct {
+	name, typ string
+}
+
+// render: declarations render nothing.
+func (n *DeclNode) render(s *renderState, sc *scope, out *bytes.Buffer) {}
+
+// reservedParams: names of generated code a parameter can't take.
+var reservedParams = []string{"ctx", "e", "r", "w", "data", "out", "err", "bytes", "context", "time", "vingo"}
+
+// parseDecl: t.Value is a Go parameter list (params) or a Go import path.
+func parseDecl(t *Token) (*DeclNode, error) {
+	node := &DeclNode{Kind: t.Type.String(), Value: t.Value, Line: t.Line}
+	if t.Type == TImport {
+		path, err := strconv.Unquote(t.Value)
+		if err != nil || path == "" {
+			return nil, tokenError(t, "invalid import tag: %s", t.Raw)
+		}
+		node.path = path
+		return node, nil
+	}
+	x, err := parser.ParseExpr("func(" + t.Value + ")")
+	fn, ok := x.(*ast.FuncType)
+	if err != nil || !ok || fn.Params == nil {
+		return nil, tokenError(t, "invalid params tag, expected Go parameters like user *models.User: %s", t.Raw)
+	}
+	for _, f := range fn.Params.List {
+		if len(f.Names) == 0 {
+			return nil, tokenError(t, "invalid params tag, parameter %s has no name", types.ExprString(f.Type))
+		}
+		for _, name := range f.Names {
+			if slices.Contains(reservedParams, name.Name) {
+				return nil, tokenError(t, "invalid params tag, %s is a name of the generated code", name.Name)
+			}
+			node.params = append(node.params, goParam{name: name.Name, typ: types.ExprString(f.Type)})
+		}
+	}
+	return node, nil
+}
+
+// splitDecls: removes the params and import tags from nodes, with the line
+// break following each.
+func splitDecls(nodes []Node) ([]Node, []*DeclNode) {
+	var decls []*DeclNode
+	out := nodes[:0]
+	for i, n := range nodes {
+		d, ok := n.(*DeclNode)
+		if !ok {
+			out = append(out, n)
+			continue
+		}
+		decls = append(decls, d)
+		trimLineBreak(nodes, i+1)
+	}
+	return out, decls
+}
@@ -0,0 +1,209 @@
+package vingo
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles: writes files (slash separated path -> content) under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// generateIn: the code Generate writes for files of a tree written to a
+// new directory, with Root set to its views directory.
+func generateIn(t *testing.T, tree map[string]string, files ...string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, tree)
+	e := New()
+	e.Root = filepath.Join(dir, "views")
+	e.AllowOutsideRoot = true
+	var buf bytes.Buffer
+	if err := e.Generate(&buf, "views", files...); err != nil {
+		t.Fatal(err)
+	}
+	return dir, buf.String()
+}
+
+func TestGenerateRelativePaths(t *testing.T) {
+	tree := map[string]string{
+		"views/page.vgo":       `<{ include "../shared/nav.vgo" }><{ a > 1 }>`,
+		"views/admin/user.vgo": `<{ b < 2 }>`,
+		"shared/nav.vgo":       `<{ c >= 3 }>`,
+	}
+	dir1, src1 := generateIn(t, tree, "page.vgo", "admin/user.vgo")
+	dir2, src2 := generateIn(t, tree, "page.vgo", "admin/user.vgo")
+	if src1 != src2 {
+		t.Errorf("generated code differs between checkouts:\n%s\n---\n%s", src1, src2)
+	}
+	for _, dir := range []string{dir1, dir2} {
+		if strings.Contains(src1, filepath.ToSlash(dir)) || strings.Contains(src1, dir) {
+			t.Errorf("generated code holds the path %s:\n%s", dir, src1)
+		}
+	}
+	for _, want := range []string{
+		"// Page renders page.vgo.",
+		"// User renders admin/user.vgo.",
+		`"page.vgo:1:34"`,
+		`"admin/user.vgo:1:1"`,
+		`"../shared/nav.vgo:1:1"`,
+	} {
+		if !strings.Contains(src1, want) {
+			t.Errorf("generated code lacks %s:\n%s", want, src1)
+		}
+	}
+}
+
+func TestGenerateParams(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"signature", "<{ params user *User, n int }>\n<{ user.Name }>", "func Page(ctx context.Context, e *vingo.Engine, user *User, n int) (out string, err error) {"},
+		{"selector", "<{ params user *User }><{ user.Address.City }>", "r.Write(w, r.Member(user.Address.City), \"\")"},
+		{"range", "<{ params items []Item }><{ for it in items }><{ it.Name }><{ /for }>", "range items"},
+		{"set", "<{ params n int }><{ set m = n }><{ m }>", `r.Var(data, "m")`},
+		{"bare name", "<{ params n string }><{ if n == open }>x<{ /if }>", `r.Compare(r.Member(n), "open"`},
+		{"import", "<{ import \"example.com/models\" }>\n<{ params u models.User }><{ u.Name }>", "\"example.com/models\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, src := generateIn(t, map[string]string{"views/page.vgo": tt.src}, "page.vgo")
+			if !strings.Contains(src, tt.want) {
+				t.Errorf("generated code lacks %s:\n%s", tt.want, src)
+			}
+		})
+	}
+
+	for _, src := range []string{
+		"<{ params user *User }><{ other }>",
+		"<{ params user *User }><{ user.Name }><{ for x in xs }><{ /for }>",
+		"<{ params data map[string]string }>",
+		"<{ params *User }>",
+		"<{ params user *User, user string }>",
+	} {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"page.vgo": src})
+		e := New()
+		e.Root = dir
+		if err := e.Generate(&bytes.Buffer{}, "views", "page.vgo"); err == nil {
+			t.Errorf("generate %q: no error", src)
+		}
+	}
+}
+
+// TestGenerateBuild: generated code compiles and renders like the
+// interpreter, with typed parameters checked by the Go compiler.
+func TestGenerateBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	repo, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := os.ReadFile("go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := `<{ import "gentest/models" }>
+<{ params user *models.User, items []models.Item }>
+<{ set title = "Shop" }><h1><{ title }>, <{ user.Greeting }></h1>
+<{ for i, it in items }><{ i }>:<{ it.Name }><{ if !loop.Last }>,<{ /if }><{ /for }>
+<{ for it in items sort by it.Price }><{ it.Name }><{ /for }>
+<{ include "card.vgo" u=user }><{ if user.Name == Ali }>!<{ /if }>
+<{ user.Address.City | default:"-" }>
+`
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module gentest\n\ngo 1.24\n\nrequire github.com/coderiantest/vingo v0.0.0\n\nreplace github.com/coderiantest/vingo => " + filepath.ToSlash(repo) + "\n",
+		"go.sum": string(sum),
+		"models/models.go": `package models
+
+type Address struct{ City string }
+
+type User struct {
+	Name    string
+	Address *Address
+}
+
+func (u *User) Greeting() string { return "hi " + u.Name }
+
+type Item struct {
+	Name  string
+	Price int
+}
+`,
+		"views/page.vgo": page,
+		"views/card.vgo": `[<{ u.Name | upper }>]`,
+		"views/list.vgo": `<{ for x in xs }><{ x }><{ /for }>`,
+		"main.go": `package main
+
+import (
+	"context"
+	"fmt"
+
+	"gentest/models"
+	"gentest/views"
+)
+
+func main() {
+	ctx := context.Background()
+	u := &models.User{Name: "Ali", Address: &models.Address{City: "İzmir"}}
+	fmt.Println(views.Page(ctx, nil, u, []models.Item{{"b", 2}, {"a", 1}}))
+	fmt.Println(views.List(ctx, nil, map[string]interface{}{"xs": []int{1, 2}}))
+	_, err := views.Page(ctx, nil, nil, nil)
+	fmt.Println(err != nil)
+}
+`,
+	})
+	e := New()
+	e.Root = filepath.Join(dir, "views")
+	var buf bytes.Buffer
+	if err := e.Generate(&buf, "views", "page.vgo", "list.vgo"); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"views/views.go": buf.String()})
+
+	goRun := func() (string, error) {
+		cmd := exec.Command("go", "run", ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	out, err := goRun()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s\n%s", err, out, buf.String())
+	}
+	want := "<h1>Shop, hi Ali</h1>\n0:b,1:a\nab\n[ALI]!\nİzmir\n <nil>\n12 <nil>\ntrue\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	// a field the type doesn't have is a compile error
+	writeFiles(t, dir, map[string]string{"views/page.vgo": strings.Replace(page, "it.Name", "it.Title", 1)})
+	buf.Reset()
+	e = New()
+	e.Root = filepath.Join(dir, "views")
+	if err := e.Generate(&buf, "views", "page.vgo", "list.vgo"); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"views/views.go": buf.String()})
+	if out, err := goRun(); err == nil || !strings.Contains(out, "Title") {
+		t.Errorf("go run with it.Title: %v\n%s", err, out)
+	}
+}
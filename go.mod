@@ -2,3 +2,10 @@ module github.com/coderiantest/vingo
 
 go 1.24.4
 
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	golang.org/x/text v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.33.0 // indirect
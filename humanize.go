@@ -0,0 +1,186 @@
+package vingo
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -------------------- Humanize filters --------------------
+//
+//	<{ count }> item<{ count | pluralize }>         item / items
+//	<{ count }> <{ count | pluralize:"item","items" }>
+//	<{ n }> famil<{ n | pluralize:"y","ies" }>        also pluralize:"y,ies"
+//	<{ rank | ordinal }>                             1st, 2nd, 3rd, 11th
+//	<{ size | humanize_bytes }>                      1.5 KB (filesizeformat too)
+//	<{ elapsed | humanize_duration }>                2 hours 5 minutes
+//
+// pluralize takes a number or a list (its length). English only; localized
+// plurals are the t tag's job.
+
+func init() {
+	builtinFuncs["pluralize"] = pluralizeFunc
+	builtinFuncs["ordinal"] = ordinalFunc
+	builtinFuncs["humanize_bytes"] = humanizeBytesFunc
+	builtinFuncs["filesizeformat"] = humanizeBytesFunc
+	builtinFuncs["humanize_duration"] = humanizeDurationFunc
+}
+
+func pluralizeFunc(c *Call) (interface{}, error) {
+	n, err := countOf(c.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	singular, plural := "", "s"
+	switch len(c.Args) {
+	case 1:
+	case 2:
+		plural = argString(c.Arg(1))
+		if s, p, ok := strings.Cut(plural, ","); ok {
+			singular, plural = s, p
+		}
+	case 3:
+		singular, plural = argString(c.Arg(1)), argString(c.Arg(2))
+	default:
+		return nil, fmt.Errorf("takes at most 2 forms, got %d", len(c.Args)-1)
+	}
+	if n == 1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+// countOf: v as a count; lists and maps count their elements.
+func countOf(v interface{}) (float64, error) {
+	if n, ok := toFloat(v); ok {
+		return n, nil
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), nil
+	}
+	return 0, fmt.Errorf("expected a number or a list, got %T", v)
+}
+
+func ordinalFunc(c *Call) (interface{}, error) {
+	n, ok := toFloat(c.Arg(0))
+	if !ok || n != math.Trunc(n) {
+		return nil, fmt.Errorf("expected an integer, got %v", c.Arg(0))
+	}
+	i := int64(n)
+	abs := i
+	if abs < 0 {
+		abs = -abs
+	}
+	suffix := "th"
+	if abs%100 < 11 || abs%100 > 13 {
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.FormatInt(i, 10) + suffix, nil
+}
+
+// byteUnits: units of humanize_bytes, powers of 1024.
+var byteUnits = []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+
+func humanizeBytesFunc(c *Call) (interface{}, error) {
+	n, ok := toFloat(c.Arg(0))
+	if !ok {
+		return nil, fmt.Errorf("expected a number of bytes, got %T", c.Arg(0))
+	}
+	if math.Abs(n) < 1024 {
+		if n == 1 {
+			return "1 byte", nil
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64) + " bytes", nil
+	}
+	unit := ""
+	for _, u := range byteUnits {
+		if math.Abs(n) < 1024 {
+			break
+		}
+		n /= 1024
+		unit = u
+	}
+	return trimZero(strconv.FormatFloat(n, 'f', 1, 64)) + " " + unit, nil
+}
+
+// trimZero: "2.0" -> "2".
+func trimZero(s string) string {
+	return strings.TrimSuffix(s, ".0")
+}
+
+// durationUnits: units of humanize_duration, largest first.
+var durationUnits = []struct {
+	name string
+	d    time.Duration
+}{
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// humanizeDurationFunc: the two largest units of a duration: a
+// time.Duration, a number of seconds or a string like "90m".
+func humanizeDurationFunc(c *Call) (interface{}, error) {
+	var d time.Duration
+	switch v := c.Arg(0).(type) {
+	case time.Duration:
+		d = v
+	case string:
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return nil, err
+		}
+	default:
+		secs, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("expected a duration or seconds, got %T", v)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	var parts []string
+	for _, u := range durationUnits {
+		if len(parts) == 2 {
+			break
+		}
+		if n := d / u.d; n > 0 || len(parts) > 0 {
+			d -= n * u.d
+			if n > 0 {
+				parts = append(parts, plural(int64(n), u.name))
+			} else {
+				// 2 days 0 hours: stop at the first unit
+				break
+			}
+		}
+	}
+	if len(parts) == 0 {
+		if d > 0 {
+			return sign + "less than a second", nil
+		}
+		return "0 seconds", nil
+	}
+	return sign + strings.Join(parts, " "), nil
+}
+
+// plural: "1 hour", "2 hours".
+func plural(n int64, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return strconv.FormatInt(n, 10) + " " + unit + "s"
+}
@@ -0,0 +1,610 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// -------------------- Translations --------------------
+//
+//	<{ t "checkout.title" }>
+//	<{ t "cart.items" count=cart.Count }>
+//	<{ t "greeting" name=user.Name }>
+//
+// writes the message of the key in the locale of the render, from
+// Engine.I18n.Catalog. Keyword arguments replace {name} placeholders in the
+// message; count also selects the plural form by the plural rules of the
+// locale. t("key", count=n) is the same as a function, for arguments of
+// other calls. Missing keys render as the key itself.
+//
+// The locale of a render is the one set with WithLocale on its context,
+// else the "locale" variable of the data, else I18n.DefaultLocale. Keys
+// missing in "pt-BR" are looked up in "pt", then in the default locale.
+//
+// With I18n.InlineTranslations each template is compiled once per locale
+// and translations whose arguments are literals become static text, so hot
+// localized pages skip the catalog on every render. Call Flush after
+// changing the catalog in that mode.
+
+// I18nOptions: configuration of the t tag.
+type I18nOptions struct {
+	Catalog       Catalog
+	DefaultLocale string
+
+	// InlineTranslations compiles templates per locale with the
+	// translations resolved at compile time.
+	InlineTranslations bool
+
+	// Missing, when set, is called for keys without a translation.
+	Missing func(locale, key string)
+}
+
+// Message: translation of one key by plural category ("zero", "one",
+// "two", "few", "many", "other") or exact count ("=0"). A message without
+// plural forms has only "other".
+type Message map[string]string
+
+// Catalog: source of translations, e.g. a MemoryCatalog or a database.
+type Catalog interface {
+	// Message returns the translation of key in locale, ok is false if
+	// there is none.
+	Message(locale, key string) (msg Message, ok bool)
+}
+
+// MemoryCatalog: Catalog held in memory, locale -> key -> message.
+type MemoryCatalog map[string]map[string]Message
+
+func (c MemoryCatalog) Message(locale, key string) (Message, bool) {
+	m, ok := c[locale][key]
+	return m, ok
+}
+
+type localeKey struct{}
+
+// WithLocale: ctx selecting locale for the renders using it.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale: locale of the render the call belongs to.
+func (c *Call) Locale() string {
+	return c.s.engine.localeOf(c.s.ctx, c.s.data)
+}
+
+// localeOf: locale of a render with ctx and data.
+func (e *Engine) localeOf(ctx context.Context, data map[string]interface{}) string {
+	if l, ok := ctx.Value(localeKey{}).(string); ok && l != "" {
+		return l
+	}
+	if l, ok := data["locale"].(string); ok && l != "" {
+		return l
+	}
+	return e.I18n.DefaultLocale
+}
+
+func init() {
+	builtinFuncs["t"] = func(c *Call) (interface{}, error) {
+		key, ok := c.Arg(0).(string)
+		if !ok {
+			return nil, fmt.Errorf("key must be a string, got %T", c.Arg(0))
+		}
+		locale := c.Locale()
+		msg, ok := c.Engine().message(locale, key)
+		return msg.format(c.Engine(), locale, key, ok, c.Kwargs), nil
+	}
+}
+
+// message: translation of key in locale or its fallbacks.
+func (e *Engine) message(locale, key string) (Message, bool) {
+	if e.I18n.Catalog == nil {
+		return nil, false
+	}
+	for _, l := range localeChain(locale, e.I18n.DefaultLocale) {
+		if m, ok := e.I18n.Catalog.Message(l, key); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// localeChain: locales to look keys up in, most specific first:
+// "pt-BR" -> pt-BR, pt, then def and its base language.
+func localeChain(locale, def string) []string {
+	var chain []string
+	add := func(l string) {
+		for l != "" {
+			if !slices.Contains(chain, l) {
+				chain = append(chain, l)
+			}
+			i := strings.LastIndexAny(l, "-_")
+			if i < 0 {
+				break
+			}
+			l = l[:i]
+		}
+	}
+	add(locale)
+	add(def)
+	return chain
+}
+
+// format: the text of m for kwargs; found is false for a missing key,
+// which renders as the key.
+func (m Message) format(e *Engine, locale, key string, found bool, kwargs map[string]interface{}) string {
+	if !found {
+		if e.I18n.Missing != nil {
+			e.I18n.Missing(locale, key)
+		}
+		return key
+	}
+	return interpolate(m.form(locale, kwargs["count"]), kwargs)
+}
+
+// form: the plural form of m for count (nil: no count).
+func (m Message) form(locale string, count interface{}) string {
+	if count != nil {
+		if n, ok := toFloat(count); ok {
+			if s, ok := m["="+strconv.FormatFloat(n, 'f', -1, 64)]; ok {
+				return s
+			}
+			if s, ok := m[PluralCategory(locale, n)]; ok {
+				return s
+			}
+		}
+	}
+	if s, ok := m["other"]; ok {
+		return s
+	}
+	for _, c := range pluralOrder {
+		if s, ok := m[c]; ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// interpolate: s with {name} replaced by the kwargs; unknown names are kept.
+func interpolate(s string, kwargs map[string]interface{}) string {
+	if len(kwargs) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		if v, ok := kwargs[s[i+1:i+j]]; ok {
+			b.WriteString(argString(v))
+		} else {
+			b.WriteString(s[i : i+j+1])
+		}
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// transExpr: t("key", ...) of a template compiled for one locale, the
+// message looked up at compile time.
+type transExpr struct {
+	engine *Engine
+	locale string
+	key    string
+	msg    Message
+	found  bool
+	kwargs []kwarg
+}
+
+func (x *transExpr) eval(s *renderState, sc *scope) (interface{}, bool) {
+	var kwargs map[string]interface{}
+	if len(x.kwargs) > 0 {
+		kwargs = make(map[string]interface{}, len(x.kwargs))
+		for _, kw := range x.kwargs {
+			kwargs[kw.name], _ = kw.val.eval(s, sc)
+		}
+	}
+	return x.msg.format(x.engine, x.locale, x.key, x.found, kwargs), true
+}
+
+// translateCall: c, a call of t with folded arguments, for a template
+// compiled for locale: static text if all arguments are literals, else a
+// transExpr. Calls with a computed key are kept.
+func (e *Engine) translateCall(c *callExpr, locale string) Expr {
+	if len(c.args) != 1 {
+		return c
+	}
+	lit, ok := c.args[0].(*litExpr)
+	if !ok {
+		return c
+	}
+	key, ok := lit.val.(string)
+	if !ok {
+		return c
+	}
+	x := &transExpr{engine: e, locale: locale, key: key, kwargs: c.kwargs}
+	x.msg, x.found = e.message(locale, key)
+	for _, kw := range c.kwargs {
+		if !isLiteral(kw.val) {
+			return x
+		}
+	}
+	v, _ := x.eval(nil, nil)
+	return &litExpr{val: v}
+}
+
+// -------------------- Plural rules --------------------
+
+// pluralOrder: the plural categories in CLDR order.
+var pluralOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// PluralCategory: CLDR plural category of n in locale ("one", "few",
+// "other"...), for the common languages; others use the English rule.
+func PluralCategory(locale string, n float64) string {
+	integer := n == math.Trunc(n)
+	i := int64(math.Abs(n))
+	switch baseLanguage(locale) {
+	case "ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km":
+		return "other"
+	case "fr", "pt", "hi", "bn", "fa", "gu", "kn", "zu", "am":
+		if n >= 0 && n < 2 {
+			return "one"
+		}
+		return "other"
+	case "ru", "uk", "be", "hr", "sr", "bs":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "pl":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 1:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "cs", "sk":
+		switch {
+		case !integer:
+			return "many"
+		case i == 1:
+			return "one"
+		case i >= 2 && i <= 4:
+			return "few"
+		}
+		return "other"
+	case "ar":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 0:
+			return "zero"
+		case i == 1:
+			return "one"
+		case i == 2:
+			return "two"
+		case i%100 >= 3 && i%100 <= 10:
+			return "few"
+		case i%100 >= 11:
+			return "many"
+		}
+		return "other"
+	case "he":
+		switch {
+		case integer && i == 1:
+			return "one"
+		case integer && i == 2:
+			return "two"
+		}
+		return "other"
+	}
+	if integer && i == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// pluralCategories: the categories PluralCategory returns for locale, in
+// CLDR order.
+func pluralCategories(locale string) []string {
+	switch baseLanguage(locale) {
+	case "ja", "zh", "ko", "vi", "th", "id", "ms", "lo", "my", "km":
+		return []string{"other"}
+	case "ru", "uk", "be", "hr", "sr", "bs", "pl", "cs", "sk":
+		return []string{"one", "few", "many", "other"}
+	case "ar":
+		return pluralOrder
+	case "he":
+		return []string{"one", "two", "other"}
+	}
+	return []string{"one", "other"}
+}
+
+// baseLanguage: language subtag of a locale, lower case ("pt-BR" -> "pt").
+func baseLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// -------------------- Catalog files --------------------
+//
+// JSON, YAML and TOML catalogs nest keys in objects:
+//
+//	{"checkout": {"title": "Checkout"},
+//	 "cart": {"items": {"=0": "Your cart is empty", "one": "{count} item", "other": "{count} items"}}}
+//
+// An object whose keys are all plural categories or exact counts is a
+// plural message. Gettext .po catalogs use msgid as the key; the msgstr[n]
+// forms are the plural categories of the locale in CLDR order.
+
+// DecodeCatalog: parses the messages of locale in format "json", "yaml",
+// "toml" or "po". For .po files an empty locale is read from the
+// Language header.
+func DecodeCatalog(b []byte, format, locale string) (map[string]Message, error) {
+	if format == "po" {
+		msgs, err := decodePO(string(b), locale)
+		if err != nil {
+			return nil, fmt.Errorf("vingo: po catalog: %w", err)
+		}
+		return msgs, nil
+	}
+	data, err := DecodeData(b, format)
+	if err != nil {
+		return nil, err
+	}
+	msgs := map[string]Message{}
+	if err := flattenCatalog(msgs, "", data); err != nil {
+		return nil, fmt.Errorf("vingo: %s catalog: %w", format, err)
+	}
+	return msgs, nil
+}
+
+// LoadCatalogDir: MemoryCatalog of the files in dir named after their
+// locale: en.json, fr.toml, pt-BR.po... Other files are ignored.
+func LoadCatalogDir(dir string) (MemoryCatalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	c := MemoryCatalog{}
+	for _, ent := range entries {
+		ext := filepath.Ext(ent.Name())
+		format := DataFormat(ent.Name())
+		if ext == ".po" {
+			format = "po"
+		}
+		if ent.IsDir() || format == "" {
+			continue
+		}
+		path := filepath.Join(dir, ent.Name())
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		locale := strings.TrimSuffix(ent.Name(), ext)
+		msgs, err := DecodeCatalog(b, format, locale)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if c[locale] == nil {
+			c[locale] = map[string]Message{}
+		}
+		for k, m := range msgs {
+			c[locale][k] = m
+		}
+	}
+	return c, nil
+}
+
+// flattenCatalog: adds the messages of a decoded catalog object under
+// prefix to msgs.
+func flattenCatalog(msgs map[string]Message, prefix string, obj map[string]interface{}) error {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := obj[k].(type) {
+		case string:
+			msgs[key] = Message{"other": v}
+		case map[string]interface{}:
+			if m, ok := pluralMessage(v); ok {
+				msgs[key] = m
+				continue
+			}
+			if err := flattenCatalog(msgs, key, v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: message must be a string or an object, got %T", key, v)
+		}
+	}
+	return nil
+}
+
+// pluralMessage: obj as a plural message, if all its keys are plural
+// categories or exact counts and all its values strings.
+func pluralMessage(obj map[string]interface{}) (Message, bool) {
+	if len(obj) == 0 {
+		return nil, false
+	}
+	m := Message{}
+	for k, v := range obj {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		if !isPluralKey(k) {
+			return nil, false
+		}
+		m[k] = s
+	}
+	return m, true
+}
+
+func isPluralKey(k string) bool {
+	if strings.HasPrefix(k, "=") {
+		_, err := strconv.ParseFloat(k[1:], 64)
+		return err == nil
+	}
+	for _, c := range pluralOrder {
+		if k == c {
+			return true
+		}
+	}
+	return false
+}
+
+// poEntry: one message of a .po file.
+type poEntry struct {
+	id, plural string
+	strs       map[int]string // msgstr[n] -> translation
+	fuzzy      bool
+}
+
+// decodePO: messages of a gettext .po file. Fuzzy and untranslated
+// entries are skipped, msgctxt is ignored.
+func decodePO(src, locale string) (map[string]Message, error) {
+	var entries []*poEntry
+	var cur *poEntry
+	last := "" // keyword continuation strings belong to
+	fuzzy := false
+	for n, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			last = ""
+			continue
+		}
+		if line[0] == '#' {
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				fuzzy = true
+			}
+			continue
+		}
+		keyword, quoted := "", line
+		if line[0] != '"' {
+			keyword, quoted, _ = strings.Cut(line, " ")
+			quoted = strings.TrimSpace(quoted)
+		}
+		s, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if keyword == "" {
+			if last == "" {
+				return nil, fmt.Errorf("line %d: string outside of an entry", n+1)
+			}
+			cur.add(last, s)
+			continue
+		}
+		if keyword == "msgctxt" || keyword == "msgid" && last != "msgctxt" {
+			cur = &poEntry{strs: map[int]string{}, fuzzy: fuzzy}
+			entries = append(entries, cur)
+			fuzzy = false
+		} else if cur == nil {
+			return nil, fmt.Errorf("line %d: %s before msgid", n+1, keyword)
+		}
+		if keyword == "msgstr" {
+			keyword = "msgstr[0]"
+		}
+		if !cur.add(keyword, s) {
+			return nil, fmt.Errorf("line %d: unknown keyword %q", n+1, keyword)
+		}
+		last = keyword
+	}
+
+	// the header (empty msgid) names the language
+	for _, e := range entries {
+		if e.id != "" || locale != "" {
+			continue
+		}
+		for _, h := range strings.Split(e.strs[0], "\n") {
+			if k, v, ok := strings.Cut(h, ":"); ok && strings.TrimSpace(k) == "Language" {
+				locale = strings.TrimSpace(v)
+			}
+		}
+	}
+	msgs := map[string]Message{}
+	for _, e := range entries {
+		if e.id == "" || e.fuzzy {
+			continue
+		}
+		if e.plural == "" {
+			if s := e.strs[0]; s != "" {
+				msgs[e.id] = Message{"other": s}
+			}
+			continue
+		}
+		m := Message{}
+		for i, c := range pluralCategories(locale) {
+			if s := e.strs[i]; s != "" {
+				m[c] = s
+			}
+		}
+		if _, ok := m["other"]; !ok {
+			// fewer forms than categories: the last one covers the rest
+			for i := len(e.strs) - 1; i >= 0; i-- {
+				if s := e.strs[i]; s != "" {
+					m["other"] = s
+					break
+				}
+			}
+		}
+		if len(m) > 0 {
+			msgs[e.id] = m
+		}
+	}
+	return msgs, nil
+}
+
+// add: appends s to the field of keyword, reports whether it is one.
+func (e *poEntry) add(keyword, s string) bool {
+	switch keyword {
+	case "msgctxt":
+	case "msgid":
+		e.id += s
+	case "msgid_plural":
+		e.plural += s
+	default:
+		i, ok := strings.CutPrefix(keyword, "msgstr[")
+		if !ok || !strings.HasSuffix(i, "]") {
+			return false
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(i, "]"))
+		if err != nil || n < 0 {
+			return false
+		}
+		e.strs[n] += s
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package vingo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInlineTranslations(t *testing.T) {
+	e := New()
+	e.Root = t.TempDir()
+	e.I18n = I18nOptions{
+		DefaultLocale:      "en",
+		InlineTranslations: true,
+		Catalog: MemoryCatalog{
+			"en": {"title": {"other": "Checkout"}, "items": {"one": "{count} item", "other": "{count} items"}},
+			"tr": {"title": {"other": "Ödeme"}, "items": {"other": "{count} ürün"}},
+		},
+	}
+	src := `<{ t "title" }>: <{ t "items" count=2 }>`
+	if err := os.WriteFile(filepath.Join(e.Root, "page.vgo"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for locale, want := range map[string][]string{
+		"en": {"Checkout", "2 items"},
+		"tr": {"Ödeme", "2 ürün"},
+	} {
+		tpl, err := e.compile(e.resolve("page.vgo"), src, locale, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var static []string
+		for _, n := range tpl.Nodes {
+			if v, ok := n.(*VarNode); ok {
+				lit, ok := v.expr.(*litExpr)
+				if !ok {
+					t.Fatalf("%s: %s compiled to %T, want static text", locale, v.Name, v.expr)
+				}
+				static = append(static, lit.val.(string))
+			}
+		}
+		if len(static) != len(want) || static[0] != want[0] || static[1] != want[1] {
+			t.Errorf("%s: inlined %q, want %q", locale, static, want)
+		}
+	}
+
+	// both variants are cached; the catalog is no longer read once they are
+	for _, locale := range []string{"en", "tr"} {
+		if _, err := e.RenderContext(WithLocale(context.Background(), locale), "page.vgo", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.I18n.Catalog = MemoryCatalog{}
+	for locale, want := range map[string]string{"en": "Checkout: 2 items", "tr": "Ödeme: 2 ürün"} {
+		got, err := e.RenderContext(WithLocale(context.Background(), locale), "page.vgo", nil)
+		if err != nil || got != want {
+			t.Errorf("render in %s = %q, %v; want %q", locale, got, err, want)
+		}
+	}
+}
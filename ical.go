@@ -0,0 +1,108 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// -------------------- iCalendar / vCard --------------------
+//
+//	BEGIN:VEVENT
+//	UID:<{ event.ID }>@example.com
+//	DTSTART:<{ event.Start | ical_time }>
+//	SUMMARY:<{ event.Title | ical_escape }>
+//	END:VEVENT
+//
+// ical_escape (vcard_escape for contact cards, the rules are the same)
+// escapes backslashes, commas, semicolons and line breaks of a TEXT value.
+// ical_time formats a time.Time (or an RFC 3339 string) as a UTC date-time,
+// ical_time:"date" as a DATE value.
+//
+// Both formats are made of CRLF terminated content lines of at most 75
+// octets, which templates can't produce by hand; rendering through
+// ContentLineStage folds and terminates the lines:
+//
+//	ics, err := e.RenderPipeline(ctx, "invite.ics.vgo", data, vingo.ContentLineStage{})
+
+func init() {
+	builtinFuncs["ical_escape"] = icalEscapeFunc
+	builtinFuncs["vcard_escape"] = icalEscapeFunc
+	builtinFuncs["ical_time"] = icalTimeFunc
+}
+
+var icalEscaper = strings.NewReplacer(
+	`\`, `\\`, ";", `\;`, ",", `\,`,
+	"\r\n", `\n`, "\n", `\n`, "\r", `\n`,
+)
+
+func icalEscapeFunc(c *Call) (interface{}, error) {
+	return icalEscaper.Replace(argString(c.Arg(0))), nil
+}
+
+func icalTimeFunc(c *Call) (interface{}, error) {
+	var t time.Time
+	switch v := c.Arg(0).(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		t = *v
+	case string:
+		var err error
+		if t, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, fmt.Errorf("invalid time %q", v)
+		}
+	default:
+		return nil, fmt.Errorf("invalid time %v", v)
+	}
+	switch kind := argString(c.Arg(1)); kind {
+	case "", "datetime":
+		return t.UTC().Format("20060102T150405Z"), nil
+	case "date":
+		return t.Format("20060102"), nil
+	default:
+		return nil, fmt.Errorf("unknown ical_time kind %q", kind)
+	}
+}
+
+// ContentLineStage: Stage turning rendered iCalendar / vCard text into
+// valid content lines. Lines are trimmed, blank lines (left by tags on
+// lines of their own) are dropped, lines longer than 75 octets are folded
+// and every line ends with CRLF.
+type ContentLineStage struct{}
+
+func (ContentLineStage) Process(ctx context.Context, in []byte) ([]byte, error) {
+	return []byte(FoldContentLines(string(in))), nil
+}
+
+// FoldContentLines: see ContentLineStage.
+func FoldContentLines(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/64)
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(strings.TrimLeft(line, " \t"), " \t\r")
+		if line == "" {
+			continue
+		}
+		// the first line holds 75 octets, continuations 74 after their space
+		max := 75
+		for len(line) > max {
+			cut := max
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			b.WriteString(line[:cut])
+			b.WriteString("\r\n ")
+			line = line[cut:]
+			max = 74
+		}
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package vingo
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// -------------------- image() helper --------------------
+//
+//	<{ image("hero.jpg", widths=[480, 960, 1920], sizes="50vw", alt="Hero") }>
+//
+// emits an <img> with a srcset entry per width. With formats=["avif", "webp"]
+// it emits a <picture> with one <source> per extra format. Other keyword
+// arguments (alt, class, loading, ...) become attributes of the <img>.
+
+// ImageResizer: returns the URL of src resized to width pixels in format
+// (file extension without the dot). It may produce the file itself or only
+// compute the URL of a variant generated elsewhere (CDN, build step).
+type ImageResizer func(src string, width int, format string) (string, error)
+
+func init() {
+	builtinFuncs["image"] = imageFunc
+}
+
+// defaultImageURL: "img/hero.jpg", 480, "webp" -> "img/hero-480w.webp".
+func defaultImageURL(src string, width int, format string) (string, error) {
+	base := strings.TrimSuffix(src, path.Ext(src))
+	return fmt.Sprintf("%s-%dw.%s", base, width, format), nil
+}
+
+func imageFunc(c *Call) (interface{}, error) {
+	src := argString(c.Arg(0))
+	if src == "" {
+		return nil, errors.New("missing image source")
+	}
+	widths, err := intList(c.Kwarg("widths", nil))
+	if err != nil {
+		return nil, fmt.Errorf("widths: %w", err)
+	}
+	var formats []string
+	if f := c.Kwarg("formats", nil); f != nil {
+		for _, v := range toList(f) {
+			formats = append(formats, strings.TrimPrefix(argString(v), "."))
+		}
+	}
+	resize := c.Engine().ResizeImage
+	if resize == nil {
+		resize = defaultImageURL
+	}
+	own := strings.TrimPrefix(path.Ext(src), ".")
+
+	srcset := func(format string) (string, error) {
+		parts := make([]string, 0, len(widths))
+		for _, w := range widths {
+			u, err := resize(src, w, format)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, fmt.Sprintf("%s %dw", u, w))
+		}
+		return strings.Join(parts, ", "), nil
+	}
+	sizes := argString(c.Kwarg("sizes", "100vw"))
+
+	b := &strings.Builder{}
+	if len(formats) > 0 {
+		b.WriteString("<picture>")
+		for _, f := range formats {
+			if f == own {
+				continue
+			}
+			set, err := srcset(f)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(b, `<source type="%s" srcset="%s" sizes="%s">`, imageMime(f), html.EscapeString(set), html.EscapeString(sizes))
+		}
+	}
+	fmt.Fprintf(b, `<img src="%s"`, html.EscapeString(src))
+	if len(widths) > 0 {
+		set, err := srcset(own)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(b, ` srcset="%s" sizes="%s"`, html.EscapeString(set), html.EscapeString(sizes))
+	}
+	writeAttrs(b, c.Kwargs, "widths", "formats", "sizes")
+	b.WriteString(">")
+	if len(formats) > 0 {
+		b.WriteString("</picture>")
+	}
+	return b.String(), nil
+}
+
+func imageMime(format string) string {
+	if t := mime.TypeByExtension("." + format); t != "" {
+		return t
+	}
+	return "image/" + format
+}
+
+// toList: slice/array argument as []interface{}; other values become a
+// one-element list, nil an empty one.
+func toList(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+func intList(v interface{}) ([]int, error) {
+	items := toList(v)
+	out := make([]int, 0, len(items))
+	for _, it := range items {
+		f, ok := toFloat(it)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a number", argString(it))
+		}
+		out = append(out, int(f))
+	}
+	sort.Ints(out)
+	return out, nil
+}
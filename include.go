@@ -0,0 +1,233 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// -------------------- Includes --------------------
+//
+//	<{ include "partials/header.vgo" title="Home" }>
+//
+// renders another template in place. The path is relative to the including
+// file. The included template sees the variables of the includer, keyword
+// arguments add variables of their own; list and map literals pass
+// structured values:
+//
+//	<{ include "partials/button.vgo" attrs={"class": "btn", "id": user.ID} sizes=[1, 2] }>
+//
+// The path can be an expression, for partials picked at render time:
+//
+//	<{ include "widgets/" + widget.Type + ".vgo" }>
+//
+// Such dynamic includes only load templates matching one of the
+// Engine.DynamicIncludes patterns.
+//
+// When Engine.Root is set, includes can't leave it: absolute paths and
+// paths escaping it with ../ fail the render, unless
+// Engine.AllowOutsideRoot is set.
+//
+// With Engine.InlineIncludes > 0, templates of at most that many bytes are
+// inlined into their includer when it is compiled, so header/footer
+// partials used on every page are not looked up on every render. A change
+// to an inlined file recompiles the templates that inlined it.
+
+// IncludeNode: <{ include }> tag.
+type IncludeNode struct {
+	Path string // as written in the tag, "" for a dynamic include
+
+	nodePos
+	path Expr // template name of a dynamic include
+	vars []kwarg
+	file string // resolved path, set when the includer is compiled
+	body []Node // inlined template; nil: looked up at render time
+}
+
+// maxIncludeDepth: include nesting allowed when Limits.MaxIncludeDepth is
+// not set. Templates including themselves are allowed (a menu including
+// itself for the children) as long as they stop below it; past it, an
+// include of a file already being rendered is reported as a cycle.
+const maxIncludeDepth = 100
+
+// enterInclude: pushes file, included by a tag of the template from, on
+// s.includes. Fails the render and returns false past the include depth
+// limit.
+func (s *renderState) enterInclude(from, file string) bool {
+	if len(s.includes) == 0 {
+		s.includer = from
+	}
+	if max := s.engine.Limits.MaxIncludeDepth; max > 0 {
+		if len(s.includes) >= max {
+			s.fail(&LimitError{Limit: "MaxIncludeDepth", Max: int64(max)})
+			return false
+		}
+	} else if len(s.includes) >= maxIncludeDepth {
+		files := append([]string{s.includer}, s.includes...)
+		chain := s.engine.includeChain(append(files, file))
+		if file == s.includer || slices.Contains(s.includes, file) {
+			s.fail(fmt.Errorf("vingo: include cycle: %s", chain))
+		} else {
+			s.fail(fmt.Errorf("vingo: includes nested deeper than %d: %s", maxIncludeDepth, chain))
+		}
+		return false
+	}
+	s.includes = append(s.includes, file)
+	return true
+}
+
+// leaveInclude: pops the include pushed by enterInclude.
+func (s *renderState) leaveInclude() {
+	s.includes = s.includes[:len(s.includes)-1]
+}
+
+func (n *IncludeNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if s.engine.sandboxed() && filepath.IsAbs(n.Path) {
+		s.fail(fmt.Errorf("vingo: include %q: absolute paths are not allowed with a template root", n.Path))
+		return
+	}
+	name, file := n.Path, n.file
+	if n.path != nil {
+		var err error
+		if name, file, err = n.dynamicFile(s, sc); err != nil {
+			s.fail(err)
+			return
+		}
+	} else if file == "" {
+		file = s.engine.resolve(n.Path)
+	}
+	if !s.enterInclude(n.template, file) {
+		return
+	}
+	defer s.leaveInclude()
+	body := n.body
+	if body == nil {
+		tpl, err := s.engine.load(file, s.locale, nil)
+		if err != nil {
+			s.fail(fmt.Errorf("vingo: include %q: %w", name, err))
+			return
+		}
+		body = tpl.Nodes
+	}
+	if len(n.vars) > 0 {
+		vars := make(map[string]interface{}, len(n.vars))
+		for _, kw := range n.vars {
+			vars[kw.name], _ = kw.val.eval(s, sc)
+		}
+		sc = sc.child(vars)
+	}
+	evalNodes(s, body, sc, out)
+}
+
+// dynamicFile: template name of a dynamic include and its resolved file,
+// checked against Engine.DynamicIncludes.
+func (n *IncludeNode) dynamicFile(s *renderState, sc *scope) (name, file string, err error) {
+	v, _ := n.path.eval(s, sc)
+	name, ok := v.(string)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("vingo: include: template name must be a non-empty string, got %v", v)
+	}
+	e := s.engine
+	file = e.resolve(name)
+	if n.template != "" && !filepath.IsAbs(name) {
+		file = filepath.Join(filepath.Dir(n.template), name)
+	}
+	if !e.dynamicIncludeAllowed(file) {
+		return "", "", fmt.Errorf("vingo: include %q: not allowed by Engine.DynamicIncludes", name)
+	}
+	return name, file, nil
+}
+
+// dynamicIncludeAllowed: reports whether file, relative to Root, matches
+// one of the DynamicIncludes patterns.
+func (e *Engine) dynamicIncludeAllowed(file string) bool {
+	rel, err := filepath.Rel(e.resolve(""), file)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	for _, pattern := range e.DynamicIncludes {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// includeChain: "page.vgo -> a.vgo -> b.vgo -> a.vgo", the files of chain
+// (the rendered template first) up to the first one included twice,
+// relative to Root.
+func (e *Engine) includeChain(chain []string) string {
+	files := chain
+	seen := map[string]bool{}
+	for i, f := range files {
+		if seen[f] {
+			files = files[:i+1]
+			break
+		}
+		seen[f] = true
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = e.relName(f)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// relName: path relative to Root for messages, path itself outside of it.
+func (e *Engine) relName(path string) string {
+	if rel, err := filepath.Rel(e.resolve(""), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// linkIncludes: resolves the includes of the template at path and inlines
+// the small ones, compiled for locale. stack holds the templates being compiled, includes of
+// those are never inlined. Returns the inlined files (transitively) with
+// their modification times.
+func (e *Engine) linkIncludes(path string, nodes []Node, locale string, stack []string) map[string]time.Time {
+	deps := map[string]time.Time{}
+	walkNodes(nodes, func(n Node) {
+		inc, ok := n.(*IncludeNode)
+		if !ok || inc.path != nil {
+			return
+		}
+		inc.file = inc.Path
+		if !filepath.IsAbs(inc.file) {
+			inc.file = filepath.Join(filepath.Dir(path), inc.file)
+		}
+		if e.InlineIncludes <= 0 || slices.Contains(stack, inc.file) || e.sandboxed() && filepath.IsAbs(inc.Path) {
+			return
+		}
+		child, err := e.load(inc.file, locale, stack)
+		if err != nil || child.size > e.InlineIncludes {
+			// errors are reported when the include is rendered
+			return
+		}
+		inc.body = child.Nodes
+		deps[inc.file] = child.ModTime
+		for f, mod := range child.deps {
+			deps[f] = mod
+		}
+	})
+	return deps
+}
+
+// depsFresh: reports whether no inlined file changed since compilation.
+func (t *Template) depsFresh(l Loader) bool {
+	for f, mod := range t.deps {
+		m, err := l.ModTime(f)
+		if err != nil || !m.Equal(mod) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package vingo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootSandbox(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "templates")
+	outside := filepath.Join(dir, "secret.vgo")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	e.Root = root
+	if _, err := e.Render("../secret.vgo", nil); err == nil {
+		t.Error("render ../secret.vgo: no error")
+	}
+	for _, src := range []string{`<{ include "../secret.vgo" }>`, `<{ include "` + filepath.ToSlash(outside) + `" }>`} {
+		if _, err := renderSource(e, src, nil); err == nil {
+			t.Errorf("render %q: no error", src)
+		}
+	}
+
+	e = New()
+	e.Root = root
+	e.AllowOutsideRoot = true
+	if got, err := renderSource(e, `<{ include "../secret.vgo" }>`, nil); err != nil || got != "secret" {
+		t.Errorf("render with AllowOutsideRoot = %q, %v; want %q", got, err, "secret")
+	}
+}
+
+func TestIncludeDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"page.vgo":  `<{ include "a.vgo" }>`,
+		"a.vgo":     `<{ include "b.vgo" }>`,
+		"b.vgo":     `<{ include "a.vgo" }>`,
+		"count.vgo": `.<{ if t.c }><{ include "count.vgo" t=t.c }><{ /if }>`,
+	})
+	// chain: data for count.vgo n levels deep
+	chain := func(n int) map[string]interface{} {
+		t := map[string]interface{}{"leaf": true}
+		for i := 1; i < n; i++ {
+			t = map[string]interface{}{"c": t}
+		}
+		return map[string]interface{}{"t": t}
+	}
+	e := New()
+	e.Root = dir
+	_, err := e.Render("page.vgo", nil)
+	if err == nil || !strings.Contains(err.Error(), "include cycle: page.vgo -> a.vgo -> b.vgo -> a.vgo") {
+		t.Errorf("render of an include cycle: %v", err)
+	}
+
+	// recursive partials stopping in time are not cycles
+	if got, err := e.Render("count.vgo", chain(50)); err != nil || got != strings.Repeat(".", 50) {
+		t.Errorf("recursive include = %q, %v", got, err)
+	}
+	if _, err := e.Render("count.vgo", chain(150)); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("recursive include past the default depth: %v, want an include cycle", err)
+	}
+
+	e.Limits.MaxIncludeDepth = 200
+	if got, err := e.Render("count.vgo", chain(150)); err != nil || got != strings.Repeat(".", 150) {
+		t.Errorf("recursive include with MaxIncludeDepth 200 = %q, %v", got, err)
+	}
+	var lerr *LimitError
+	if _, err := e.Render("page.vgo", nil); !errors.As(err, &lerr) || lerr.Limit != "MaxIncludeDepth" {
+		t.Errorf("render of an include cycle with MaxIncludeDepth: %v, want a LimitError", err)
+	}
+}
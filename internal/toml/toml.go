@@ -0,0 +1,525 @@
+// Package toml decodes TOML documents for vingo's data files: tables,
+// arrays of tables, dotted and quoted keys, all string forms, integers,
+// floats, booleans, arrays and inline tables. Offset date-times become
+// time.Time, local dates and times stay strings. Integers are int, floats
+// float64, like template literals.
+//
+// Documents TOML 1.0 rejects are rejected: tables defined twice, tables
+// extended after being defined by dotted keys or inline, arrays of tables
+// appended to a static array, malformed numbers and dates.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// kind: how a table or array came into being, which decides how it may be
+// extended.
+type kind int
+
+const (
+	implicit kind = iota // created as the parent of a [header]; may get its own header once
+	header               // defined by a [header]
+	dotted               // created by a dotted key; may only get sub-table headers
+	inline               // an inline table, complete as written
+	static               // an array value, not an array of tables
+	tables               // an array of tables
+)
+
+type parser struct {
+	src     string
+	pos     int
+	root    map[string]interface{}
+	cur     map[string]interface{} // table of the last [header]
+	curPath string
+	kinds   map[string]kind // tables and arrays by path, see path
+	inlines int             // inline tables parsed, for their paths
+}
+
+// path: key of a table or array in parser.kinds; elements of arrays of
+// tables are their index.
+func path(parent, key string) string {
+	return parent + "\x00" + key
+}
+
+// Decode: the document src as a map.
+func Decode(src string) (map[string]interface{}, error) {
+	p := &parser{src: src, root: map[string]interface{}{}, kinds: map[string]kind{}}
+	p.cur = p.root
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return p.root, nil
+		}
+		if err := p.statement(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", strings.Count(p.src[:p.pos], "\n")+1, err)
+		}
+	}
+}
+
+// skipSpace: skips spaces and tabs.
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank: skips whitespace, newlines and comments.
+func (p *parser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) accept(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// endOfLine: only a comment may follow a statement on its line.
+func (p *parser) endOfLine() error {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '#' {
+		for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.src) && !p.accept("\n") && !p.accept("\r\n") {
+		return fmt.Errorf("unexpected %q after value", p.src[p.pos])
+	}
+	return nil
+}
+
+func (p *parser) statement() error {
+	if p.accept("[") {
+		array := p.accept("[")
+		p.skipSpace()
+		keys, err := p.key()
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		closing := "]"
+		if array {
+			closing = "]]"
+		}
+		if !p.accept(closing) {
+			return fmt.Errorf("expected %q after table name", closing)
+		}
+		parent, at, err := p.table(p.root, "", keys[:len(keys)-1], implicit)
+		if err != nil {
+			return err
+		}
+		last := keys[len(keys)-1]
+		at = path(at, last)
+		if array {
+			arr, ok := parent[last].([]interface{})
+			if _, exists := parent[last]; exists && (!ok || p.kinds[at] != tables) {
+				return fmt.Errorf("key %q is not an array of tables", last)
+			}
+			p.kinds[at] = tables
+			p.cur, p.curPath = map[string]interface{}{}, path(at, strconv.Itoa(len(arr)))
+			parent[last] = append(arr, p.cur)
+			p.kinds[p.curPath] = header
+			return p.endOfLine()
+		}
+		switch v := parent[last].(type) {
+		case nil:
+			p.cur = map[string]interface{}{}
+			parent[last] = p.cur
+		case map[string]interface{}:
+			if p.kinds[at] != implicit {
+				return fmt.Errorf("table %q defined twice", strings.Join(keys, "."))
+			}
+			p.cur = v
+		default:
+			return fmt.Errorf("key %q is not a table", last)
+		}
+		p.kinds[at], p.curPath = header, at
+		return p.endOfLine()
+	}
+
+	if err := p.keyValue(p.cur, p.curPath); err != nil {
+		return err
+	}
+	return p.endOfLine()
+}
+
+// keyValue: key = value into m, the table at path at.
+func (p *parser) keyValue(m map[string]interface{}, at string) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.accept("=") {
+		return fmt.Errorf("expected '=' after key %q", strings.Join(keys, "."))
+	}
+	p.skipSpace()
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	tbl, at, err := p.table(m, at, keys[:len(keys)-1], dotted)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, dup := tbl[last]; dup {
+		return fmt.Errorf("duplicate key %q", last)
+	}
+	tbl[last] = v
+	switch v.(type) {
+	case map[string]interface{}:
+		p.kinds[path(at, last)] = inline
+	case []interface{}:
+		p.kinds[path(at, last)] = static
+	}
+	return nil
+}
+
+// table: the table at keys below m, the table at path at, and its path.
+// Missing tables are created as k; through an array of tables, keys go
+// into its last table. Dotted keys (k dotted) can't go into tables of
+// headers.
+func (p *parser) table(m map[string]interface{}, at string, keys []string, k kind) (map[string]interface{}, string, error) {
+	for _, key := range keys {
+		at = path(at, key)
+		switch v := m[key].(type) {
+		case nil:
+			next := map[string]interface{}{}
+			m[key] = next
+			m = next
+			p.kinds[at] = k
+		case map[string]interface{}:
+			switch p.kinds[at] {
+			case inline:
+				return nil, "", fmt.Errorf("inline table %q can't be extended", key)
+			case header, implicit:
+				if k == dotted {
+					return nil, "", fmt.Errorf("table %q defined twice", key)
+				}
+			}
+			m = v
+		case []interface{}:
+			if p.kinds[at] != tables || len(v) == 0 {
+				return nil, "", fmt.Errorf("key %q is not a table", key)
+			}
+			if k == dotted {
+				return nil, "", fmt.Errorf("array of tables %q can't be extended by a dotted key", key)
+			}
+			m = v[len(v)-1].(map[string]interface{})
+			at = path(at, strconv.Itoa(len(v)-1))
+		default:
+			return nil, "", fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return m, at, nil
+}
+
+// key: dotted key of bare and quoted parts.
+func (p *parser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var k string
+		switch {
+		case p.pos < len(p.src) && p.src[p.pos] == '"':
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		case p.pos < len(p.src) && p.src[p.pos] == '\'':
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && isBareKeyChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("expected a key")
+			}
+			k = p.src[start:p.pos]
+		}
+		keys = append(keys, k)
+		p.skipSpace()
+		if !p.accept(".") {
+			return keys, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *parser) value() (interface{}, error) {
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("missing value")
+	}
+	switch p.src[p.pos] {
+	case '"':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			return p.multilineString(`"""`)
+		}
+		return p.basicString()
+	case '\'':
+		if strings.HasPrefix(p.src[p.pos:], "'''") {
+			return p.multilineString("'''")
+		}
+		return p.literalString()
+	case '[':
+		p.pos++
+		arr := []interface{}{}
+		for {
+			p.skipBlank()
+			if p.accept("]") {
+				return arr, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+			p.skipBlank()
+			if !p.accept(",") {
+				p.skipBlank()
+				if !p.accept("]") {
+					return nil, fmt.Errorf("expected ',' or ']' in array")
+				}
+				return arr, nil
+			}
+		}
+	case '{':
+		p.pos++
+		m := map[string]interface{}{}
+		p.inlines++
+		at := "\x01" + strconv.Itoa(p.inlines)
+		p.skipSpace()
+		if p.accept("}") {
+			return m, nil
+		}
+		for {
+			p.skipSpace()
+			if err := p.keyValue(m, at); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			if p.accept("}") {
+				return m, nil
+			}
+			if !p.accept(",") {
+				return nil, fmt.Errorf("expected ',' or '}' in inline table")
+			}
+		}
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(",]}# \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	// "1979-05-27 07:32:00": date and time separated by a space
+	if p.pos+1 < len(p.src) && p.src[p.pos] == ' ' && p.pos-start == 10 && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+		p.pos++
+		for p.pos < len(p.src) && !strings.ContainsRune(",]}# \t\r\n", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	}
+	return scalar(p.src[start:p.pos])
+}
+
+var (
+	decimal = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	float   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+	based   = map[string]*regexp.Regexp{
+		"0x": regexp.MustCompile(`^0x[0-9a-fA-F](_?[0-9a-fA-F])*$`),
+		"0o": regexp.MustCompile(`^0o[0-7](_?[0-7])*$`),
+		"0b": regexp.MustCompile(`^0b[01](_?[01])*$`),
+	}
+)
+
+// localLayouts: local date-times, dates and times, which stay strings.
+var localLayouts = []string{"2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02", "15:04:05.999999999"}
+
+// scalar: boolean, number or date-time.
+func scalar(s string) (interface{}, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+	n := strings.ReplaceAll(s, "_", "")
+	if re, ok := based[s[:min(2, len(s))]]; ok {
+		i, err := strconv.ParseInt(n[2:], map[byte]int{'x': 16, 'o': 8, 'b': 2}[s[1]], 64)
+		if err != nil || !re.MatchString(s) {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return int(i), nil
+	}
+	if decimal.MatchString(s) {
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return int(i), nil
+	}
+	if float.MatchString(s) {
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", s)
+		}
+		return f, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, strings.Replace(s, " ", "T", 1)); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid value %q", s)
+}
+
+func (p *parser) literalString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *parser) basicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string")
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// multilineString: triple-quoted basic or literal string; a newline right
+// after the opening quotes is dropped.
+func (p *parser) multilineString(delim string) (string, error) {
+	p.pos += 3
+	if !p.accept("\n") {
+		p.accept("\r\n")
+	}
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		if strings.HasPrefix(p.src[p.pos:], delim) {
+			p.pos += 3
+			// up to two quotes right before the closing ones belong to the string
+			for i := 0; i < 2 && strings.HasPrefix(p.src[p.pos:], delim[:1]); i++ {
+				b.WriteByte(delim[0])
+				p.pos++
+			}
+			return b.String(), nil
+		}
+		c := p.src[p.pos]
+		if c == '\\' && delim == `"""` {
+			// line ending backslash: trims the newline and leading whitespace
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// escape: the escape sequence at p.pos (a backslash) into b.
+func (p *parser) escape(b *strings.Builder) error {
+	if p.pos+1 >= len(p.src) {
+		return fmt.Errorf("unterminated string")
+	}
+	c := p.src[p.pos+1]
+	p.pos += 2
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return fmt.Errorf("invalid escape \\%c", c)
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return fmt.Errorf("invalid escape \\%c%s", c, p.src[p.pos:p.pos+n])
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package toml
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type m = map[string]interface{}
+type a = []interface{}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name, src string
+		want      m
+	}{
+		{"empty", "", m{}},
+		{"comments", "# c\n\na = 1 # c\n", m{"a": 1}},
+		{"strings", `a = "x\ty\u00e7"` + "\nb = 'C:\\path'\n" + `c = """
+one \
+  two"""` + "\nd = '''\nraw\\n'''", m{"a": "x\tyç", "b": `C:\path`, "c": "one two", "d": `raw\n`}},
+		{"quotes before closing", `a = """x"""""`, m{"a": `x""`}},
+		{"numbers", "a = 1_000\nb = -17\nc = 0xff\nd = 0o17\ne = 0b101\nf = 3.14\ng = 1e3\nh = -2.5E-3\ni = 0", m{"a": 1000, "b": -17, "c": 255, "d": 15, "e": 5, "f": 3.14, "g": 1000.0, "h": -0.0025, "i": 0}},
+		{"inf", "a = inf\nb = -inf", m{"a": math.Inf(1), "b": math.Inf(-1)}},
+		{"booleans", "a = true\nb = false", m{"a": true, "b": false}},
+		{"dates", "a = 1979-05-27T07:32:00Z\nb = 1979-05-27\nc = 07:32:00\nd = 1979-05-27 07:32:00", m{
+			"a": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC), "b": "1979-05-27", "c": "07:32:00", "d": "1979-05-27 07:32:00",
+		}},
+		{"arrays", "a = [1, [2, 3], \"x\",]\nb = [\n  1, # one\n  2\n]", m{"a": a{1, a{2, 3}, "x"}, "b": a{1, 2}}},
+		{"inline table", `a = { x = 1, y.z = "q" }`, m{"a": m{"x": 1, "y": m{"z": "q"}}}},
+		{"dotted and quoted keys", `a.b = 1` + "\n" + `a."c.d" = 2` + "\n'e' = 3", m{"a": m{"b": 1, "c.d": 2}, "e": 3}},
+		{"tables", "[a]\nx = 1\n[a.b]\ny = 2\n[c . d]\nz = 3", m{"a": m{"x": 1, "b": m{"y": 2}}, "c": m{"d": m{"z": 3}}}},
+		{"super table after sub table", "[a.b]\ny = 2\n[a]\nx = 1", m{"a": m{"x": 1, "b": m{"y": 2}}}},
+		{"sub table of dotted table", "[f]\napple.color = 1\n[f.apple.texture]\nsmooth = true", m{"f": m{"apple": m{"color": 1, "texture": m{"smooth": true}}}}},
+		{"arrays of tables", "[[p]]\nn = 1\n[[p]]\nn = 2\n[p.sub]\nx = 3", m{"p": a{m{"n": 1}, m{"n": 2, "sub": m{"x": 3}}}}},
+		{"crlf", "a = 1\r\n[b]\r\nc = 2\r\n", m{"a": 1, "b": m{"c": 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct{ name, src string }{
+		{"duplicate table", "[t]\na = 1\n[t]\nb = 2"},
+		{"duplicate key", "a = 1\na = 2"},
+		{"duplicate dotted key", "a.b = 1\na.b = 2"},
+		{"table over value", "a = 1\n[a]"},
+		{"header over dotted table", "[f]\napple.color = 1\n[f.apple]"},
+		{"dotted key into table", "[a.b]\nx = 1\n[a]\nb.y = 2"},
+		{"extend inline table by header", "a = { x = 1 }\n[a]\ny = 2"},
+		{"extend inline table by key", "a = { x = 1 }\na.y = 2"},
+		{"array of tables over static array", "a = [{ x = 1 }]\n[[a]]"},
+		{"array of tables over table", "[a]\n[[a]]"},
+		{"table over array of tables", "[[a]]\n[a]"},
+		{"missing value", "a ="},
+		{"missing equals", "a 1"},
+		{"two values on a line", "a = 1 b = 2"},
+		{"unterminated string", `a = "x`},
+		{"newline in string", "a = \"x\ny\""},
+		{"bad escape", `a = "\q"`},
+		{"leading zero", "a = 007"},
+		{"double underscore", "a = 1__0"},
+		{"trailing underscore", "a = 1_"},
+		{"bare dot float", "a = .5"},
+		{"trailing dot float", "a = 1."},
+		{"infinity word", "a = infinity"},
+		{"hex float", "a = 0x1p3"},
+		{"bad hex", "a = 0xfg"},
+		{"bad date", "a = 2024-13-45"},
+		{"bad local value", "a = 2024-xx-yy"},
+		{"inline table trailing comma", "a = { x = 1, }"},
+		{"inline table newline", "a = {\nx = 1 }"},
+		{"unclosed array", "a = [1, 2"},
+		{"unclosed header", "[a\nb = 1"},
+		{"empty key", "= 1"},
+		{"bare word", "a = yes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decode(tt.src); err == nil {
+				t.Errorf("Decode(%q) = %#v, want an error", tt.src, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package vingo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// -------------------- JSON --------------------
+//
+//	<script>window.__DATA__ = <{ payload | json }>;</script>
+//	<script type="application/json" id="state"><{ state | json }></script>
+//	<pre><{ config | json:2 }></pre>                   indented, 2 spaces
+//
+// marshals the value with encoding/json. <, > and & are written as \u003c,
+// \u003e and \u0026 (U+2028 and U+2029 are escaped too), so the output can't
+// close a <script> element or open an HTML comment inside it and is a valid
+// JavaScript expression: data from users can be embedded for hydration.
+// The result is not HTML-escaped; inside attributes use json | escape.
+
+func init() {
+	builtinFuncs["json"] = jsonFunc
+}
+
+func jsonFunc(c *Call) (interface{}, error) {
+	indent := ""
+	switch v := c.Arg(1).(type) {
+	case nil:
+	case string:
+		indent = v
+	default:
+		n, ok := toFloat(v)
+		if !ok || n < 0 || n > 16 {
+			return nil, fmt.Errorf("indent must be a string or 0-16 spaces, got %v", v)
+		}
+		indent = strings.Repeat(" ", int(n))
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(c.Arg(0)); err != nil {
+		return nil, err
+	}
+	// Encode escapes <, > and & (SetEscapeHTML defaults to true)
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,67 @@
+package vingo
+
+import (
+	"fmt"
+	"time"
+)
+
+// -------------------- Resource limits --------------------
+
+// Limits: bounds of a single render, for templates fed untrusted data or
+// written by tenants. Zero fields mean no limit. A render exceeding a limit
+// stops and returns a *LimitError.
+type Limits struct {
+	MaxOutputBytes  int           // size of the rendered output
+	MaxIterations   int           // loop iterations, all loops together
+	MaxIncludeDepth int           // nesting of includes
+	MaxRenderTime   time.Duration // checked between nodes and loop iterations
+
+	// MaxOperations: approximate CPU budget, counting node evaluations,
+	// operators and function calls. Unlike MaxRenderTime it does not
+	// depend on how busy the machine is, so tenants sharing a process get
+	// the same budget under load.
+	MaxOperations int
+}
+
+// LimitError: a render exceeded one of the Engine.Limits.
+type LimitError struct {
+	Limit string // name of the Limits field
+	Max   int64  // its value
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "MaxRenderTime" {
+		return fmt.Sprintf("vingo: render exceeded %s (%v)", e.Limit, time.Duration(e.Max))
+	}
+	return fmt.Sprintf("vingo: render exceeded %s (%d)", e.Limit, e.Max)
+}
+
+// iterate: counts one loop iteration, false once MaxIterations is exceeded.
+func (s *renderState) iterate() bool {
+	s.iterations++
+	if max := s.engine.Limits.MaxIterations; max > 0 && s.iterations > max {
+		s.fail(&LimitError{Limit: "MaxIterations", Max: int64(max)})
+		return false
+	}
+	return true
+}
+
+// op: counts one operation against Limits.MaxOperations. s is nil when
+// constants are folded at compile time.
+func (s *renderState) op() {
+	if s == nil {
+		return
+	}
+	s.ops++
+	if max := s.engine.Limits.MaxOperations; max > 0 && s.ops > max {
+		s.fail(&LimitError{Limit: "MaxOperations", Max: int64(max)})
+	}
+}
+
+// checkOutput: fails the render once n, the output written so far, is over
+// MaxOutputBytes.
+func (s *renderState) checkOutput(n int) {
+	if max := s.engine.Limits.MaxOutputBytes; max > 0 && n > max {
+		s.fail(&LimitError{Limit: "MaxOutputBytes", Max: int64(max)})
+	}
+}
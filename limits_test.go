@@ -0,0 +1,42 @@
+package vingo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	tests := []struct {
+		name   string
+		limits Limits
+		src    string
+		limit  string // Limit of the expected *LimitError, "" = no error
+	}{
+		{"output", Limits{MaxOutputBytes: 20}, `<{ for i in items }>x<{ /for }>`, "MaxOutputBytes"},
+		{"output in block", Limits{MaxOutputBytes: 20}, `<{ block "b" }><{ for i in items }>x<{ /for }><{ /block }>`, "MaxOutputBytes"},
+		{"output in section", Limits{MaxOutputBytes: 20}, `<{ section "s" }><{ for i in items }>x<{ /for }><{ /section }>`, "MaxOutputBytes"},
+		{"output copied once", Limits{MaxOutputBytes: 50}, `<{ block "a" }><{ block "b" }><{ for i in items }>x<{ /for }><{ /block }><{ /block }>`, ""},
+		{"iterations", Limits{MaxIterations: 10}, `<{ for i in items }><{ /for }>`, "MaxIterations"},
+		{"iterations of where", Limits{MaxIterations: 10}, `<{ for i in items where i > 100 }><{ i }><{ /for }>`, "MaxIterations"},
+		{"iterations of sort", Limits{MaxIterations: 60}, `<{ for i in items sort by i desc }><{ /for }>`, "MaxIterations"},
+		{"iterations within", Limits{MaxIterations: 60}, `<{ for i in items where i < 5 }><{ /for }>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.Limits = tt.limits
+			_, err := renderSource(e, tt.src, map[string]interface{}{"items": items})
+			var le *LimitError
+			switch {
+			case tt.limit == "" && err != nil:
+				t.Fatalf("render %q: %v", tt.src, err)
+			case tt.limit != "" && (!errors.As(err, &le) || le.Limit != tt.limit):
+				t.Fatalf("render %q: error %v, want %s exceeded", tt.src, err, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package vingo
+
+import (
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// -------------------- Link checker --------------------
+
+// BrokenLink: an internal href/src in a generated page that resolves to
+// neither a generated page nor an asset.
+type BrokenLink struct {
+	File string // generated file, relative to the checked directory
+	Line int    // 1-based line of the attribute in File
+	Href string // attribute value as written
+}
+
+var (
+	linkAttrRe = regexp.MustCompile(`(?i)\b(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	schemeRe   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// CheckLinks: scans every .html/.htm file under dir (usually the output of a
+// static build) and reports internal links that don't resolve. Absolute
+// links ("/blog/") are resolved against dir, relative ones against the page.
+// "/blog/" matches blog/index.html and "/about" also matches about.html.
+func CheckLinks(dir string) ([]BrokenLink, error) {
+	var broken []BrokenLink
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if d.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		content := string(b)
+		for _, m := range linkAttrRe.FindAllStringSubmatchIndex(content, -1) {
+			start, end := m[2], m[3]
+			if start < 0 {
+				start, end = m[4], m[5]
+			}
+			href := content[start:end]
+			target, ok := internalTarget(rel, href)
+			if !ok || linkExists(dir, target) {
+				continue
+			}
+			broken = append(broken, BrokenLink{
+				File: rel,
+				Line: strings.Count(content[:start], "\n") + 1,
+				Href: href,
+			})
+		}
+		return nil
+	})
+	return broken, err
+}
+
+// internalTarget: slash separated path (relative to the site root) an href
+// of page points to; ok is false for external, fragment-only or empty links.
+func internalTarget(page, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "//") || schemeRe.MatchString(href) {
+		return "", false
+	}
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	if unq, err := url.PathUnescape(href); err == nil {
+		href = unq
+	}
+	if href == "" {
+		return "", false
+	}
+	if strings.HasPrefix(href, "/") {
+		return path.Clean(href), true
+	}
+	return path.Clean("/" + path.Join(path.Dir(page), href)), true
+}
+
+func linkExists(dir, target string) bool {
+	p := filepath.Join(dir, filepath.FromSlash(target))
+	st, err := os.Stat(p)
+	if err == nil {
+		if !st.IsDir() {
+			return true
+		}
+		_, err = os.Stat(filepath.Join(p, "index.html"))
+		return err == nil
+	}
+	if filepath.Ext(p) == "" {
+		_, err = os.Stat(p + ".html")
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package vingo
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// -------------------- Loaders --------------------
+
+// Loader: reads template sources, by the resolved path of the template.
+// Compiled templates are cached per loader and path, so a Loader must be
+// comparable (a pointer or a struct of comparable fields).
+type Loader interface {
+	// ModTime: modification time of path, compared to decide recompiles.
+	ModTime(path string) (time.Time, error)
+	// ReadFile: source of the template at path.
+	ReadFile(path string) ([]byte, error)
+}
+
+// FileLoader: reads templates from disk; the default Loader.
+type FileLoader struct{}
+
+func (FileLoader) ModTime(path string) (time.Time, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return stat.ModTime(), nil
+}
+
+func (FileLoader) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// loader: Engine.Loader or a FileLoader.
+func (e *Engine) loader() Loader {
+	if e.Loader != nil {
+		return e.Loader
+	}
+	return FileLoader{}
+}
+
+// FrontMatterLoader: wraps Loader (nil = FileLoader) and strips front
+// matter (see SplitFrontMatter) from the templates it reads, for sites whose
+// pages carry their data. Line numbers in errors count from the body.
+type FrontMatterLoader struct {
+	Loader Loader
+}
+
+func (l FrontMatterLoader) ModTime(path string) (time.Time, error) {
+	return l.loader().ModTime(path)
+}
+
+func (l FrontMatterLoader) ReadFile(path string) ([]byte, error) {
+	b, err := l.loader().ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := SplitFrontMatter(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return body, nil
+}
+
+func (l FrontMatterLoader) loader() Loader {
+	if l.Loader != nil {
+		return l.Loader
+	}
+	return FileLoader{}
+}
@@ -0,0 +1,687 @@
+package vingo
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// -------------------- Markdown --------------------
+//
+//	<{ post.Body | markdown }>
+//	<{ include "posts/hello.md" }>
+//
+// converts markdown to HTML with Engine.Markdown. Templates with a .md or
+// .markdown extension are not parsed as templates: rendering or including
+// one renders its markdown (once, when it is compiled), so blog and docs
+// content stays in markdown while the layouts around it are vingo.
+//
+// The default renderer, BasicMarkdown, covers the common CommonMark
+// blocks and inlines and is safe for user content: raw HTML is escaped and
+// links to schemes other than http, https, mailto and tel are dropped. Plug
+// in a complete implementation (goldmark...) with MarkdownFunc.
+
+// MarkdownRenderer: converts markdown source to HTML.
+type MarkdownRenderer interface {
+	RenderMarkdown(src []byte) ([]byte, error)
+}
+
+// MarkdownFunc: MarkdownRenderer calling f.
+type MarkdownFunc func(src []byte) ([]byte, error)
+
+func (f MarkdownFunc) RenderMarkdown(src []byte) ([]byte, error) {
+	return f(src)
+}
+
+func init() {
+	builtinFuncs["markdown"] = func(c *Call) (interface{}, error) {
+		out, err := c.Engine().markdown().RenderMarkdown([]byte(argString(c.Arg(0))))
+		if err != nil {
+			return nil, err
+		}
+		return string(out), nil
+	}
+}
+
+// markdown: Engine.Markdown or BasicMarkdown.
+func (e *Engine) markdown() MarkdownRenderer {
+	if e.Markdown != nil {
+		return e.Markdown
+	}
+	return BasicMarkdown{}
+}
+
+// isMarkdown: reports whether path is a markdown file, rendered instead of
+// compiled.
+func isMarkdown(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// BasicMarkdown: the default MarkdownRenderer. Supports ATX and setext
+// headings, paragraphs, fenced and indented code, block quotes, nested
+// lists, thematic breaks, emphasis, code spans, links, images, autolinks
+// and hard line breaks. Raw HTML is escaped.
+type BasicMarkdown struct{}
+
+func (BasicMarkdown) RenderMarkdown(src []byte) ([]byte, error) {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = expandTabs(l)
+	}
+	var b strings.Builder
+	mdBlocks(&b, lines, false)
+	return []byte(b.String()), nil
+}
+
+// expandTabs: leading tabs of l as spaces, up to the next multiple of 4.
+func expandTabs(l string) string {
+	if !strings.Contains(l, "\t") {
+		return l
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(l); i++ {
+		switch l[i] {
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case ' ':
+			b.WriteByte(' ')
+			col++
+		default:
+			b.WriteString(l[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+func isBlank(l string) bool {
+	return strings.TrimSpace(l) == ""
+}
+
+func leadingSpaces(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+// mdBlocks: renders the block structure of lines. Paragraphs of tight list
+// items are written without <p>.
+func mdBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			i++
+			continue
+		}
+		indent := leadingSpaces(line)
+		rest := line[indent:]
+
+		if indent >= 4 {
+			// indented code, blank lines inside kept
+			var code []string
+			for ; i < len(lines) && (isBlank(lines[i]) || leadingSpaces(lines[i]) >= 4); i++ {
+				if len(lines[i]) >= 4 {
+					code = append(code, lines[i][4:])
+				} else {
+					code = append(code, "")
+				}
+			}
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "\n</code></pre>\n")
+			continue
+		}
+		if fence, info, ok := mdFence(rest); ok {
+			i++
+			var code []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if t := strings.TrimSpace(l); leadingSpaces(l) < 4 && strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+					i++
+					break
+				}
+				// content is unindented by the indentation of the fence
+				code = append(code, l[min(indent, leadingSpaces(l)):])
+			}
+			b.WriteString("<pre><code")
+			if lang, _, _ := strings.Cut(info, " "); lang != "" {
+				b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+			}
+			b.WriteString(">")
+			if len(code) > 0 {
+				b.WriteString(html.EscapeString(strings.Join(code, "\n")) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+			continue
+		}
+		if level, text, ok := mdATXHeading(rest); ok {
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, mdInline(text), level)
+			i++
+			continue
+		}
+		if mdThematicBreak(rest) {
+			b.WriteString("<hr>\n")
+			i++
+			continue
+		}
+		if rest[0] == '>' {
+			var quoted []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				t := strings.TrimLeft(l, " ")
+				switch {
+				case leadingSpaces(l) < 4 && strings.HasPrefix(t, ">"):
+					t = t[1:]
+					if strings.HasPrefix(t, " ") {
+						t = t[1:]
+					}
+					quoted = append(quoted, t)
+					continue
+				case !isBlank(l) && len(quoted) > 0 && !isBlank(quoted[len(quoted)-1]) && !mdStartsBlock(l):
+					// lazy continuation of a quoted paragraph
+					quoted = append(quoted, l)
+					continue
+				}
+				break
+			}
+			b.WriteString("<blockquote>\n")
+			mdBlocks(b, quoted, false)
+			b.WriteString("</blockquote>\n")
+			continue
+		}
+		if m, ok := mdListMarker(line); ok {
+			i = mdList(b, lines, i, m)
+			continue
+		}
+
+		// paragraph, ended by a blank line or another block
+		para := []string{rest}
+		i++
+		level := 0
+		for ; i < len(lines); i++ {
+			l := lines[i]
+			if isBlank(l) {
+				break
+			}
+			if t := strings.TrimSpace(l); leadingSpaces(l) < 4 && t != "" && strings.Trim(t, "=") == "" {
+				level = 1
+			} else if leadingSpaces(l) < 4 && t != "" && strings.Trim(t, "-") == "" {
+				level = 2
+			}
+			if level > 0 {
+				i++
+				break
+			}
+			if mdStartsBlock(l) {
+				break
+			}
+			para = append(para, strings.TrimLeft(l, " "))
+		}
+		text := mdInline(strings.TrimRight(strings.Join(para, "\n"), " "))
+		switch {
+		case level > 0:
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, text, level)
+		case tight:
+			b.WriteString(text + "\n")
+		default:
+			b.WriteString("<p>" + text + "</p>\n")
+		}
+	}
+}
+
+// mdStartsBlock: reports whether l starts a block that interrupts a
+// paragraph.
+func mdStartsBlock(l string) bool {
+	indent := leadingSpaces(l)
+	if indent >= 4 {
+		return false
+	}
+	rest := l[indent:]
+	if _, _, ok := mdFence(rest); ok {
+		return true
+	}
+	if _, _, ok := mdATXHeading(rest); ok {
+		return true
+	}
+	if mdThematicBreak(rest) || strings.HasPrefix(rest, ">") {
+		return true
+	}
+	m, ok := mdListMarker(l)
+	// an ordered list interrupts a paragraph only when it starts at 1
+	return ok && !m.empty && (!m.ordered || m.start == 1)
+}
+
+// mdFence: the opening fence (``` or ~~~, 3 or more) and info string of rest.
+func mdFence(rest string) (fence, info string, ok bool) {
+	if !strings.HasPrefix(rest, "```") && !strings.HasPrefix(rest, "~~~") {
+		return "", "", false
+	}
+	n := len(rest) - len(strings.TrimLeft(rest, rest[:1]))
+	info = strings.TrimSpace(rest[n:])
+	if rest[0] == '`' && strings.Contains(info, "`") {
+		return "", "", false
+	}
+	return rest[:n], info, true
+}
+
+func mdATXHeading(rest string) (level int, text string, ok bool) {
+	level = len(rest) - len(strings.TrimLeft(rest, "#"))
+	if level == 0 || level > 6 || (len(rest) > level && rest[level] != ' ') {
+		return 0, "", false
+	}
+	text = strings.TrimSpace(rest[level:])
+	// closing sequence: " ##"
+	if t := strings.TrimRight(text, "#"); t == "" || strings.HasSuffix(t, " ") {
+		text = strings.TrimSpace(t)
+	}
+	return level, text, true
+}
+
+func mdThematicBreak(rest string) bool {
+	t := strings.ReplaceAll(strings.TrimSpace(rest), " ", "")
+	if len(t) < 3 {
+		return false
+	}
+	c := t[0]
+	return (c == '-' || c == '*' || c == '_') && strings.Trim(t, string(c)) == ""
+}
+
+// mdMarker: list item marker at the start of a line.
+type mdMarker struct {
+	ordered bool
+	start   int
+	delim   byte // bullet character, or . / ) of an ordered list
+	offset  int  // column of the item content
+	empty   bool // nothing after the marker
+}
+
+func mdListMarker(l string) (mdMarker, bool) {
+	indent := leadingSpaces(l)
+	if indent >= 4 {
+		return mdMarker{}, false
+	}
+	rest := l[indent:]
+	var m mdMarker
+	n := 0
+	switch {
+	case rest != "" && strings.ContainsRune("-*+", rune(rest[0])):
+		m.delim, n = rest[0], 1
+	default:
+		for n < len(rest) && n < 9 && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(rest) || (rest[n] != '.' && rest[n] != ')') {
+			return mdMarker{}, false
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(rest[:n])
+		m.delim = rest[n]
+		n++
+	}
+	after := rest[n:]
+	if after == "" || isBlank(after) {
+		m.empty = true
+		m.offset = indent + n + 1
+		return m, true
+	}
+	if after[0] != ' ' {
+		return mdMarker{}, false
+	}
+	spaces := leadingSpaces(after)
+	if spaces > 4 {
+		// indented code in the item: the content starts after one space
+		spaces = 1
+	}
+	m.offset = indent + n + spaces
+	return m, true
+}
+
+// mdSibling: reports whether l starts an item of the list of first.
+func mdSibling(l string, first mdMarker) bool {
+	m, ok := mdListMarker(l)
+	return ok && m.ordered == first.ordered && m.delim == first.delim && !mdThematicBreak(l)
+}
+
+// mdList: renders the list starting at lines[i], returns the index of the
+// line after it.
+func mdList(b *strings.Builder, lines []string, i int, first mdMarker) int {
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		if !mdSibling(lines[i], first) {
+			break
+		}
+		m, _ := mdListMarker(lines[i])
+		item := []string{""}
+		if !m.empty {
+			item[0] = lines[i][m.offset:]
+		}
+		i++
+		for i < len(lines) {
+			l := lines[i]
+			switch {
+			case isBlank(l):
+				item = append(item, "")
+			case leadingSpaces(l) >= m.offset:
+				item = append(item, l[m.offset:])
+			case mdSibling(l, first):
+				goto end
+			case !isBlank(item[len(item)-1]) && !mdStartsBlock(l):
+				// lazy continuation line
+				item = append(item, strings.TrimLeft(l, " "))
+			default:
+				goto end
+			}
+			i++
+		}
+	end:
+		trailing := 0
+		for len(item) > 1 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+			trailing++
+		}
+		for _, l := range item[1:] {
+			if isBlank(l) {
+				loose = true
+			}
+		}
+		items = append(items, item)
+		if trailing > 0 && i < len(lines) && mdSibling(lines[i], first) {
+			loose = true
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		fmt.Fprintf(b, ` start="%d"`, first.start)
+	}
+	b.WriteString(">\n")
+	for _, item := range items {
+		var inner strings.Builder
+		mdBlocks(&inner, item, !loose)
+		content := inner.String()
+		if !loose {
+			content = strings.TrimSuffix(content, "\n")
+		} else if content != "" {
+			content = "\n" + content
+		}
+		b.WriteString("<li>" + content + "</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// -------------------- Markdown inlines --------------------
+
+// mdInline: HTML of the inline content s.
+func mdInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			out := strings.TrimRight(b.String(), " ")
+			b.Reset()
+			b.WriteString(out + "<br>\n")
+			i += 2
+			continue
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '\n':
+			// two trailing spaces: hard break
+			out := b.String()
+			if strings.HasSuffix(out, "  ") {
+				b.Reset()
+				b.WriteString(strings.TrimRight(out, " ") + "<br>")
+			} else if strings.HasSuffix(out, " ") {
+				b.Reset()
+				b.WriteString(strings.TrimRight(out, " "))
+			}
+			b.WriteByte('\n')
+			i++
+			continue
+		case c == '`':
+			n := mdRun(s, i)
+			if end := mdCodeSpanEnd(s, i+n, n); end >= 0 {
+				code := strings.ReplaceAll(s[i+n:end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + n
+				continue
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if text, dest, title, end, ok := mdLink(s, i+1); ok {
+				alt := mdPlain(text)
+				if mdSafeURL(dest) {
+					b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(alt) + `"`)
+					if title != "" {
+						b.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					b.WriteString(">")
+				} else {
+					b.WriteString(html.EscapeString(alt))
+				}
+				i = end
+				continue
+			}
+		case c == '[':
+			if text, dest, title, end, ok := mdLink(s, i); ok {
+				if mdSafeURL(dest) {
+					b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+					if title != "" {
+						b.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					b.WriteString(">" + mdInline(text) + "</a>")
+				} else {
+					b.WriteString(mdInline(text))
+				}
+				i = end
+				continue
+			}
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 {
+				u := s[i+1 : i+end]
+				if !strings.ContainsAny(u, " <\n") && (strings.Contains(u, "://") && mdSafeURL(u) || strings.HasPrefix(strings.ToLower(u), "mailto:")) {
+					b.WriteString(`<a href="` + html.EscapeString(u) + `">` + html.EscapeString(u) + "</a>")
+					i += end + 1
+					continue
+				}
+			}
+		case c == '*' || c == '_':
+			if out, end, ok := mdEmphasis(s, i); ok {
+				b.WriteString(out)
+				i = end
+				continue
+			}
+			n := mdRun(s, i)
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// mdCodeSpanEnd: index of the closing backtick run of exactly n backticks
+// at or after i, -1 if there is none.
+func mdCodeSpanEnd(s string, i, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		j += i
+		k := j
+		for k < len(s) && s[k] == '`' {
+			k++
+		}
+		if k-j == n {
+			return j
+		}
+		i = k
+	}
+	return -1
+}
+
+// mdLink: [text](dest "title") starting at s[i] == '['; end is the index
+// after it.
+func mdLink(s string, i int) (text, dest, title string, end int, ok bool) {
+	depth := 0
+	j := i
+	for ; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if j >= len(s) || j+1 >= len(s) || s[j+1] != '(' {
+		return "", "", "", 0, false
+	}
+	text = s[i+1 : j]
+	k := j + 2
+	rest := s[k:]
+	// closing parenthesis, those in the destination must be balanced
+	close, depth := -1, 1
+	for n := 0; n < len(rest) && close < 0; n++ {
+		switch rest[n] {
+		case '\\':
+			n++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				close = n
+			}
+		}
+	}
+	if close < 0 {
+		return "", "", "", 0, false
+	}
+	inner := strings.TrimSpace(rest[:close])
+	if strings.HasPrefix(inner, "<") {
+		if gt := strings.IndexByte(inner, '>'); gt > 0 {
+			dest, inner = inner[1:gt], strings.TrimSpace(inner[gt+1:])
+		}
+	} else {
+		dest, inner, _ = strings.Cut(inner, " ")
+		inner = strings.TrimSpace(inner)
+	}
+	if inner != "" {
+		q := inner[0]
+		if len(inner) < 2 || (q != '"' && q != '\'') || inner[len(inner)-1] != q {
+			return "", "", "", 0, false
+		}
+		title = inner[1 : len(inner)-1]
+	}
+	return text, dest, title, k + close + 1, true
+}
+
+// mdEmphasis: *em*, **strong**, ***both*** (or with _) starting at s[i];
+// end is the index after the closing delimiter. A closing run must have
+// the length of the opening one; extra opening delimiters stay literal.
+func mdEmphasis(s string, i int) (out string, end int, ok bool) {
+	c := s[i]
+	n := mdRun(s, i)
+	if i+n >= len(s) || s[i+n] == ' ' || s[i+n] == '\n' {
+		return "", 0, false
+	}
+	// _ doesn't open inside a word
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, false
+	}
+	for _, size := range []int{3, 2, 1} {
+		if n < size {
+			continue
+		}
+		start := i + n // content
+		for j := start + 1; j < len(s); j++ {
+			if s[j] == '`' {
+				// don't close inside a code span
+				r := mdRun(s, j)
+				if k := mdCodeSpanEnd(s, j+r, r); k >= 0 {
+					j = k + r - 1
+				} else {
+					j += r - 1
+				}
+				continue
+			}
+			if s[j] != c {
+				continue
+			}
+			r := mdRun(s, j)
+			if r != size || s[j-1] == ' ' || s[j-1] == '\n' || s[j-1] == '\\' || c == '_' && j+r < len(s) && isWordByte(s[j+r]) {
+				j += r - 1
+				continue
+			}
+			inner := mdInline(s[start:j])
+			switch size {
+			case 1:
+				inner = "<em>" + inner + "</em>"
+			case 2:
+				inner = "<strong>" + inner + "</strong>"
+			case 3:
+				inner = "<em><strong>" + inner + "</strong></em>"
+			}
+			return s[i:i+n-size] + inner, j + r, true
+		}
+	}
+	return "", 0, false
+}
+
+// mdRun: length of the run of s[i] starting at i.
+func mdRun(s string, i int) int {
+	return len(s[i:]) - len(strings.TrimLeft(s[i:], s[i:i+1]))
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// mdPlain: s without markup, for alt texts.
+func mdPlain(s string) string {
+	r := strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "")
+	return r.Replace(s)
+}
+
+// mdSafeURL: reports whether u is relative or has an allowed scheme.
+func mdSafeURL(u string) bool {
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(u[:i]) {
+	case "http", "https", "mailto", "tel":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package vingo
+
+import (
+	"fmt"
+	"math"
+)
+
+// -------------------- Math helpers --------------------
+//
+//	<{ round(rating, 1) }>, <{ price | ceil }>, <{ abs(delta) }>
+//	<{ min(stock, 10) }>, <{ max(scores) }>
+//	<{ items | pluck:"Total" | sum }>, <{ avg(ratings) }>
+//
+// min and max take several values or one list and return the smallest or
+// largest of them, compared like sort does (numbers, strings, times);
+// undefined values are skipped. sum adds a list of numbers, integers stay
+// integers; avg is their mean, undefined for an empty list. round takes
+// the number of decimals (0 by default); round without decimals, ceil and
+// floor give integers.
+
+func init() {
+	builtinFuncs["min"] = func(c *Call) (interface{}, error) {
+		return extreme(c.Args, -1), nil
+	}
+	builtinFuncs["max"] = func(c *Call) (interface{}, error) {
+		return extreme(c.Args, 1), nil
+	}
+	builtinFuncs["abs"] = func(c *Call) (interface{}, error) {
+		n, err := numberArg(c.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		switch n.kind {
+		case 'i':
+			if n.i < 0 {
+				return -n.i, nil
+			}
+			return n.i, nil
+		case 'u':
+			return n.u, nil
+		}
+		return math.Abs(n.f), nil
+	}
+	builtinFuncs["round"] = func(c *Call) (interface{}, error) {
+		n, err := numberArg(c.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		places := 0
+		if len(c.Args) > 1 {
+			if places, err = intArg(c.Arg(1), "decimals"); err != nil {
+				return nil, err
+			}
+		}
+		if n.kind == 'i' && places >= 0 {
+			return n.i, nil
+		}
+		p := math.Pow(10, float64(places))
+		f := math.Round(n.float()*p) / p
+		if places <= 0 {
+			return int64(f), nil
+		}
+		return f, nil
+	}
+	builtinFuncs["ceil"] = func(c *Call) (interface{}, error) {
+		n, err := numberArg(c.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		return int64(math.Ceil(n.float())), nil
+	}
+	builtinFuncs["floor"] = func(c *Call) (interface{}, error) {
+		n, err := numberArg(c.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		return int64(math.Floor(n.float())), nil
+	}
+	builtinFuncs["sum"] = func(c *Call) (interface{}, error) {
+		total, _, err := sum(toList(c.Arg(0)))
+		return total, err
+	}
+	builtinFuncs["avg"] = func(c *Call) (interface{}, error) {
+		total, count, err := sum(toList(c.Arg(0)))
+		if err != nil || count == 0 {
+			return nil, err
+		}
+		n, _ := asNumber(total)
+		return n.float() / float64(count), nil
+	}
+}
+
+// numberArg: v as a number, an error if it isn't one.
+func numberArg(v interface{}) (number, error) {
+	n, ok := asNumber(v)
+	if !ok {
+		return number{}, fmt.Errorf("expected a number, got %T", v)
+	}
+	return n, nil
+}
+
+// extreme: the smallest (dir -1) or largest (dir 1) of args, or of the
+// items of a single list argument; nil if there are none.
+func extreme(args []interface{}, dir int) interface{} {
+	if len(args) == 1 {
+		args = toList(args[0])
+	}
+	var best interface{}
+	for _, v := range args {
+		if v != nil && (best == nil || sortCompare(v, best)*dir > 0) {
+			best = v
+		}
+	}
+	return best
+}
+
+// sum: the sum of the numbers in items (int64 while they are integers,
+// float64 otherwise) and how many there were; undefined items are skipped.
+func sum(items []interface{}) (interface{}, int, error) {
+	var (
+		i     int64
+		f     float64
+		float bool
+		count int
+	)
+	for _, v := range items {
+		if v == nil {
+			continue
+		}
+		n, err := numberArg(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		count++
+		if n.kind == 'i' && !float {
+			i += n.i
+			continue
+		}
+		if !float {
+			f, float = float64(i), true
+		}
+		f += n.float()
+	}
+	if float {
+		return f, count, nil
+	}
+	return i, count, nil
+}
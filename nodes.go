@@ -1,143 +1,507 @@
 package vingo
 
 import (
+	"bytes"
+	"context"
+	"database/sql/driver"
 	"fmt"
 	"html"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // -------------------- AST Nodes --------------------
 
+// Node: compiled template element. Eval renders the node on its own, with
+// data as its variables; in a render, the engine's own nodes write their
+// output through render instead, and other nodes put into Template.Nodes
+// are called with Eval.
 type Node interface {
 	Eval(data map[string]interface{}) string
 }
 
+// renderer: the engine's nodes; render writes the output to out.
+type renderer interface {
+	render(s *renderState, sc *scope, out *bytes.Buffer)
+}
+
+// renderState: per-render state shared by every node of one Render call.
+type renderState struct {
+	ctx     context.Context
+	engine  *Engine
+	data    map[string]interface{} // data passed to Render
+	err     error                  // first error; once set, evaluation stops
+	locale  string                 // locale the templates were compiled for, see I18nOptions
+	globals *scope                 // variables of set tags, between data and the loop scopes
+
+	iterations int      // loop iterations so far, see Limits
+	output     int      // bytes written so far, into any buffer
+	ops        int      // operations so far
+	includes   []string // files of the includes being rendered, outermost first
+	includer   string   // template of the outermost include tag, first in include chains
+
+	flashes     []Flash // flash messages not rendered yet
+	flashesRead bool    // flashes were taken from Engine.Flashes
+
+	csv *csvDialect // enclosing csv block; text and variables are dropped
+
+	sections      map[string]interface{} // output of section tags by name, see yield
+	captureBlocks bool                   // blocks write to sections instead, see RenderWithLayout
+
+	fills map[string]string   // slots filled by the component body being rendered
+	slots []map[string]string // slots of the components being rendered, innermost last
+
+	once map[interface{}]bool // once tags already rendered: their key, or the node
+
+	loops []int // iteration of the loops being rendered, innermost last; see cycle
+
+	node Node // node being evaluated, for RenderError
+}
+
+// fieldTag: Engine.FieldTag; s is nil when constants are folded.
+func (s *renderState) fieldTag() string {
+	if s == nil {
+		return ""
+	}
+	return s.engine.FieldTag
+}
+
+// fail: records err unless an earlier error is already recorded.
+func (s *renderState) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// stopped: reports whether evaluation must stop (error or cancelled ctx).
+func (s *renderState) stopped() bool {
+	if s.err != nil {
+		return true
+	}
+	if s.ctx.Err() != nil {
+		// the cause is a *LimitError when MaxRenderTime ran out
+		s.err = context.Cause(s.ctx)
+		return true
+	}
+	return false
+}
+
 type TextNode struct {
 	Text string
 }
 
-func (n *TextNode) Eval(data map[string]interface{}) string {
-	return n.Text
+func (n *TextNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if s.csv != nil {
+		return
+	}
+	out.WriteString(n.Text)
 }
 
 type VarNode struct {
 	Name    string
 	Default string
-	Filters []string
+
+	nodePos
+	expr Expr // compiled Name
 }
 
-func (n *VarNode) Eval(data map[string]interface{}) string {
-	val, ok := lookup(data, n.Name)
-	var out string
-	if ok {
-		out = fmt.Sprintf("%v", val)
-	} else if n.Default != "" {
-		out = n.Default
-	} else {
-		out = ""
+// newVarNode: VarNode for a TVar token.
+func newVarNode(t *Token) *VarNode {
+	return &VarNode{Name: t.Value, Default: t.Default, nodePos: posOf(t), expr: t.expr}
+}
+
+func (n *VarNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if s.csv != nil {
+		return
 	}
-	// Apply filters in order
-	for _, f := range n.Filters {
-		out = applyFilter(f, out)
+	val, ok := compiledExpr(s, n.expr, n.Name).eval(s, sc)
+	if !ok || val == nil {
+		val = n.Default
+	}
+	s.write(out, val)
+}
+
+// write: writes v as output text, formatted by the engine formatter (see
+// SetFormatter) if it takes v.
+func (s *renderState) write(out *bytes.Buffer, v interface{}) {
+	if f := s.engine.formatter.Load(); f != nil {
+		if _, isStr := v.(string); !isStr {
+			if str, ok := (*f)(v); ok {
+				out.WriteString(str)
+				return
+			}
+		}
+	}
+	writeValue(out, v)
+}
+
+// format: v as output text, like write.
+func (s *renderState) format(v interface{}) string {
+	b := getBuffer(0)
+	defer putBuffer(b)
+	s.write(b, v)
+	return b.String()
+}
+
+// writeValue: writes the %v form of v, without fmt for the common types;
+// database values (sql.NullString...) write their value, nothing for NULL.
+func writeValue(out *bytes.Buffer, v interface{}) {
+	switch t := v.(type) {
+	case string:
+		out.WriteString(t)
+	case int:
+		out.Write(strconv.AppendInt(out.AvailableBuffer(), int64(t), 10))
+	case int64:
+		out.Write(strconv.AppendInt(out.AvailableBuffer(), t, 10))
+	case bool:
+		out.Write(strconv.AppendBool(out.AvailableBuffer(), t))
+	case float64:
+		out.Write(strconv.AppendFloat(out.AvailableBuffer(), t, 'g', -1, 64))
+	case driver.Valuer:
+		val, err := t.Value()
+		if err == nil && val == nil {
+			return
+		}
+		if _, ok := v.(fmt.Stringer); !ok && err == nil {
+			// sql.NullInt64 writes its value, not {5 true}
+			if b, ok := val.([]byte); ok {
+				out.Write(b)
+			} else {
+				writeValue(out, val)
+			}
+			return
+		}
+		fmt.Fprintf(out, "%v", v)
+	default:
+		fmt.Fprintf(out, "%v", v)
 	}
-	return out
 }
 
 type IfNode struct {
 	Branches []IfBranch
 	Else     []Node
+
+	nodePos
 }
 
 type IfBranch struct {
 	Expr string
 	Body []Node
+
+	cond Expr // compiled Expr
 }
 
-func (n *IfNode) Eval(data map[string]interface{}) string {
+func (n *IfNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
 	for _, b := range n.Branches {
-		ok, err := evalCondition(b.Expr, data)
-		if err == nil && ok {
-			return evalNodes(b.Body, data)
+		if evalTruthy(s, compiledExpr(s, b.cond, b.Expr), sc) {
+			evalNodes(s, b.Body, sc, out)
+			return
 		}
 	}
 	// else
-	return evalNodes(n.Else, data)
+	evalNodes(s, n.Else, sc, out)
 }
 
 type ForNode struct {
 	IndexVar string // optional, can be ""
 	ItemVar  string
 	ListExpr string
+	Where    string // optional condition on ItemVar, "" = every item
+	SortBy   string // optional sort key of ItemVar, "" = list order
+	Desc     bool   // SortBy in descending order
 	Body     []Node
+
+	nodePos
+	list   Expr // compiled ListExpr
+	where  Expr // compiled Where
+	sortBy Expr // compiled SortBy
 }
 
-func (n *ForNode) Eval(data map[string]interface{}) string {
-	seq, ok := lookup(data, n.ListExpr)
+func (n *ForNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	seq, ok := compiledExpr(s, n.list, n.ListExpr).eval(s, sc)
 	if !ok {
-		return ""
+		return
 	}
 	v := reflect.ValueOf(seq)
 	kind := v.Kind()
 	if kind != reflect.Slice && kind != reflect.Array {
-		return ""
+		return
 	}
 	length := v.Len()
-	out := &strings.Builder{}
+	item := func(i int) interface{} { return v.Index(i).Interface() }
+	// one child scope for the whole loop; its variables are overwritten on
+	// every iteration instead of copying the outer data
+	vars := map[string]interface{}{}
+	inner := sc.child(vars)
+	if n.where != nil || n.sortBy != nil {
+		// filter and sort first: loop.Length and loop.Last count the kept items
+		var kept, keys []interface{}
+		for i := 0; i < length; i++ {
+			// the pass counts against MaxIterations like the loop itself
+			if s.stopped() || !s.iterate() {
+				return
+			}
+			vars[n.ItemVar] = item(i)
+			if n.where != nil && !evalTruthy(s, n.where, inner) {
+				continue
+			}
+			kept = append(kept, vars[n.ItemVar])
+			if n.sortBy != nil {
+				key, _ := n.sortBy.eval(s, inner)
+				keys = append(keys, key)
+			}
+		}
+		if n.sortBy != nil {
+			kept = sortItems(kept, keys, n.Desc)
+		}
+		length = len(kept)
+		item = func(i int) interface{} { return kept[i] }
+	}
+	s.loops = append(s.loops, 0)
+	defer func() { s.loops = s.loops[:len(s.loops)-1] }()
 	for i := 0; i < length; i++ {
-		item := v.Index(i).Interface()
-		newData := shallowCopyMap(data)
+		if s.stopped() || !s.iterate() {
+			break
+		}
+		s.loops[len(s.loops)-1] = i
 		if n.IndexVar != "" {
-			newData[n.IndexVar] = i
+			vars[n.IndexVar] = i
 		}
-		newData[n.ItemVar] = item
+		vars[n.ItemVar] = item(i)
 		// loop meta
-		loopMeta := map[string]interface{}{
-			"Index":  i,
-			"First":  i == 0,
-			"Last":   i == length-1,
-			"Length": length,
-		}
-		newData["loop"] = loopMeta
-		out.WriteString(evalNodes(n.Body, newData))
+		vars["loop"] = loopInfo{Index: i, First: i == 0, Last: i == length-1, Length: length}
+		evalNodes(s, n.Body, inner, out)
 	}
-	return out.String()
+}
+
+// loopInfo: the loop variable inside a for body.
+type loopInfo struct {
+	Index  int
+	First  bool
+	Last   bool
+	Length int
 }
 
 type SwitchNode struct {
 	Expr    string
 	Cases   []SwitchCase
 	Default []Node
+
+	nodePos
+	expr Expr // compiled Expr
 }
 
 type SwitchCase struct {
 	Cond string
 	Body []Node
+
+	cond Expr // compiled Cond
 }
 
-func (n *SwitchNode) Eval(data map[string]interface{}) string {
-	val := lookupVal(data, n.Expr)
-	// Try to match with case expressions: we evaluate each case as condition:
+// switchValue: the variable holding the switch value in the case
+// conditions and bodies of a switch.
+const switchValue = "__switch__"
+
+func (n *SwitchNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	val, _ := compiledExpr(s, n.expr, n.Expr).eval(s, sc)
+	sc = sc.child(map[string]interface{}{switchValue: val})
 	for _, c := range n.Cases {
-		// if case expression is a simple literal equal to val -> match
-		// Alternatively evaluate case as condition using evalCondition, but allow bare literal too.
-		ok, err := evalConditionWithValue(c.Cond, val, data)
-		if err == nil && ok {
-			return evalNodes(c.Body, data)
+		if c.matches(s, val, sc) {
+			evalNodes(s, c.Body, sc, out)
+			return
 		}
 	}
 	// default
-	return evalNodes(n.Default, data)
+	evalNodes(s, n.Default, sc, out)
 }
 
-func evalNodes(nodes []Node, data map[string]interface{}) string {
-	out := &strings.Builder{}
+// matches: case against the switch value val; sc has val as __switch__.
+//   - conditions (`case __switch__ > 10`, `case a and b`) are evaluated as
+//     they are; `case > 10` is short for `case __switch__ > 10`
+//   - a bare name (`case paid`) compares against "paid", then falls back to
+//     the truthiness of a variable with that name
+//   - any other expression compares its value with val
+func (c *SwitchCase) matches(s *renderState, val interface{}, sc *scope) bool {
+	switch x := compiledExpr(s, c.cond, caseSource(c.Cond)).(type) {
+	case *binaryExpr, *notExpr:
+		return evalTruthy(s, x, sc)
+	case *pathExpr:
+		if valuesEqual(val, literalFromString(x.path)) {
+			return true
+		}
+		return evalTruthy(s, x, sc)
+	default:
+		v, _ := x.eval(s, sc)
+		return valuesEqual(val, v)
+	}
+}
+
+// caseSource: the source of a case condition with the relational shorthand
+// (`case > 100`) expanded to a comparison with the switch value.
+func caseSource(src string) string {
+	trimmed := strings.TrimSpace(src)
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(trimmed, op) {
+			return switchValue + " " + trimmed
+		}
+	}
+	return src
+}
+
+// compiledExpr: the compiled form of src, parsing it now for nodes that were
+// built by hand instead of by the compiler. Parse errors fail the render.
+func compiledExpr(s *renderState, compiled Expr, src string) Expr {
+	if compiled != nil {
+		return compiled
+	}
+	x, err := parseExpr(src)
+	if err != nil {
+		s.fail(fmt.Errorf("vingo: invalid expression %q: %w", src, err))
+		return &litExpr{}
+	}
+	return x
+}
+
+// BlockNode: named fragment, rendered in place and on its own by RenderBlock.
+type BlockNode struct {
+	Name string
+	Body []Node
+}
+
+func (n *BlockNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if !s.captureBlocks {
+		evalNodes(s, n.Body, sc, out)
+		return
+	}
+	b := getBuffer(0)
+	defer putBuffer(b)
+	evalNodes(s, n.Body, sc, b)
+	if s.sections == nil {
+		s.sections = map[string]interface{}{}
+	}
+	s.sections[n.Name] = b.String()
+}
+
+// findBlock: first block called name in nodes, searching nested bodies too.
+func findBlock(nodes []Node, name string) *BlockNode {
+	var found *BlockNode
+	walkNodes(nodes, func(n Node) {
+		if b, ok := n.(*BlockNode); ok && found == nil && b.Name == name {
+			found = b
+		}
+	})
+	return found
+}
+
+// walkNodes: calls fn for every node in nodes and their bodies, parents
+// before children. Inlined includes are not entered.
+func walkNodes(nodes []Node, fn func(Node)) {
+	for _, n := range nodes {
+		fn(n)
+		switch n := n.(type) {
+		case *IfNode:
+			for _, b := range n.Branches {
+				walkNodes(b.Body, fn)
+			}
+			walkNodes(n.Else, fn)
+		case *ForNode:
+			walkNodes(n.Body, fn)
+		case *SwitchNode:
+			for _, c := range n.Cases {
+				walkNodes(c.Body, fn)
+			}
+			walkNodes(n.Default, fn)
+		case *BlockNode:
+			walkNodes(n.Body, fn)
+		case *CacheNode:
+			walkNodes(n.Body, fn)
+		case *CSVNode:
+			walkNodes(n.Body, fn)
+		case *SectionNode:
+			walkNodes(n.Body, fn)
+		case *ComponentNode:
+			walkNodes(n.Body, fn)
+		case *SlotNode:
+			walkNodes(n.Body, fn)
+		case *OnceNode:
+			walkNodes(n.Body, fn)
+		}
+	}
+}
+
+func evalNodes(s *renderState, nodes []Node, sc *scope, out *bytes.Buffer) {
 	for _, n := range nodes {
-		out.WriteString(n.Eval(data))
+		if s.stopped() {
+			break
+		}
+		s.op()
+		s.node = n
+		before, counted := out.Len(), s.output
+		if r, ok := n.(renderer); ok {
+			r.render(s, sc, out)
+		} else {
+			out.WriteString(n.Eval(sc.flatten()))
+		}
+		// output copied from the buffers of nested nodes (blocks, sections,
+		// components...) was counted when it was written there
+		if written := out.Len() - before - (s.output - counted); written > 0 {
+			s.output += written
+		}
+		s.checkOutput(s.output)
 	}
-	return out.String()
 }
 
+// bufPool: output buffers reused across renders.
+var bufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledBuffer: larger buffers are left to the GC instead of being kept
+// alive by the pool.
+const maxPooledBuffer = 4 << 20
+
+// getBuffer: empty pooled buffer with room for at least size bytes.
+func getBuffer(size int) *bytes.Buffer {
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Grow(size)
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufPool.Put(b)
+}
+
+// -------------------- Eval --------------------
+
+// evalNode: output of n rendered alone with data by the default engine; an
+// error leaves the output empty.
+func evalNode(n Node, data map[string]interface{}) string {
+	out, _ := defaultEngine.newState(context.Background(), "", data).run([]Node{n}, 0)
+	return out
+}
+
+func (n *TextNode) Eval(data map[string]interface{}) string      { return evalNode(n, data) }
+func (n *VarNode) Eval(data map[string]interface{}) string       { return evalNode(n, data) }
+func (n *IfNode) Eval(data map[string]interface{}) string        { return evalNode(n, data) }
+func (n *ForNode) Eval(data map[string]interface{}) string       { return evalNode(n, data) }
+func (n *SwitchNode) Eval(data map[string]interface{}) string    { return evalNode(n, data) }
+func (n *BlockNode) Eval(data map[string]interface{}) string     { return evalNode(n, data) }
+func (n *IncludeNode) Eval(data map[string]interface{}) string   { return evalNode(n, data) }
+func (n *CacheNode) Eval(data map[string]interface{}) string     { return evalNode(n, data) }
+func (n *CSVNode) Eval(data map[string]interface{}) string       { return evalNode(n, data) }
+func (n *RowNode) Eval(data map[string]interface{}) string       { return evalNode(n, data) }
+func (n *SectionNode) Eval(data map[string]interface{}) string   { return evalNode(n, data) }
+func (n *YieldNode) Eval(data map[string]interface{}) string     { return evalNode(n, data) }
+func (n *ComponentNode) Eval(data map[string]interface{}) string { return evalNode(n, data) }
+func (n *SlotNode) Eval(data map[string]interface{}) string      { return evalNode(n, data) }
+func (n *OnceNode) Eval(data map[string]interface{}) string      { return evalNode(n, data) }
+func (n *TestNode) Eval(data map[string]interface{}) string      { return "" }
+func (n *DeclNode) Eval(data map[string]interface{}) string      { return "" }
+
 // -------------------- Filters --------------------
 
 func applyFilter(name string, input string) string {
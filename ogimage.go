@@ -0,0 +1,111 @@
+package vingo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// -------------------- Open Graph images --------------------
+//
+//	<meta property="og:image" content="<{ og_image(title=post.Title, author=post.Author) }>">
+//
+// renders Engine.OGImages.Template with the keyword arguments as data,
+// rasterizes the HTML into a PNG and returns its URL. Images are named by
+// the hash of their HTML, so each one is rasterized only once, in a static
+// build as well as in a running server; the PNG files are kept in Dir.
+
+// Rasterizer: converts a rendered HTML page into a PNG of width x height
+// pixels (a headless browser, wkhtmltoimage, an image service...).
+type Rasterizer interface {
+	Rasterize(ctx context.Context, html string, width, height int) ([]byte, error)
+}
+
+// OGImageOptions: configuration of the og_image() helper.
+type OGImageOptions struct {
+	Template   string     // template of the image, relative to Root
+	Rasterizer Rasterizer // required
+	Dir        string     // directory the PNG files are written to
+	URLPrefix  string     // URL of Dir, e.g. "/og/"
+	Width      int        // 0 = 1200
+	Height     int        // 0 = 630
+}
+
+// CommandRasterizer: Rasterizer running an external program that reads
+// HTML on stdin and writes the PNG to stdout. "{width}" and "{height}" in
+// the arguments are replaced with the image size:
+//
+//	vingo.CommandRasterizer{"wkhtmltoimage", "--width", "{width}", "--height", "{height}", "-f", "png", "-", "-"}
+type CommandRasterizer []string
+
+func (c CommandRasterizer) Rasterize(ctx context.Context, html string, width, height int) ([]byte, error) {
+	if len(c) == 0 {
+		return nil, errors.New("empty CommandRasterizer")
+	}
+	r := strings.NewReplacer("{width}", strconv.Itoa(width), "{height}", strconv.Itoa(height))
+	argv := make([]string, len(c))
+	for i, a := range c {
+		argv[i] = r.Replace(a)
+	}
+	return runCommand(ctx, argv, []byte(html))
+}
+
+func init() {
+	builtinFuncs["og_image"] = ogImageFunc
+}
+
+// ogImageLocks: one lock per image file, so concurrent renders of the same
+// image rasterize it once.
+var ogImageLocks sync.Map
+
+func ogImageFunc(c *Call) (interface{}, error) {
+	e := c.Engine()
+	opts := e.OGImages
+	if opts.Template == "" || opts.Rasterizer == nil {
+		return nil, errors.New("Engine.OGImages.Template and Rasterizer must be set")
+	}
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = 1200
+	}
+	if height == 0 {
+		height = 630
+	}
+
+	html, err := e.RenderContext(c.Context(), opts.Template, c.Kwargs)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%dx%d\n%s", width, height, html)))
+	name := "og-" + hex.EncodeToString(sum[:8]) + ".png"
+	path := filepath.Join(opts.Dir, name)
+
+	mu, _ := ogImageLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+	if _, err := os.Stat(path); err != nil {
+		png, err := opts.Rasterizer.Rasterize(c.Context(), html, width, height)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+			return nil, err
+		}
+		// written under a temporary name, so a half-written file is never served
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, png, 0644); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return nil, err
+		}
+	}
+	return opts.URLPrefix + name, nil
+}
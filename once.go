@@ -0,0 +1,63 @@
+package vingo
+
+import (
+	"bytes"
+)
+
+// -------------------- once --------------------
+//
+//	<{ once }><script src="/js/chart.js"></script><{ /once }>
+//
+// renders its body the first time the tag is reached in a render and
+// nothing after that, so a partial included many times per page brings its
+// scripts and styles only once. With a key, every once tag with the same
+// key shares the one rendering, across partials:
+//
+//	<{ once "chart.js" }><{ script("js/chart.js") }><{ /once }>
+//
+// Combined with a section, the dependencies land in the layout:
+//
+//	<{ once }><{ section "scripts" }>...<{ /section }><{ /once }>
+
+// OnceNode: <{ once }> block.
+type OnceNode struct {
+	Key  string // raw key expression, "" for the tag itself
+	Body []Node
+
+	nodePos
+	key Expr // compiled Key
+}
+
+func (n *OnceNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	var key interface{} = n
+	if n.key != nil {
+		v, _ := n.key.eval(s, sc)
+		key = argString(v)
+	}
+	if s.once[key] {
+		return
+	}
+	if s.once == nil {
+		s.once = map[interface{}]bool{}
+	}
+	s.once[key] = true
+	evalNodes(s, n.Body, sc, out)
+}
+
+func parseOnce(tokens []*Token, start int) (*OnceNode, int, error) {
+	// tokens[start] is TOnce with Value `[keyExpr]`
+	node := &OnceNode{Key: tokens[start].Value, nodePos: posOf(tokens[start])}
+	if node.Key != "" {
+		args, kwargs, err := parseTagArgs(node.Key)
+		if err != nil || len(args) != 1 || len(kwargs) != 0 {
+			return nil, 0, tokenError(tokens[start], "invalid once tag: %s", tokens[start].Raw)
+		}
+		node.key = args[0]
+	}
+	body, i, err := parseClosed(tokens, start, "once", TEndOnce)
+	if err != nil {
+		return nil, 0, err
+	}
+	node.Body = body
+	return node, i, nil
+}
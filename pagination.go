@@ -0,0 +1,169 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// -------------------- Pagination --------------------
+//
+//	<{ set p = paginate(page, 20, total) }>
+//	Showing <{ p.From }>-<{ p.To }> of <{ p.Total }>
+//	<{ pagination(p, url="/posts?page={page}") }>
+//
+// paginate computes the page window of a list; the requested page is
+// clamped to 1..Pages. window=n sets the number of pages shown on each side
+// of the current one (default 2); the first and last pages are always in
+// Window, with gaps for the pages left out.
+//
+// pagination renders it as a <nav> of links: "{page}" in url is replaced
+// by the page number (default "?page={page}"). label, prev and next set
+// the aria-label of the nav and the texts of the prev/next links. The
+// current page has aria-current="page", unavailable prev/next links are
+// rendered as disabled spans.
+
+// Pagination: page window of a list, from Paginate.
+type Pagination struct {
+	Page, PerPage, Total int
+	Pages                int  // number of pages, at least 1
+	Offset               int  // index of the first item of Page, for queries
+	From, To             int  // 1-based numbers of the first and last item shown, 0 for an empty list
+	HasPrev, HasNext     bool // a previous / next page exists
+	Prev, Next           int  // their numbers, 0 if there is none
+	Window               []PageLink
+}
+
+// PageLink: an entry of Pagination.Window; Gap stands for skipped pages.
+type PageLink struct {
+	Number  int
+	Current bool
+	Gap     bool
+}
+
+// Paginate: pagination of total items, perPage per page, showing page with
+// window pages around it.
+func Paginate(page, perPage, total, window int) (*Pagination, error) {
+	if perPage <= 0 {
+		return nil, fmt.Errorf("vingo: paginate: items per page must be positive, got %d", perPage)
+	}
+	total = max(total, 0)
+	p := &Pagination{PerPage: perPage, Total: total, Pages: max((total+perPage-1)/perPage, 1)}
+	p.Page = min(max(page, 1), p.Pages)
+	p.Offset = (p.Page - 1) * perPage
+	if total > 0 {
+		p.From, p.To = p.Offset+1, min(p.Offset+perPage, total)
+	}
+	if p.HasPrev = p.Page > 1; p.HasPrev {
+		p.Prev = p.Page - 1
+	}
+	if p.HasNext = p.Page < p.Pages; p.HasNext {
+		p.Next = p.Page + 1
+	}
+	lo, hi := max(p.Page-window, 1), min(p.Page+window, p.Pages)
+	// a gap would hide a single page: show the page instead
+	if lo == 3 {
+		lo = 2
+	}
+	if hi == p.Pages-2 {
+		hi = p.Pages - 1
+	}
+	add := func(n int) {
+		p.Window = append(p.Window, PageLink{Number: n, Current: n == p.Page})
+	}
+	if lo > 1 {
+		add(1)
+		if lo > 2 {
+			p.Window = append(p.Window, PageLink{Gap: true})
+		}
+	}
+	for n := lo; n <= hi; n++ {
+		add(n)
+	}
+	if hi < p.Pages {
+		if hi < p.Pages-1 {
+			p.Window = append(p.Window, PageLink{Gap: true})
+		}
+		add(p.Pages)
+	}
+	return p, nil
+}
+
+func init() {
+	builtinFuncs["paginate"] = paginateFunc
+	builtinFuncs["pagination"] = paginationFunc
+}
+
+func paginateFunc(c *Call) (interface{}, error) {
+	var n [4]int
+	for i, v := range []interface{}{c.Arg(0), c.Arg(1), c.Arg(2), c.Kwarg("window", 2)} {
+		f, ok := numberOf(v)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("expected page, per page and total as integers, got %v", v)
+		}
+		n[i] = int(f)
+	}
+	if n[3] < 0 {
+		return nil, fmt.Errorf("window must not be negative, got %d", n[3])
+	}
+	p, err := Paginate(n[0], n[1], n[2], n[3])
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// paginationPartial: the default pagination markup; rendered with the
+// variables p (the Pagination), links (Window with url), prev_url,
+// next_url, label, prev and next.
+const paginationPartial = `<nav class="pagination" aria-label="<{ label | escape }>"><ul>` +
+	`<{ if p.HasPrev }><li><a href="<{ prev_url | escape }>" rel="prev"><{ prev | escape }></a></li>` +
+	`<{ else }><li><span aria-disabled="true"><{ prev | escape }></span></li><{ /if }>` +
+	`<{ for l in links }><{ if l.gap }><li><span class="gap">&hellip;</span></li>` +
+	`<{ elseif l.current }><li><a href="<{ l.url | escape }>" aria-current="page"><{ l.number }></a></li>` +
+	`<{ else }><li><a href="<{ l.url | escape }>"><{ l.number }></a></li><{ /if }><{ /for }>` +
+	`<{ if p.HasNext }><li><a href="<{ next_url | escape }>" rel="next"><{ next | escape }></a></li>` +
+	`<{ else }><li><span aria-disabled="true"><{ next | escape }></span></li><{ /if }>` +
+	`</ul></nav>`
+
+var paginationNodes = sync.OnceValue(func() []Node {
+	tokens, err := tokenize(paginationPartial)
+	if err != nil {
+		panic(err)
+	}
+	nodes, err := compileTokens(tokens)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+})
+
+func paginationFunc(c *Call) (interface{}, error) {
+	p, ok := c.Arg(0).(*Pagination)
+	if !ok {
+		return nil, fmt.Errorf("expected the result of paginate(), got %T", c.Arg(0))
+	}
+	pattern := argString(c.Kwarg("url", "?page={page}"))
+	pageURL := func(n int) string {
+		return strings.ReplaceAll(pattern, "{page}", strconv.Itoa(n))
+	}
+	links := make([]interface{}, len(p.Window))
+	for i, l := range p.Window {
+		links[i] = map[string]interface{}{"number": l.Number, "current": l.Current, "gap": l.Gap, "url": pageURL(l.Number)}
+	}
+	vars := map[string]interface{}{
+		"p":        p,
+		"links":    links,
+		"prev_url": pageURL(p.Prev),
+		"next_url": pageURL(p.Next),
+		"label":    c.Kwarg("label", "Pagination"),
+		"prev":     c.Kwarg("prev", "Previous"),
+		"next":     c.Kwarg("next", "Next"),
+	}
+	out := &bytes.Buffer{}
+	evalNodes(c.s, paginationNodes(), &scope{vars: vars}, out)
+	return out.String(), nil
+}
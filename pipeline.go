@@ -0,0 +1,146 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// -------------------- Post-render pipeline --------------------
+//
+// RenderPipeline passes the rendered output through a list of stages, each
+// one receiving the output of the previous one. Stages turn HTML into other
+// formats (PDF), minify it, sign it... RenderPDF is the pipeline of invoice
+// and report templates:
+//
+//	e.PDF = vingo.CommandPDFConverter{"wkhtmltopdf", "--quiet", "-", "-"}
+//	pdf, err := e.RenderPDF(ctx, "invoice.vgo", data)
+//
+// The converter sees the HTML without its URL, so stylesheets and images
+// should use absolute URLs or be inlined.
+
+// Stage: one step of a post-render pipeline.
+type Stage interface {
+	Process(ctx context.Context, in []byte) ([]byte, error)
+}
+
+// StageFunc: adapter to use a function as a Stage.
+type StageFunc func(ctx context.Context, in []byte) ([]byte, error)
+
+func (f StageFunc) Process(ctx context.Context, in []byte) ([]byte, error) {
+	return f(ctx, in)
+}
+
+// RenderPipeline: renders file with data and passes the output through
+// stages in order.
+func (e *Engine) RenderPipeline(ctx context.Context, file string, data map[string]interface{}, stages ...Stage) ([]byte, error) {
+	out, err := e.RenderContext(ctx, file, data)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte(out)
+	for i, s := range stages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if b, err = s.Process(ctx, b); err != nil {
+			return nil, fmt.Errorf("vingo: %s: stage %d: %w", file, i+1, err)
+		}
+	}
+	return b, nil
+}
+
+// PDFConverter: converts a rendered HTML page into a PDF document
+// (wkhtmltopdf, a headless chromium, a conversion service...).
+type PDFConverter interface {
+	ConvertPDF(ctx context.Context, html []byte) ([]byte, error)
+}
+
+// PDFStage: Stage converting HTML into PDF with Converter.
+type PDFStage struct {
+	Converter PDFConverter
+}
+
+func (s PDFStage) Process(ctx context.Context, in []byte) ([]byte, error) {
+	if s.Converter == nil {
+		return nil, errors.New("PDFStage without a Converter")
+	}
+	return s.Converter.ConvertPDF(ctx, in)
+}
+
+// RenderPDF: renders file with data and converts it with Engine.PDF.
+func (e *Engine) RenderPDF(ctx context.Context, file string, data map[string]interface{}) ([]byte, error) {
+	if e.PDF == nil {
+		return nil, errors.New("vingo: Engine.PDF must be set")
+	}
+	return e.RenderPipeline(ctx, file, data, PDFStage{e.PDF})
+}
+
+// CommandPDFConverter: PDFConverter running an external program. By default
+// the HTML is written to its stdin and the PDF read from its stdout; programs
+// that need files get them with the "{input}" (an .html file holding the
+// page) and "{output}" (the .pdf file to write) placeholders:
+//
+//	vingo.CommandPDFConverter{"wkhtmltopdf", "--quiet", "-", "-"}
+//	vingo.CommandPDFConverter{"chromium", "--headless", "--no-pdf-header-footer", "--print-to-pdf={output}", "{input}"}
+type CommandPDFConverter []string
+
+func (c CommandPDFConverter) ConvertPDF(ctx context.Context, html []byte) ([]byte, error) {
+	if len(c) == 0 {
+		return nil, errors.New("empty CommandPDFConverter")
+	}
+	joined := strings.Join(c, "\x00")
+	useInput := strings.Contains(joined, "{input}")
+	useOutput := strings.Contains(joined, "{output}")
+	if !useInput && !useOutput {
+		return runCommand(ctx, c, html)
+	}
+
+	dir, err := os.MkdirTemp("", "vingo-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "page.html")
+	output := filepath.Join(dir, "page.pdf")
+	var stdin []byte
+	if useInput {
+		if err := os.WriteFile(input, html, 0600); err != nil {
+			return nil, err
+		}
+	} else {
+		stdin = html
+	}
+
+	r := strings.NewReplacer("{input}", input, "{output}", output)
+	argv := make([]string, len(c))
+	for i, a := range c {
+		argv[i] = r.Replace(a)
+	}
+	stdout, err := runCommand(ctx, argv, stdin)
+	if err != nil || !useOutput {
+		return stdout, err
+	}
+	return os.ReadFile(output)
+}
+
+// runCommand: runs argv with stdin, returns its stdout; stderr is added to
+// the error of a failed run.
+func runCommand(ctx context.Context, argv []string, stdin []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return stdout.Bytes(), nil
+}
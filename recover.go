@@ -0,0 +1,131 @@
+package vingo
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// -------------------- Panic recovery --------------------
+//
+// A panic while a template is evaluated (a nil dereference in a Func,
+// reflection on a value it can't handle, ...) doesn't take the program
+// down: the render stops and returns a *RenderError pointing at the tag
+// that was being evaluated.
+
+// RenderError: a panic recovered during a render.
+type RenderError struct {
+	File      string      // template of the tag, "" for built-in partials
+	Line, Col int         // position of the tag, 0 if unknown
+	Value     interface{} // value passed to panic
+	Stack     string      // innermost frames of the panicking goroutine
+}
+
+func (e *RenderError) Error() string {
+	where := formatWhere(e.File, e.Line, e.Col)
+	if where == "" {
+		return fmt.Sprintf("vingo: panic during render: %v", e.Value)
+	}
+	return fmt.Sprintf("vingo: %s: panic during render: %v", where, e.Value)
+}
+
+// Unwrap: the panic value when it is an error (e.g. a runtime.Error).
+func (e *RenderError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// nodePos: position of the tag a node was compiled from.
+type nodePos struct {
+	template  string // set by setTemplate
+	line, col int
+}
+
+func (p *nodePos) position() *nodePos { return p }
+
+// positioned: nodes carrying a nodePos.
+type positioned interface {
+	position() *nodePos
+}
+
+func posOf(t *Token) nodePos {
+	return nodePos{line: t.Line, col: t.Col}
+}
+
+// nodeWhere: "file:line:col" of the tag of n, "" if unknown.
+func nodeWhere(n Node) string {
+	p, ok := n.(positioned)
+	if !ok {
+		return ""
+	}
+	pos := p.position()
+	return formatWhere(pos.template, pos.line, pos.col)
+}
+
+// formatWhere: "file:line:col", without the parts that are unknown.
+func formatWhere(file string, line, col int) string {
+	if line > 0 {
+		return fmt.Sprintf("%s:%d:%d", file, line, col)
+	}
+	return file
+}
+
+// errorAt: render error err at where (see nodeWhere).
+func errorAt(where string, err error) error {
+	if where == "" {
+		return fmt.Errorf("vingo: %w", err)
+	}
+	return fmt.Errorf("vingo: %s: %w", where, err)
+}
+
+// setTemplate: records path as the template of nodes.
+func setTemplate(nodes []Node, path string) {
+	walkNodes(nodes, func(n Node) {
+		if p, ok := n.(positioned); ok {
+			p.position().template = path
+		}
+	})
+}
+
+// eval: evalNodes from the top of a render, with panics turned into a
+// *RenderError.
+func (s *renderState) eval(nodes []Node, out *bytes.Buffer) {
+	defer s.recoverPanic()
+	evalNodes(s, nodes, s.globals, out)
+}
+
+// recoverPanic: deferred around evaluation; records a panic as a
+// *RenderError at s.node.
+func (s *renderState) recoverPanic() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	err := &RenderError{Value: v, Stack: panicStack(debug.Stack())}
+	if p, ok := s.node.(positioned); ok {
+		pos := p.position()
+		err.File, err.Line, err.Col = pos.template, pos.line, pos.col
+	}
+	s.err = err
+}
+
+// panicStackFrames: frames kept in RenderError.Stack.
+const panicStackFrames = 8
+
+// panicStack: the frames of stack below the call to panic, at most
+// panicStackFrames of them.
+func panicStack(stack []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") {
+			// skip the panic frame (function and file lines)
+			lines = lines[min(i+2, len(lines)):]
+			break
+		}
+	}
+	if n := panicStackFrames * 2; len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package vingo
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// -------------------- Regular expressions --------------------
+//
+//	<{ if email matches "^[^@]+@[^@]+$" }>...<{ /if }>
+//	<{ phone | regex_replace:"[^0-9]":"" }>
+//	<{ name | regex_replace:"(\\w+) (\\w+)":"$2, $1" }>
+//
+// Patterns use Go's regexp syntax (RE2) and are compiled once: compiled
+// patterns are cached per process. matches is true when the pattern
+// matches anywhere in the value (anchor it with ^ and $ for the whole
+// value); an undefined value never matches. regex_replace replaces every
+// match, $1 and ${name} in the replacement expand to submatches. An
+// invalid pattern fails the render.
+
+func init() {
+	builtinFuncs["regex_replace"] = func(c *Call) (interface{}, error) {
+		re, err := compileRegexp(argString(c.Arg(1)))
+		if err != nil {
+			return nil, err
+		}
+		return re.ReplaceAllString(argString(c.Arg(0)), argString(c.Arg(2))), nil
+	}
+}
+
+// maxCachedRegexps: patterns kept compiled; patterns built from data could
+// otherwise grow the cache without bound.
+const maxCachedRegexps = 512
+
+var regexpCache struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}
+
+// compileRegexp: pattern compiled, from the cache if possible.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.RLock()
+	re, ok := regexpCache.m[pattern]
+	regexpCache.RUnlock()
+	if ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("vingo: invalid pattern %q: %w", pattern, err)
+	}
+	regexpCache.Lock()
+	if regexpCache.m == nil {
+		regexpCache.m = map[string]*regexp.Regexp{}
+	}
+	if len(regexpCache.m) < maxCachedRegexps {
+		regexpCache.m[pattern] = re
+	}
+	regexpCache.Unlock()
+	return re, nil
+}
+
+// matchValue: v matches pattern; an undefined v never matches.
+func matchValue(v, pattern interface{}) (bool, error) {
+	re, err := compileRegexp(argString(pattern))
+	if err != nil {
+		return false, err
+	}
+	if v == nil {
+		return false, nil
+	}
+	return re.MatchString(argString(v)), nil
+}
@@ -0,0 +1,271 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"reflect"
+	"runtime/debug"
+)
+
+// -------------------- Runtime of generated code --------------------
+//
+// Functions written by Generate render through a Runtime. Its methods are
+// the building blocks of that code and behave like the interpreter; they
+// are exported for generated code, not meant to be called by hand.
+
+// Runtime: state of one render of a generated template.
+type Runtime struct {
+	s      *renderState
+	cancel context.CancelFunc // MaxRenderTime timer
+	unlock func()             // releases the engine lock taken for the render, nil once released
+	copied bool               // data was copied by Set
+}
+
+// NewRuntime: starts a render of data on e (nil: the default engine) and
+// returns its output buffer, pre-sized to size bytes.
+func NewRuntime(ctx context.Context, e *Engine, data map[string]interface{}, size int) (*Runtime, *bytes.Buffer) {
+	if e == nil {
+		e = defaultEngine
+	}
+	r := &Runtime{}
+	ctx, r.unlock = e.lockRender(ctx)
+	if d := e.Limits.MaxRenderTime; d > 0 {
+		ctx, r.cancel = context.WithTimeoutCause(ctx, d, &LimitError{Limit: "MaxRenderTime", Max: int64(d)})
+	}
+	r.s = &renderState{ctx: ctx, engine: e, data: data}
+	return r, getBuffer(size)
+}
+
+// Finish: the rendered output, or the first error of the render. w goes
+// back to the buffer pool and must not be used afterwards. Finish or
+// Recover must end every render started by NewRuntime.
+func (r *Runtime) Finish(w *bytes.Buffer) (string, error) {
+	defer putBuffer(w)
+	defer r.release()
+	if r.cancel != nil {
+		defer r.cancel()
+	}
+	r.s.checkOutput(w.Len())
+	if r.Stopped() {
+		return "", r.s.err
+	}
+	return r.s.engine.Output.encodeOutput(w.String())
+}
+
+// release: releases the engine lock of the render.
+func (r *Runtime) release() {
+	if r.unlock != nil {
+		r.unlock()
+		r.unlock = nil
+	}
+}
+
+// Stopped: reports whether rendering must stop (error or cancelled context).
+func (r *Runtime) Stopped() bool {
+	return r.s.stopped()
+}
+
+// Var: value of the variable path[0] of data, followed through path[1:].
+func (r *Runtime) Var(data map[string]interface{}, path ...string) (interface{}, bool) {
+	v, ok := data[path[0]]
+	if !ok {
+		return nil, false
+	}
+	return walkPath(v, path[1:], r.s.engine.FieldTag)
+}
+
+// Path: v followed through path.
+func (r *Runtime) Path(v interface{}, path ...string) (interface{}, bool) {
+	return walkPath(v, path, r.s.engine.FieldTag)
+}
+
+// VarOr: like Var, lit if the variable is undefined (bare names in
+// comparisons stand for themselves).
+func (r *Runtime) VarOr(lit interface{}, data map[string]interface{}, path ...string) interface{} {
+	if v, ok := r.Var(data, path...); ok {
+		return v
+	}
+	return lit
+}
+
+// PathOr: like Path, lit if the path is undefined.
+func (r *Runtime) PathOr(lit interface{}, v interface{}, path ...string) interface{} {
+	if v, ok := walkPath(v, path, r.s.engine.FieldTag); ok {
+		return v
+	}
+	return lit
+}
+
+// Member: a selector on a typed parameter: v, or its result if v is a
+// method without arguments and with one result, like the interpreter
+// calls them.
+func (r *Runtime) Member(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Func || rv.Type().NumIn() != 0 || rv.Type().NumOut() != 1 {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Call(nil)[0].Interface()
+}
+
+// Recover: deferred by generated functions; a panic of the render (in a
+// Func, or on a nil pointer in a selector of a typed parameter) releases
+// the render and sets *err to a *RenderError.
+func (r *Runtime) Recover(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.release()
+	r.s.err = &RenderError{Value: v, Stack: panicStack(debug.Stack())}
+	*err = r.s.err
+}
+
+// Value: v, nil if undefined.
+func (r *Runtime) Value(v interface{}, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Truthy: condition value of v.
+func (r *Runtime) Truthy(v interface{}) bool {
+	return r.s.truthy(v)
+}
+
+// Compare: a op b for the comparison operators of templates; at is the
+// "file:line:col" of the tag, for errors.
+func (r *Runtime) Compare(a, b interface{}, op, at string) bool {
+	r.s.op()
+	ok, err := compareValues(a, b, op)
+	if err != nil {
+		r.s.fail(errorAt(at, err))
+	}
+	return ok
+}
+
+// Matches: v matches pattern, the matches operator.
+func (r *Runtime) Matches(v, pattern interface{}) bool {
+	r.s.op()
+	ok, err := matchValue(v, pattern)
+	if err != nil {
+		r.s.fail(err)
+	}
+	return ok
+}
+
+// Add: a + b.
+func (r *Runtime) Add(a, b interface{}) interface{} {
+	r.s.op()
+	return addValues(a, b)
+}
+
+// Set: data with name set to v, for the set tag. data is copied on the
+// first Set; the map passed to the render is never modified.
+func (r *Runtime) Set(data map[string]interface{}, name string, v interface{}) map[string]interface{} {
+	if !r.copied {
+		data = maps.Clone(data)
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		r.copied = true
+	}
+	data[name] = v
+	return data
+}
+
+// Equal: switch case comparison.
+func (r *Runtime) Equal(a, b interface{}) bool {
+	return valuesEqual(a, b)
+}
+
+// Call: calls the template function name.
+func (r *Runtime) Call(name string, args []interface{}, kwargs map[string]interface{}) interface{} {
+	v, _ := callFunc(r.s, name, args, kwargs)
+	return v
+}
+
+// Filter: v | name:args...; nil values are passed on without a call,
+// except to default.
+func (r *Runtime) Filter(name string, v interface{}, args ...interface{}) interface{} {
+	if v == nil && name != "default" {
+		return nil
+	}
+	return r.Call(name, append([]interface{}{v}, args...), nil)
+}
+
+// Items: elements of a slice or array for a for loop, nil for other values.
+func (r *Runtime) Items(v interface{}) []interface{} {
+	if items, ok := v.([]interface{}); ok {
+		return items
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// Sort: items sorted stably by keys, keys[i] being the key of items[i]
+// (a sort clause of a for loop).
+func (r *Runtime) Sort(items, keys []interface{}, desc bool) []interface{} {
+	return sortItems(items, keys, desc)
+}
+
+// Iterate: counts one item of the where / sort pass of a for loop against
+// Limits.MaxIterations; false once the render stopped.
+func (r *Runtime) Iterate() bool {
+	r.s.iterate()
+	return !r.Stopped()
+}
+
+// BeginLoop: starts a for loop, for cycle().
+func (r *Runtime) BeginLoop() {
+	r.s.loops = append(r.s.loops, 0)
+}
+
+// EndLoop: ends the loop started by BeginLoop.
+func (r *Runtime) EndLoop() {
+	r.s.loops = r.s.loops[:len(r.s.loops)-1]
+}
+
+// Loop: the loop variable of iteration i of n; counts the iteration
+// against Limits.MaxIterations.
+func (r *Runtime) Loop(i, n int) interface{} {
+	r.s.op()
+	r.s.iterate()
+	if len(r.s.loops) > 0 {
+		r.s.loops[len(r.s.loops)-1] = i
+	}
+	return loopInfo{Index: i, First: i == 0, Last: i == n-1, Length: n}
+}
+
+// Write: writes v, def if v is nil.
+func (r *Runtime) Write(w *bytes.Buffer, v interface{}, def string) {
+	r.s.op()
+	if v == nil {
+		v = def
+	}
+	r.s.write(w, v)
+}
+
+// Cache: <{ cache }> block; nil options are not set. body renders the
+// fragment and may be run again in the background after the render ended.
+func (r *Runtime) Cache(w *bytes.Buffer, key, vary, per, ttl, stale interface{}, body func(r *Runtime, w *bytes.Buffer)) {
+	render := func(s *renderState, out *bytes.Buffer) {
+		body(&Runtime{s: s}, out)
+	}
+	args := cacheArgs{key: key, vary: vary, per: per, ttl: ttl, stale: stale}
+	renderFragment(r.s, w, args, render, func() fragmentBody { return render })
+}
@@ -0,0 +1,113 @@
+package vingo
+
+import (
+	"bytes"
+)
+
+// -------------------- Sections --------------------
+//
+// Pages add content to named places of their layout (page scripts and
+// styles, usually):
+//
+//	<{ section "scripts" }><script src="/chart.js"></script><{ /section }>
+//
+// and the layout writes it where it belongs:
+//
+//	<{ yield "scripts" }>
+//
+// A section renders nothing in place. Sections of the same name are
+// appended in render order; replace=true drops what earlier ones added.
+// With RenderWithLayout the page is rendered before the layout, so every
+// section of the page is there when the layout yields it; within one
+// template a yield only sees the sections rendered before it. Sections are
+// also in the sections variable of the layout, like blocks.
+
+// SectionNode: <{ section }> block.
+type SectionNode struct {
+	Name string
+	Body []Node
+
+	nodePos
+	replace Expr // optional
+}
+
+// YieldNode: <{ yield }> tag.
+type YieldNode struct {
+	Name string
+
+	nodePos
+}
+
+func (n *SectionNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	b := getBuffer(0)
+	defer putBuffer(b)
+	evalNodes(s, n.Body, sc, b)
+	if s.sections == nil {
+		s.sections = map[string]interface{}{}
+	}
+	prev, _ := s.sections[n.Name].(string)
+	if condTruthy(optValue(s, n.replace, sc)) {
+		prev = ""
+	}
+	s.sections[n.Name] = prev + b.String()
+}
+
+func (n *YieldNode) render(s *renderState, sc *scope, out *bytes.Buffer) {
+	if s.csv != nil {
+		return
+	}
+	str, _ := s.sections[n.Name].(string)
+	out.WriteString(str)
+}
+
+func parseSection(tokens []*Token, start int) (*SectionNode, int, error) {
+	// tokens[start] is TSection with Value `"name" [replace=true]`
+	args, kwargs, err := parseTagArgs(tokens[start].Value)
+	if err != nil || len(args) != 1 {
+		return nil, 0, tokenError(tokens[start], "invalid section tag: %s", tokens[start].Raw)
+	}
+	name := literalName(args[0])
+	if name == "" {
+		return nil, 0, tokenError(tokens[start], "section name must be a string: %s", tokens[start].Raw)
+	}
+	node := &SectionNode{Name: name, nodePos: posOf(tokens[start])}
+	for _, kw := range kwargs {
+		if kw.name != "replace" {
+			return nil, 0, tokenError(tokens[start], "unknown section option %q in: %s", kw.name, tokens[start].Raw)
+		}
+		node.replace = kw.val
+	}
+
+	body, i, err := parseBody(tokens, start+1, "section", TEndSection)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == len(tokens) {
+		return nil, 0, tokenError(tokens[start], "unclosed section %q", name)
+	}
+	node.Body = body
+	return node, i + 1, nil
+}
+
+func parseYield(t *Token) (*YieldNode, error) {
+	// t.Value is `"name"`
+	args, kwargs, err := parseTagArgs(t.Value)
+	if err != nil || len(args) != 1 || len(kwargs) != 0 {
+		return nil, tokenError(t, "invalid yield tag: %s", t.Raw)
+	}
+	name := literalName(args[0])
+	if name == "" {
+		return nil, tokenError(t, "yield name must be a string: %s", t.Raw)
+	}
+	return &YieldNode{Name: name, nodePos: posOf(t)}, nil
+}
+
+// literalName: the value of x if it is a non-empty string literal.
+func literalName(x Expr) string {
+	lit, ok := x.(*litExpr)
+	if !ok {
+		return ""
+	}
+	name, _ := lit.val.(string)
+	return name
+}
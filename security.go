@@ -0,0 +1,110 @@
+package vingo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// -------------------- CSRF token and CSP nonce --------------------
+//
+// Security tokens are per request, so they travel in the render context
+// instead of every data map: middleware stores them in the request context
+// and vingohttp.Renderer.RenderRequest (or RenderContext(r.Context(), ...))
+// passes it on.
+//
+//	http.Handle("/", vingo.CSPNonce("script-src 'nonce-{nonce}'")(
+//		vingo.CSRFToken(csrf.Token)(app))) // e.g. gorilla/csrf
+//
+//	<form method="post"><{ csrf_field() }> ... </form>
+//	<meta name="csrf-token" content="<{ csrf_token() }>">
+//	<script nonce="<{ csp_nonce() }>">...</script>
+//
+// csrf_field renders a hidden input named csrf_token (csrf_field(name=...)
+// for another name). script() and stylesheet() add the nonce by themselves.
+// The helpers fail the render when the context has no value, so a page
+// can't silently go out with a form that will be rejected.
+
+type csrfKey struct{}
+
+type cspNonceKey struct{}
+
+// WithCSRFToken: ctx giving token to csrf_field() and csrf_token().
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfKey{}, token)
+}
+
+// WithCSPNonce: ctx giving nonce to csp_nonce(), script() and stylesheet().
+func WithCSPNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, cspNonceKey{}, nonce)
+}
+
+// CSRFToken: middleware storing token(r), the token of the CSRF
+// protection of the application, in the request context.
+func CSRFToken(token func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithCSRFToken(r.Context(), token(r))))
+		})
+	}
+}
+
+// CSPNonce: middleware generating a nonce for every request, storing it in
+// the request context and, unless policy is "", sending policy with
+// {nonce} replaced as the Content-Security-Policy header.
+func CSPNonce(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce := NewNonce()
+			if policy != "" {
+				w.Header().Set("Content-Security-Policy", strings.ReplaceAll(policy, "{nonce}", nonce))
+			}
+			next.ServeHTTP(w, r.WithContext(WithCSPNonce(r.Context(), nonce)))
+		})
+	}
+}
+
+// NewNonce: random CSP nonce, 128 bits in base64.
+func NewNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func init() {
+	builtinFuncs["csrf_token"] = func(c *Call) (interface{}, error) {
+		return csrfTokenOf(c)
+	}
+	builtinFuncs["csrf_field"] = func(c *Call) (interface{}, error) {
+		token, err := csrfTokenOf(c)
+		if err != nil {
+			return nil, err
+		}
+		name := argString(c.Kwarg("name", "csrf_token"))
+		return `<input type="hidden" name="` + html.EscapeString(name) + `" value="` + html.EscapeString(token) + `">`, nil
+	}
+	builtinFuncs["csp_nonce"] = func(c *Call) (interface{}, error) {
+		nonce, ok := cspNonceOf(c)
+		if !ok {
+			return nil, errors.New("no CSP nonce in the render context, see WithCSPNonce")
+		}
+		return nonce, nil
+	}
+}
+
+func csrfTokenOf(c *Call) (string, error) {
+	token, _ := c.Context().Value(csrfKey{}).(string)
+	if token == "" {
+		return "", errors.New("no CSRF token in the render context, see WithCSRFToken")
+	}
+	return token, nil
+}
+
+func cspNonceOf(c *Call) (string, bool) {
+	nonce, _ := c.Context().Value(cspNonceKey{}).(string)
+	return nonce, nonce != ""
+}
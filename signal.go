@@ -0,0 +1,77 @@
+package vingo
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// -------------------- Signal handling --------------------
+
+// Flush: drops every compiled template, so the next render recompiles from
+// disk, and every cached fragment if the fragment store supports Clear.
+// Renders running meanwhile finish with the templates they already loaded.
+func (e *Engine) Flush() {
+	e.mu.Lock()
+	e.cache.clear()
+	e.mu.Unlock()
+	e.clearFragments()
+}
+
+// clearFragments: empties the fragment store if it supports Clear.
+func (e *Engine) clearFragments() {
+	if c, ok := e.fragmentStore().(interface{ Clear() }); ok {
+		c.Clear()
+	}
+}
+
+// ReloadOnSignal: for long-running processes (serve/daemon). Every time the
+// process receives a reload signal (SIGHUP on unix) reload is called (if not
+// nil), then the compiled templates and cached fragments are dropped as
+// Flush does. Renders are paused meanwhile: the reload waits for running
+// renders to finish and new ones wait for the reload, so a render sees the
+// configuration either before or after it, never part of each. reload may
+// call SetConst, AddFunc and the other Engine methods or set Engine fields,
+// but must not render with e. Blocks until ctx is cancelled; on platforms
+// without SIGHUP it only waits.
+func (e *Engine) ReloadOnSignal(ctx context.Context, reload func()) error {
+	sig := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(sig, reloadSignals...)
+		defer signal.Stop(sig)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sig:
+			e.reload(reload)
+		}
+	}
+}
+
+// reload: runs fn, then Flush, with renders paused.
+func (e *Engine) reload(fn func()) {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+	if fn != nil {
+		fn()
+	}
+	e.Flush()
+}
+
+// renderHeld: context key of a render of e, which holds e.reloadMu for
+// reading; renders nested in it (a Func rendering a template) don't lock
+// it again, which could deadlock with a waiting reload.
+type renderHeld struct{ e *Engine }
+
+// lockRender: read-locks the configuration of e for a render under ctx,
+// unless a render of e up in ctx holds it already. unlock releases it.
+func (e *Engine) lockRender(ctx context.Context) (_ context.Context, unlock func()) {
+	if ctx.Value(renderHeld{e}) != nil {
+		return ctx, func() {}
+	}
+	e.reloadMu.RLock()
+	return context.WithValue(ctx, renderHeld{e}, true), e.reloadMu.RUnlock
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9 || js || wasip1
+
+package vingo
+
+import "os"
+
+// reloadSignals: no SIGHUP equivalent on these platforms.
+var reloadSignals []os.Signal
@@ -0,0 +1,106 @@
+package vingo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestReloadAtomic: renders running alongside reloads see the constants
+// of one reload, never those of two.
+func TestReloadAtomic(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "page.vgo"), []byte(`<{ A }>-<{ B }>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.Root = dir
+	e.SetConst("A", 0)
+	e.SetConst("B", 0)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				out, err := e.Render("page.vgo", nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var a, b int
+				if _, err := fmt.Sscanf(out, "%d-%d", &a, &b); err != nil || a != b {
+					t.Errorf("render during reload = %q", out)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 50; i++ {
+		e.reload(func() {
+			e.SetConst("A", i)
+			time.Sleep(100 * time.Microsecond)
+			e.SetConst("B", i)
+		})
+	}
+	close(done)
+	wg.Wait()
+}
+
+// TestReloadNestedRender: a render nested in another one (a Func rendering
+// a template) doesn't deadlock with a reload waiting for the outer one.
+func TestReloadNestedRender(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"page.vgo": `[<{ inner() }>]`, "inner.vgo": `inner`} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := New()
+	e.Root = dir
+	started, proceed := make(chan struct{}), make(chan struct{})
+	e.AddFunc("inner", func(c *Call) (interface{}, error) {
+		close(started)
+		<-proceed
+		return c.Engine().RenderContext(c.Context(), "inner.vgo", nil)
+	})
+
+	type result struct {
+		out string
+		err error
+	}
+	res := make(chan result, 1)
+	go func() {
+		out, err := e.RenderContext(context.Background(), "page.vgo", nil)
+		res <- result{out, err}
+	}()
+	<-started
+	reloaded := make(chan struct{})
+	go func() {
+		e.reload(nil)
+		close(reloaded)
+	}()
+	time.Sleep(10 * time.Millisecond) // the reload waits for the render
+	close(proceed)
+
+	select {
+	case r := <-res:
+		if r.err != nil || r.out != "[inner]" {
+			t.Errorf("render = %q, %v; want %q", r.out, r.err, "[inner]")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested render deadlocked")
+	}
+	<-reloaded
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package vingo
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals: signals that trigger ReloadOnSignal.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
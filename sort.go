@@ -0,0 +1,206 @@
+package vingo
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// -------------------- Sorting --------------------
+//
+//	<{ for u in users sort by u.Name }>
+//	<{ for p in products where p.InStock sort by p.Price desc }>
+//	<{ for u in users | sort:"Name" }>
+//	<{ for e in events | sort:"StartsAt","desc" }>
+//	<{ for x in items | reverse }>
+//
+// Sorting is stable: items with equal keys keep their order. Keys compare
+// like the comparison operators do, by type: times (with date strings),
+// durations, strings, numbers; undefined keys sort last, other values by
+// their text. The list itself is never modified.
+
+func init() {
+	builtinFuncs["sort"] = func(c *Call) (interface{}, error) {
+		items := toList(c.Arg(0))
+		field := argString(c.Arg(1))
+		desc := false
+		switch order := argString(c.Arg(2)); order {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			return nil, fmt.Errorf("sort order must be \"asc\" or \"desc\", got %q", order)
+		}
+		keys := items
+		if field != "" {
+			keys = make([]interface{}, len(items))
+			path := strings.Split(field, ".")
+			for i, it := range items {
+				keys[i], _ = walkPath(it, path, c.s.fieldTag())
+			}
+		}
+		return sortItems(items, keys, desc), nil
+	}
+	builtinFuncs["group_by"] = func(c *Call) (interface{}, error) {
+		field := argString(c.Arg(1))
+		if field == "" {
+			return nil, fmt.Errorf("group_by needs a field name")
+		}
+		return groupBy(toList(c.Arg(0)), strings.Split(field, "."), c.s.fieldTag()), nil
+	}
+	builtinFuncs["batch"] = func(c *Call) (interface{}, error) {
+		n, err := intArg(c.Arg(1), "batch size")
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("batch size must be a positive integer, got %v", c.Arg(1))
+		}
+		var fill *interface{}
+		if len(c.Args) > 2 {
+			fill = &c.Args[2]
+		}
+		return batch(toList(c.Arg(0)), n, fill), nil
+	}
+	builtinFuncs["cycle"] = func(c *Call) (interface{}, error) {
+		values := c.Args
+		if len(values) == 1 {
+			values = toList(values[0])
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		i := 0
+		if loops := c.s.loops; len(loops) > 0 {
+			i = loops[len(loops)-1]
+		}
+		return values[i%len(values)], nil
+	}
+	builtinFuncs["reverse"] = func(c *Call) (interface{}, error) {
+		items := toList(c.Arg(0))
+		out := make([]interface{}, len(items))
+		for i, it := range items {
+			out[len(items)-1-i] = it
+		}
+		return out, nil
+	}
+}
+
+// sortItems: a copy of items sorted stably by keys, keys[i] being the key
+// of items[i].
+func sortItems(items, keys []interface{}, desc bool) []interface{} {
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int {
+		// undefined keys stay last, in both orders
+		switch ka, kb := keys[a], keys[b]; {
+		case ka == nil && kb == nil:
+			return 0
+		case ka == nil:
+			return 1
+		case kb == nil:
+			return -1
+		case desc:
+			return sortCompare(kb, ka)
+		default:
+			return sortCompare(ka, kb)
+		}
+	})
+	out := make([]interface{}, len(items))
+	for i, j := range idx {
+		out[i] = items[j]
+	}
+	return out
+}
+
+// sortCompare: -1, 0 or +1 as sort key a is before, equal to or after b.
+func sortCompare(a, b interface{}) int {
+	if at, bt, ok := timePair(a, b); ok {
+		return at.Compare(bt)
+	}
+	if as, ok := stringOf(a); ok {
+		if bs, ok := stringOf(b); ok {
+			return strings.Compare(as, bs)
+		}
+	}
+	if ad, bd, ok := durationPair(a, b); ok {
+		return cmp.Compare(ad, bd)
+	}
+	if an, ok := asNumber(a); ok {
+		if bn, ok := asNumber(b); ok {
+			return an.cmp(bn)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// -------------------- Cycling --------------------
+//
+//	<{ for row in rows }><tr class="<{ cycle("odd", "even") }>">...<{ /for }>
+//
+// cycle gives its arguments (or the items of a list argument) in turn, one
+// per iteration of the innermost enclosing loop; the first one outside of
+// loops.
+
+// -------------------- Grouping --------------------
+//
+//	<{ for g in products | group_by:"Category" }>
+//	  <h2><{ g.Key }> (<{ g.Items | length }>)</h2>
+//	  <{ for p in g.Items }>...<{ /for }>
+//	<{ /for }>
+//
+// group_by splits a list into groups of the items with the same field
+// value, in the order the values first appear; sort first for sorted
+// groups (products | sort:"Category" | group_by:"Category").
+
+// itemGroup: one group of group_by.
+type itemGroup struct {
+	Key   interface{}
+	Items []interface{}
+}
+
+// groupBy: items grouped by the value at path.
+func groupBy(items []interface{}, path []string, tag string) []itemGroup {
+	var groups []itemGroup
+	index := map[interface{}]int{}
+	for _, it := range items {
+		key, _ := walkPath(it, path, tag)
+		id := key
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			id = fmt.Sprint(key)
+		}
+		i, ok := index[id]
+		if !ok {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, itemGroup{Key: key})
+		}
+		groups[i].Items = append(groups[i].Items, it)
+	}
+	return groups
+}
+
+// -------------------- Batching --------------------
+//
+//	<{ for row in products | batch:3 }>
+//	  <div class="row"><{ for p in row }><div class="col"><{ p.Name }></div><{ /for }></div>
+//	<{ /for }>
+//
+// batch:n splits a list into rows of n items; the last row is shorter
+// unless a fill value is given (batch:3,"" pads it to 3 items).
+
+// batch: items in rows of n, the last one padded with *fill if not nil.
+func batch(items []interface{}, n int, fill *interface{}) [][]interface{} {
+	var rows [][]interface{}
+	for len(items) > 0 {
+		row := items[:min(n, len(items))]
+		items = items[len(row):]
+		row = row[:len(row):len(row)]
+		for fill != nil && len(row) < n {
+			row = append(row, *fill)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
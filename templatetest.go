@@ -0,0 +1,272 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// -------------------- Template tests --------------------
+//
+//	<{ test "renders admin badge" with {"user": {"IsAdmin": true}} expects contains "Admin" }>
+//
+// declares a test of the template it is in: the template is rendered with
+// the JSON object after `with` (optional) and the output is checked against
+// the expectation, one of contains "s", not contains "s" or equals "s".
+// Test tags are allowed at the top level of a template and are stripped
+// when it is compiled; `vingo test` and Engine.RunTests run them.
+
+// TestNode: <{ test }> tag.
+type TestNode struct {
+	Name   string
+	Data   map[string]interface{}
+	Expect string // "contains", "not contains" or "equals"
+	Want   string
+	Line   int
+}
+
+// render: tests render nothing.
+func (n *TestNode) render(s *renderState, sc *scope, out *bytes.Buffer) {}
+
+// TestResult: outcome of one template test.
+type TestResult struct {
+	File   string
+	Name   string
+	Line   int
+	Output string // rendered output
+	Err    error  // nil: passed
+
+	Golden  string // golden file of a golden test; Name is its case
+	Updated bool   // the golden file was rewritten
+}
+
+// RunTests: runs the tests declared in file. The error is about the
+// template itself (missing, does not compile); failed tests are reported
+// in the results.
+func (e *Engine) RunTests(ctx context.Context, file string) ([]TestResult, error) {
+	tpl, err := e.getOrCompile(e.resolve(file))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TestResult, 0, len(tpl.tests))
+	for _, t := range tpl.tests {
+		res := TestResult{File: file, Name: t.Name, Line: t.Line}
+		res.Output, res.Err = e.render(ctx, file, "", t.Data)
+		if res.Err == nil {
+			res.Err = t.check(res.Output)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// check: nil if out meets the expectation.
+func (n *TestNode) check(out string) error {
+	var ok bool
+	switch n.Expect {
+	case "contains":
+		ok = strings.Contains(out, n.Want)
+	case "not contains":
+		ok = !strings.Contains(out, n.Want)
+	case "equals":
+		ok = out == n.Want
+	}
+	if !ok {
+		return fmt.Errorf("expected output to %s %q", strings.TrimSuffix(n.Expect, "s"), n.Want)
+	}
+	return nil
+}
+
+// parseTest: t.Value is `"name" [with {json}] expects [not] contains|equals "text"`.
+func parseTest(t *Token) (*TestNode, error) {
+	invalid := func(why string) error {
+		return tokenError(t, "invalid test tag (%s): %s", why, t.Raw)
+	}
+	src := t.Value
+	if src[0] != '"' && src[0] != '\'' {
+		return nil, invalid("name must be a string")
+	}
+	name, n, err := scanQuoted(src)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	node := &TestNode{Name: name, Line: t.Line}
+	src = strings.TrimSpace(src[n:])
+
+	if rest, ok := strings.CutPrefix(src, "with"); ok {
+		dec := json.NewDecoder(strings.NewReader(rest))
+		if err := dec.Decode(&node.Data); err != nil {
+			return nil, invalid("with: " + err.Error())
+		}
+		src = strings.TrimSpace(rest[dec.InputOffset():])
+	}
+
+	src, ok := strings.CutPrefix(src, "expects")
+	if !ok {
+		return nil, invalid("missing expects")
+	}
+	src = strings.TrimSpace(src)
+	for _, op := range []string{"contains", "not contains", "equals"} {
+		if rest, ok := strings.CutPrefix(src, op); ok {
+			node.Expect, src = op, strings.TrimSpace(rest)
+			break
+		}
+	}
+	if node.Expect == "" || src == "" || (src[0] != '"' && src[0] != '\'') {
+		return nil, invalid("expects contains, not contains or equals and a string")
+	}
+	node.Want, n, err = scanQuoted(src)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	if strings.TrimSpace(src[n:]) != "" {
+		return nil, invalid("unexpected " + strings.TrimSpace(src[n:]))
+	}
+	return node, nil
+}
+
+// splitTests: removes the test tags from nodes, with the line break
+// following each, so they leave no blank lines in the output.
+func splitTests(nodes []Node) ([]Node, []*TestNode) {
+	var tests []*TestNode
+	out := nodes[:0]
+	for i, n := range nodes {
+		t, ok := n.(*TestNode)
+		if !ok {
+			out = append(out, n)
+			continue
+		}
+		tests = append(tests, t)
+		trimLineBreak(nodes, i+1)
+	}
+	return out, tests
+}
+
+// trimLineBreak: removes the line break starting nodes[i], if it is text.
+func trimLineBreak(nodes []Node, i int) {
+	if i >= len(nodes) {
+		return
+	}
+	if text, ok := nodes[i].(*TextNode); ok {
+		if !strings.HasPrefix(text.Text, "\r\n") {
+			text.Text = strings.TrimPrefix(text.Text, "\n")
+		} else {
+			text.Text = text.Text[2:]
+		}
+	}
+}
+
+// -------------------- Golden files --------------------
+//
+// Golden tests pair a template with data fixtures and the output expected
+// for each, in a testdata directory next to it:
+//
+//	views/card.vgo
+//	views/testdata/card/admin.json     data of the "admin" case
+//	views/testdata/card/admin.golden   expected output
+//	views/testdata/card/empty.golden   case rendered without data
+//
+// Data files can be JSON, YAML or TOML. RunGoldenTests with update set
+// writes the rendered output to the golden files instead of comparing.
+
+// RunGoldenTests: runs the golden tests of file. The error is about the
+// template or its testdata directory; failed cases are reported in the
+// results. A template without a testdata directory has no golden tests.
+func (e *Engine) RunGoldenTests(ctx context.Context, file string, update bool) ([]TestResult, error) {
+	stem := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	dir := filepath.Join(filepath.Dir(file), "testdata", stem)
+	if e.Root != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(e.Root, dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// case name -> data file ("" for goldens without data)
+	cases := map[string]string{}
+	var names []string
+	for _, ent := range entries {
+		name := ent.Name()
+		ext := filepath.Ext(name)
+		if ent.IsDir() || (ext != ".golden" && DataFormat(name) == "") {
+			continue
+		}
+		c := strings.TrimSuffix(name, ext)
+		data, seen := cases[c]
+		if !seen {
+			names = append(names, c)
+		}
+		if ext == ".golden" {
+			cases[c] = data
+			continue
+		}
+		if data != "" {
+			return nil, fmt.Errorf("vingo: %s: case %q has two data files", dir, c)
+		}
+		cases[c] = filepath.Join(dir, name)
+	}
+	sort.Strings(names)
+
+	results := make([]TestResult, 0, len(names))
+	for _, c := range names {
+		golden := filepath.Join(dir, c+".golden")
+		res := TestResult{File: file, Name: c, Golden: golden}
+		var data map[string]interface{}
+		if cases[c] != "" {
+			data, res.Err = ReadDataFile(cases[c])
+		}
+		if res.Err == nil {
+			res.Output, res.Err = e.render(ctx, file, "", data)
+		}
+		if res.Err == nil {
+			if update {
+				res.Err = os.WriteFile(golden, []byte(res.Output), 0644)
+				res.Updated = res.Err == nil
+			} else {
+				res.Err = checkGolden(golden, res.Output)
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// checkGolden: nil if out equals the content of the golden file, otherwise
+// an error showing the first line that differs.
+func checkGolden(golden, out string) error {
+	b, err := os.ReadFile(golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("missing golden file %s (run with update to create it)", golden)
+	}
+	if err != nil {
+		return err
+	}
+	want := string(b)
+	if out == want {
+		return nil
+	}
+	wantLines, gotLines := strings.SplitAfter(want, "\n"), strings.SplitAfter(out, "\n")
+	line := 0
+	for line < len(wantLines) && line < len(gotLines) && wantLines[line] == gotLines[line] {
+		line++
+	}
+	var w, g string
+	if line < len(wantLines) {
+		w = wantLines[line]
+	}
+	if line < len(gotLines) {
+		g = gotLines[line]
+	}
+	return fmt.Errorf("output differs from %s at line %d:\n  want: %q\n  got:  %q", golden, line+1, w, g)
+}
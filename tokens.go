@@ -1,8 +1,10 @@
 package vingo
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -21,218 +23,528 @@ const (
 	TCase
 	TDefault
 	TEndSwitch
+	TBlock
+	TEndBlock
+	TCache
+	TEndCache
+	TInclude
+	TTest
+	TCSV
+	TEndCSV
+	TRow
+	TSection
+	TEndSection
+	TYield
+	TComponent
+	TEndComponent
+	TSlot
+	TEndSlot
+	TOnce
+	TEndOnce
+	TParams
+	TImport
 )
 
+var tokenNames = [...]string{
+	TText: "text", TVar: "var", TIf: "if", TElseIf: "elseif", TElse: "else", TEndIf: "/if",
+	TFor: "for", TEndFor: "/for", TSwitch: "switch", TCase: "case", TDefault: "default",
+	TEndSwitch: "/switch", TBlock: "block", TEndBlock: "/block", TCache: "cache", TEndCache: "/cache",
+	TInclude: "include", TTest: "test", TCSV: "csv", TEndCSV: "/csv", TRow: "row",
+	TSection: "section", TEndSection: "/section", TYield: "yield",
+	TComponent: "component", TEndComponent: "/component", TSlot: "slot", TEndSlot: "/slot",
+	TOnce: "once", TEndOnce: "/once", TParams: "params", TImport: "import",
+}
+
+func (t TokenType) String() string {
+	if int(t) < len(tokenNames) && tokenNames[t] != "" {
+		return tokenNames[t]
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
 type Token struct {
 	Type    TokenType
 	Value   string // for Var: expression or name; for If/For/Switch/Case: expression / raw
 	Default string // for Var default literal (if provided)
 	Raw     string // raw tag text
+
+	Pos  int // byte offset of the token in the template source
+	Line int // 1-based line of Pos
+	Col  int // 1-based column (in bytes) of Pos
+
+	expr Expr // for Var: parsed Value
 }
 
-var (
-	varPattern       = regexp.MustCompile(`^\s*(\w+(?:\.\w+)*)(?:\s*\|\s*"(.*?)")?\s*$`)
-	ifPattern        = regexp.MustCompile(`^if\s+(.+)$`)
-	elseifPattern    = regexp.MustCompile(`^elseif\s+(.+)$`)
-	elsePattern      = regexp.MustCompile(`^else$`)
-	endifPattern     = regexp.MustCompile(`^/if$`)
-	forPattern       = regexp.MustCompile(`^for\s+(.+)\s+in\s+(.+)$`)
-	endforPattern    = regexp.MustCompile(`^/for$`)
-	switchPattern    = regexp.MustCompile(`^switch\s+(.+)$`)
-	casePattern      = regexp.MustCompile(`^case\s+(.+)$`)
-	defaultPattern   = regexp.MustCompile(`^default$`)
-	endswitchPattern = regexp.MustCompile(`^/switch$`)
-)
+// forPattern: "idx, item in list" / "item in list" (list may end with
+// where and sort clauses, see splitLoop)
+var forPattern = regexp.MustCompile(`(?s)^(.+)\s+in\s+(.+)$`)
+
+// -------------------- Lexer --------------------
 
-func tokenize(input string) []*Token {
+// tokenize: single pass scanner over the template source. Text between tags
+// becomes TText, tags are classified by their first word. A tag ends at the
+// first "}>" outside of a quoted string and may span multiple lines.
+// row is only a keyword between <{ csv }> and <{ /csv }>.
+// `\<{` is an escaped delimiter and produces a literal "<{" in the text.
+func tokenize(input string) ([]*Token, error) {
 	var tokens []*Token
-	parts := strings.Split(input, "<{")
+	pos := &position{src: input, line: 1}
+	text := &strings.Builder{}
+	textStart := 0
+	csvDepth := 0 // csv blocks open at i
+
+	flushText := func() {
+		if text.Len() == 0 {
+			return
+		}
+		t := &Token{Type: TText, Value: text.String()}
+		pos.set(t, textStart)
+		tokens = append(tokens, t)
+		text.Reset()
+	}
 
-	for _, part := range parts {
-		if part == "" {
+	i := 0
+	for i < len(input) {
+		j := strings.Index(input[i:], "<{")
+		if j < 0 {
+			text.WriteString(input[i:])
+			break
+		}
+		j += i
+		if j > 0 && input[j-1] == '\\' {
+			// escaped delimiter
+			text.WriteString(input[i : j-1])
+			text.WriteString("<{")
+			i = j + 2
 			continue
 		}
+		text.WriteString(input[i:j])
+		flushText()
 
-		sub := strings.SplitN(part, "}>", 2)
-		if len(sub) == 2 {
-			tag := strings.TrimSpace(sub[0])
-			rest := sub[1]
-
-			switch {
-			case ifPattern.MatchString(tag):
-				m := ifPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TIf, Value: m[1], Raw: tag})
-			case elseifPattern.MatchString(tag):
-				m := elseifPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TElseIf, Value: m[1], Raw: tag})
-			case elsePattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TElse, Raw: tag})
-			case endifPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndIf, Raw: tag})
-			case forPattern.MatchString(tag):
-				m := forPattern.FindStringSubmatch(tag)
-				// m[1] could be "idx, item" or "item"
-				tokens = append(tokens, &Token{Type: TFor, Value: strings.TrimSpace(m[1]) + ":" + strings.TrimSpace(m[2]), Raw: tag})
-			case endforPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndFor, Raw: tag})
-			case switchPattern.MatchString(tag):
-				m := switchPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TSwitch, Value: m[1], Raw: tag})
-			case casePattern.MatchString(tag):
-				m := casePattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TCase, Value: m[1], Raw: tag})
-			case defaultPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TDefault, Raw: tag})
-			case endswitchPattern.MatchString(tag):
-				tokens = append(tokens, &Token{Type: TEndSwitch, Raw: tag})
-			case varPattern.MatchString(tag):
-				m := varPattern.FindStringSubmatch(tag)
-				tokens = append(tokens, &Token{Type: TVar, Value: m[1], Default: m[2], Raw: tag})
-			default:
-				// treat as text containing the tag (unknown tag kept)
-				tokens = append(tokens, &Token{Type: TText, Value: "<{" + tag + "}>", Raw: tag})
+		end := scanTagEnd(input, j+2)
+		if end < 0 {
+			line, col := pos.at(j)
+			return nil, &SyntaxError{Line: line, Col: col, Err: errors.New("unterminated tag, missing }>")}
+		}
+		tag := strings.TrimSpace(input[j+2 : end])
+		t := classifyTag(tag)
+		switch t.Type {
+		case TCSV:
+			csvDepth++
+		case TEndCSV:
+			csvDepth = max(csvDepth-1, 0)
+		case TRow:
+			if csvDepth == 0 {
+				// row is only a keyword inside a csv block
+				t = outputToken(tag)
 			}
+		}
+		pos.set(t, j)
+		tokens = append(tokens, t)
+		i = end + 2
+		textStart = i
+	}
+	flushText()
+	return tokens, nil
+}
 
-			if rest != "" {
-				tokens = append(tokens, &Token{Type: TText, Value: rest})
+// scanTagEnd: offset of the "}>" closing a tag whose body starts at start,
+// skipping quoted strings; -1 if the tag is never closed.
+func scanTagEnd(src string, start int) int {
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '"', '\'':
+			q := src[i]
+			k := i + 1
+			for k < len(src) && src[k] != q {
+				if src[k] == '\\' {
+					k++
+				}
+				k++
 			}
-		} else {
-			// trailing text without closing tag
-			tokens = append(tokens, &Token{Type: TText, Value: "<{" + part})
+			if k >= len(src) {
+				// unbalanced quote: treat it as a plain character
+				continue
+			}
+			i = k
+		case '}':
+			if i+1 < len(src) && src[i+1] == '>' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// classifyTag: token for the trimmed text between <{ and }>.
+func classifyTag(tag string) *Token {
+	word, rest := tag, ""
+	if k := strings.IndexAny(tag, " \t\r\n"); k >= 0 {
+		word, rest = tag[:k], strings.TrimSpace(tag[k+1:])
+	}
+
+	// keywords taking an argument
+	if rest != "" {
+		switch word {
+		case "if":
+			return &Token{Type: TIf, Value: rest, Raw: tag}
+		case "elseif":
+			return &Token{Type: TElseIf, Value: rest, Raw: tag}
+		case "for":
+			if m := forPattern.FindStringSubmatch(rest); m != nil {
+				// m[1] could be "idx, item" or "item"
+				return &Token{Type: TFor, Value: strings.TrimSpace(m[1]) + ":" + strings.TrimSpace(m[2]), Raw: tag}
+			}
+		case "switch":
+			// <{ switch x case a: v ... }> is an output tag
+			if expr, src, def, err := parseOutputTag(tag); err == nil {
+				if _, ok := expr.(*switchExpr); ok {
+					return &Token{Type: TVar, Value: src, Default: def, Raw: tag, expr: expr}
+				}
+			}
+			return &Token{Type: TSwitch, Value: rest, Raw: tag}
+		case "set":
+			if x, err := parseSet(rest); err == nil {
+				return &Token{Type: TVar, Value: tag, Raw: tag, expr: x}
+			}
+		case "case":
+			return &Token{Type: TCase, Value: rest, Raw: tag}
+		case "block":
+			if !variableUse(rest) {
+				return &Token{Type: TBlock, Value: rest, Raw: tag}
+			}
+		case "cache":
+			if !variableUse(rest) {
+				return &Token{Type: TCache, Value: rest, Raw: tag}
+			}
+		case "include":
+			if !variableUse(rest) {
+				return &Token{Type: TInclude, Value: rest, Raw: tag}
+			}
+		case "csv":
+			if !variableUse(rest) {
+				return &Token{Type: TCSV, Value: rest, Raw: tag}
+			}
+		case "row":
+			if !variableUse(rest) {
+				return &Token{Type: TRow, Value: rest, Raw: tag}
+			}
+		case "section":
+			if !variableUse(rest) {
+				return &Token{Type: TSection, Value: rest, Raw: tag}
+			}
+		case "yield":
+			if !variableUse(rest) {
+				return &Token{Type: TYield, Value: rest, Raw: tag}
+			}
+		case "component":
+			if !variableUse(rest) {
+				return &Token{Type: TComponent, Value: rest, Raw: tag}
+			}
+		case "slot":
+			if !variableUse(rest) {
+				return &Token{Type: TSlot, Value: rest, Raw: tag}
+			}
+		case "once":
+			if !variableUse(rest) {
+				return &Token{Type: TOnce, Value: rest, Raw: tag}
+			}
+		case "test":
+			if rest[0] == '"' || rest[0] == '\'' {
+				return &Token{Type: TTest, Value: rest, Raw: tag}
+			}
+		case "params":
+			if !variableUse(rest) {
+				return &Token{Type: TParams, Value: rest, Raw: tag}
+			}
+		case "import":
+			if rest[0] == '"' || rest[0] == '`' {
+				return &Token{Type: TImport, Value: rest, Raw: tag}
+			}
+		case "t":
+			// <{ t "key" count=n }> is the output of t("key", count=n)
+			if rest[0] == '"' || rest[0] == '\'' {
+				if args, kwargs, err := parseTagArgs(rest); err == nil && len(args) == 1 {
+					return &Token{Type: TVar, Value: tag, Raw: tag, expr: &callExpr{name: "t", args: args, kwargs: kwargs}}
+				}
+			}
+		}
+	} else {
+		switch word {
+		case "else":
+			return &Token{Type: TElse, Raw: tag}
+		case "/if":
+			return &Token{Type: TEndIf, Raw: tag}
+		case "/for":
+			return &Token{Type: TEndFor, Raw: tag}
+		case "default":
+			return &Token{Type: TDefault, Raw: tag}
+		case "/switch":
+			return &Token{Type: TEndSwitch, Raw: tag}
+		case "/block":
+			return &Token{Type: TEndBlock, Raw: tag}
+		case "/cache":
+			return &Token{Type: TEndCache, Raw: tag}
+		case "csv":
+			return &Token{Type: TCSV, Raw: tag}
+		case "/csv":
+			return &Token{Type: TEndCSV, Raw: tag}
+		case "/section":
+			return &Token{Type: TEndSection, Raw: tag}
+		case "/component":
+			return &Token{Type: TEndComponent, Raw: tag}
+		case "/slot":
+			return &Token{Type: TEndSlot, Raw: tag}
+		case "once":
+			return &Token{Type: TOnce, Raw: tag}
+		case "/once":
+			return &Token{Type: TEndOnce, Raw: tag}
 		}
 	}
 
-	return tokens
+	return outputToken(tag)
+}
+
+// outputToken: token of tag as an output tag, a text token keeping the tag
+// if it isn't one.
+func outputToken(tag string) *Token {
+	if expr, src, def, err := parseOutputTag(tag); err == nil {
+		return &Token{Type: TVar, Value: src, Default: def, Raw: tag, expr: expr}
+	}
+	// treat as text containing the tag (unknown tag kept)
+	return &Token{Type: TText, Value: "<{" + tag + "}>", Raw: tag}
+}
+
+// variableUse: rest, following the first word of a tag, filters or walks
+// into that word as a variable (<{ block | upper }>, <{ block .Name }>)
+// instead of being the arguments of a keyword.
+func variableUse(rest string) bool {
+	return strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ".")
+}
+
+// position: converts increasing byte offsets into line/column numbers.
+type position struct {
+	src       string
+	off       int // offset up to which newlines have been counted
+	line      int // line at off
+	lineStart int // offset of the first byte of line
+}
+
+func (p *position) at(off int) (line, col int) {
+	for p.off < off {
+		if p.src[p.off] == '\n' {
+			p.line++
+			p.lineStart = p.off + 1
+		}
+		p.off++
+	}
+	return p.line, off - p.lineStart + 1
+}
+
+func (p *position) set(t *Token, off int) {
+	t.Pos = off
+	t.Line, t.Col = p.at(off)
 }
 
 // -------------------- compile (tokens -> AST nodes) --------------------
 
+// SyntaxError: a template that does not compile, at Line:Col (1-based,
+// column in bytes) of its source.
+type SyntaxError struct {
+	Line, Col int
+	Err       error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d:%d: %v", e.Line, e.Col, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// tokenError: compile error at the position of t.
+func tokenError(t *Token, format string, args ...interface{}) error {
+	return &SyntaxError{Line: t.Line, Col: t.Col, Err: fmt.Errorf(format, args...)}
+}
+
+// unknownTag: error for the first tag classifyTag did not recognize (kept
+// as text) or that looks like a misspelled closing tag, nil if there is
+// none.
+func unknownTag(tokens []*Token) error {
+	for _, t := range tokens {
+		if closer, ok := misspelledCloser(t); ok {
+			return tokenError(t, "unknown tag <{ %s }>, did you mean <{ %s }>?", t.Raw, closer)
+		}
+		if t.Type == TText && t.Raw != "" {
+			return tokenError(t, "unknown tag <{ %s }>", t.Raw)
+		}
+	}
+	return nil
+}
+
+// misspelledCloser: the closing tag meant by <{ endif }>, <{ endfor }>...,
+// which would otherwise output a variable called endif.
+func misspelledCloser(t *Token) (string, bool) {
+	if t.Type != TVar && t.Type != TText {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(t.Raw, "end")
+	if !ok || !slices.Contains(tokenNames[:], "/"+name) {
+		return "", false
+	}
+	return "/" + name, true
+}
+
 func compileTokens(tokens []*Token) ([]Node, error) {
+	nodes, _, err := parseBody(tokens, 0, "")
+	return nodes, err
+}
+
+// parseBody: nodes of tokens[i:] up to the first token whose type is in
+// stop; nested blocks are parsed recursively by their own parse function.
+// in names the enclosing tag for errors ("" = top level). Returns the
+// index of the stop token, len(tokens) if the input ended first.
+func parseBody(tokens []*Token, i int, in string, stop ...TokenType) ([]Node, int, error) {
 	nodes := []Node{}
-	i := 0
 	for i < len(tokens) {
 		t := tokens[i]
+		if slices.Contains(stop, t.Type) {
+			return nodes, i, nil
+		}
+		var (
+			child Node
+			err   error
+		)
+		ni := i + 1
 		switch t.Type {
 		case TText:
-			nodes = append(nodes, &TextNode{Text: t.Value})
-			i++
+			child = &TextNode{Text: t.Value}
 		case TVar:
-			// parse filters from t.Raw maybe in future; currently only default supported.
-			filters := []string{}
-			// if user wants filters like <{ var | upper }>, varPattern must be extended.
-			nodes = append(nodes, &VarNode{Name: t.Value, Default: t.Default, Filters: filters})
-			i++
+			child = newVarNode(t)
+		case TInclude:
+			child, err = parseInclude(t)
+		case TRow:
+			child, err = parseRow(t)
+		case TYield:
+			child, err = parseYield(t)
 		case TIf:
-			ifNode, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, err
-			}
-			nodes = append(nodes, ifNode)
-			i = ni
+			child, ni, err = parseIf(tokens, i)
 		case TFor:
-			forNode, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, err
-			}
-			nodes = append(nodes, forNode)
-			i = ni
+			child, ni, err = parseFor(tokens, i)
 		case TSwitch:
-			switchNode, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, err
+			child, ni, err = parseSwitch(tokens, i)
+		case TBlock:
+			child, ni, err = parseBlock(tokens, i)
+		case TCache:
+			child, ni, err = parseCache(tokens, i)
+		case TCSV:
+			child, ni, err = parseCSV(tokens, i)
+		case TSection:
+			child, ni, err = parseSection(tokens, i)
+		case TComponent:
+			child, ni, err = parseComponent(tokens, i)
+		case TSlot:
+			child, ni, err = parseSlot(tokens, i)
+		case TOnce:
+			child, ni, err = parseOnce(tokens, i)
+		case TTest:
+			if in != "" {
+				return nil, 0, tokenError(t, "unexpected %v tag inside %s", t.Type, in)
 			}
-			nodes = append(nodes, switchNode)
-			i = ni
+			child, err = parseTest(t)
+		case TParams, TImport:
+			if in != "" {
+				return nil, 0, tokenError(t, "unexpected %v tag inside %s", t.Type, in)
+			}
+			child, err = parseDecl(t)
 		default:
-			return nil, fmt.Errorf("unexpected token %v at position %d (raw: %s)", t.Type, i, t.Raw)
+			if in != "" {
+				return nil, 0, tokenError(t, "unexpected %v tag inside %s", t.Type, in)
+			}
+			return nil, 0, tokenError(t, "unexpected %v tag (raw: %s)", t.Type, t.Raw)
+		}
+		if err != nil {
+			return nil, 0, err
 		}
+		nodes = append(nodes, child)
+		i = ni
 	}
-	return nodes, nil
+	return nodes, i, nil
 }
 
-func parseIf(tokens []*Token, start int) (*IfNode, int, error) {
-	// tokens[start] is TIf
-	root := &IfNode{}
-	branches := []IfBranch{{Expr: tokens[start].Value, Body: []Node{}}}
-	elseBody := []Node{}
-	currentBody := &branches[0].Body
-	depth := 0
+// parseClosed: body of the block tag at tokens[start] up to its closing
+// tag end; returns the index after the closing tag.
+func parseClosed(tokens []*Token, start int, in string, end TokenType) ([]Node, int, error) {
+	body, i, err := parseBody(tokens, start+1, in, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == len(tokens) {
+		return nil, 0, tokenError(tokens[start], "unclosed %s", in)
+	}
+	return body, i + 1, nil
+}
 
+func parseIf(tokens []*Token, start int) (*IfNode, int, error) {
+	// tokens[start] is TIf, followed by branches up to TEndIf:
+	//   body (elseif body)* (else body)? /if
+	cond, err := parseCondition(tokens[start])
+	if err != nil {
+		return nil, 0, err
+	}
+	node := &IfNode{Else: []Node{}, nodePos: posOf(tokens[start])}
+	branch := IfBranch{Expr: tokens[start].Value, cond: cond}
 	i := start + 1
-	for i < len(tokens) {
-		t := tokens[i]
+	for {
+		body, ni, err := parseBody(tokens, i, "if", TElseIf, TElse, TEndIf)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ni == len(tokens) {
+			return nil, 0, tokenError(tokens[start], "unclosed if")
+		}
+		branch.Body = body
+		node.Branches = append(node.Branches, branch)
+		t := tokens[ni]
 		switch t.Type {
-		case TIf:
-			// nested if: append token as part of body by compiling nested structure
-			nested, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			*currentBody = append(*currentBody, nested)
-			i = ni
-			continue
 		case TEndIf:
-			if depth == 0 {
-				// finish
-				root.Branches = branches
-				root.Else = elseBody
-				return root, i + 1, nil
-			}
-			depth--
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-		case TElseIf:
-			if depth == 0 {
-				branches = append(branches, IfBranch{Expr: t.Value, Body: []Node{}})
-				currentBody = &branches[len(branches)-1].Body
-				i++
-				continue
-			}
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
+			return node, ni + 1, nil
 		case TElse:
-			if depth == 0 {
-				elseBody = []Node{}
-				currentBody = &elseBody
-				i++
-				continue
-			}
-			*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-		case TFor:
-			fnode, ni, err := parseFor(tokens, i)
+			body, ni, err := parseBody(tokens, ni+1, "if", TElseIf, TElse, TEndIf)
 			if err != nil {
 				return nil, 0, err
 			}
-			*currentBody = append(*currentBody, fnode)
-			i = ni
-			continue
-		case TSwitch:
-			snode, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
+			if ni == len(tokens) {
+				return nil, 0, tokenError(tokens[start], "unclosed if")
 			}
-			*currentBody = append(*currentBody, snode)
-			i = ni
-			continue
-		default:
-			// Text or Var
-			switch t.Type {
-			case TText:
-				*currentBody = append(*currentBody, &TextNode{Text: t.Value})
-			case TVar:
-				*currentBody = append(*currentBody, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token inside if: %v", t.Type)
+			if tokens[ni].Type != TEndIf {
+				return nil, 0, tokenError(tokens[ni], "unexpected %v tag after else", tokens[ni].Type)
 			}
-			i++
+			node.Else = body
+			return node, ni + 1, nil
+		}
+		// TElseIf
+		if cond, err = parseCondition(t); err != nil {
+			return nil, 0, err
 		}
+		branch = IfBranch{Expr: t.Value, cond: cond}
+		i = ni + 1
+	}
+}
+
+// parseCondition: compiles the expression of an if/elseif/switch token.
+func parseCondition(t *Token) (Expr, error) {
+	x, err := parseExpr(t.Value)
+	if err != nil {
+		return nil, tokenError(t, "invalid expression in %q: %w", t.Raw, err)
 	}
-	return nil, 0, fmt.Errorf("unclosed if starting at token %d", start)
+	return x, nil
 }
 
 func parseFor(tokens []*Token, start int) (*ForNode, int, error) {
 	// tokens[start] is TFor with Value like "idx, item:listExpr" or "item:listExpr"
 	parts := strings.SplitN(tokens[start].Value, ":", 2)
 	if len(parts) != 2 {
-		return nil, 0, fmt.Errorf("invalid for tag: %s", tokens[start].Raw)
+		return nil, 0, tokenError(tokens[start], "invalid for tag: %s", tokens[start].Raw)
 	}
 	left := strings.TrimSpace(parts[0])
 	listExpr := strings.TrimSpace(parts[1])
@@ -247,140 +559,187 @@ func parseFor(tokens []*Token, start int) (*ForNode, int, error) {
 		itemVar = left
 	}
 
-	node := &ForNode{IndexVar: indexVar, ItemVar: itemVar, ListExpr: listExpr, Body: []Node{}}
-	i := start + 1
-	depth := 0
-	for i < len(tokens) {
-		t := tokens[i]
-		switch t.Type {
-		case TFor:
-			// nested for
-			nf, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, nf)
-			i = ni
-			continue
-		case TEndFor:
-			if depth == 0 {
-				return node, i + 1, nil
-			}
-			depth--
-			node.Body = append(node.Body, &TextNode{Text: t.Value})
-		case TIf:
-			ifn, ni, err := parseIf(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, ifn)
-			i = ni
-			continue
-		case TSwitch:
-			sn, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			node.Body = append(node.Body, sn)
-			i = ni
-			continue
-		default:
-			switch t.Type {
-			case TText:
-				node.Body = append(node.Body, &TextNode{Text: t.Value})
-			case TVar:
-				node.Body = append(node.Body, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token in for: %v", t.Type)
-			}
-			i++
+	lc := splitLoop(listExpr)
+	list, err := parseExpr(lc.list)
+	if err != nil {
+		return nil, 0, tokenError(tokens[start], "invalid expression in %q: %w", tokens[start].Raw, err)
+	}
+	node := &ForNode{IndexVar: indexVar, ItemVar: itemVar, ListExpr: lc.list, Where: lc.where, SortBy: lc.sortBy, Desc: lc.desc, nodePos: posOf(tokens[start]), list: list}
+	if lc.where != "" {
+		if node.where, err = parseExpr(lc.where); err != nil {
+			return nil, 0, tokenError(tokens[start], "invalid where clause in %q: %w", tokens[start].Raw, err)
+		}
+	}
+	if lc.sortBy != "" {
+		if node.sortBy, err = parseExpr(lc.sortBy); err != nil {
+			return nil, 0, tokenError(tokens[start], "invalid sort clause in %q: %w", tokens[start].Raw, err)
 		}
 	}
-	return nil, 0, fmt.Errorf("unclosed for starting at token %d", start)
+	body, i, err := parseClosed(tokens, start, "for", TEndFor)
+	if err != nil {
+		return nil, 0, err
+	}
+	node.Body = body
+	return node, i, nil
 }
 
 func parseSwitch(tokens []*Token, start int) (*SwitchNode, int, error) {
-	node := &SwitchNode{Expr: tokens[start].Value, Cases: []SwitchCase{}, Default: []Node{}}
-	i := start + 1
-	depth := 0
-	currentCond := ""
-	currentBody := []Node{}
-
-	flushCase := func() {
-		if currentCond != "" {
-			node.Cases = append(node.Cases, SwitchCase{Cond: currentCond, Body: currentBody})
-		} else if currentBody != nil && len(currentBody) > 0 {
-			node.Default = currentBody
+	// tokens[start] is TSwitch, followed by (case body | default body)* /switch;
+	// only whitespace may come before the first case
+	expr, err := parseCondition(tokens[start])
+	if err != nil {
+		return nil, 0, err
+	}
+	node := &SwitchNode{Expr: tokens[start].Value, Cases: []SwitchCase{}, Default: []Node{}, nodePos: posOf(tokens[start]), expr: expr}
+	body, i, err := parseBody(tokens, start+1, "switch", TCase, TDefault, TEndSwitch)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, n := range body {
+		if t, ok := n.(*TextNode); !ok || strings.TrimSpace(t.Text) != "" {
+			return nil, 0, tokenError(tokens[start], "content before the first case of switch: %s", tokens[start].Raw)
 		}
-		currentCond = ""
-		currentBody = []Node{}
 	}
-
+	hasDefault := false
 	for i < len(tokens) {
 		t := tokens[i]
-		switch t.Type {
-		case TSwitch:
-			// nested
-			nn, ni, err := parseSwitch(tokens, i)
-			if err != nil {
-				return nil, 0, err
-			}
-			currentBody = append(currentBody, nn)
-			i = ni
-			continue
-		case TEndSwitch:
-			if depth == 0 {
-				flushCase()
-				return node, i + 1, nil
-			}
-			depth--
-			currentBody = append(currentBody, &TextNode{Text: t.Value})
-		case TCase:
-			if depth == 0 {
-				// finish previous
-				flushCase()
-				currentCond = t.Value
-				currentBody = []Node{}
-				i++
-				continue
-			}
-			currentBody = append(currentBody, &TextNode{Text: t.Value})
-		case TDefault:
-			if depth == 0 {
-				flushCase()
-				currentCond = ""
-				currentBody = []Node{}
-				i++
-				continue
+		if t.Type == TEndSwitch {
+			return node, i + 1, nil
+		}
+		body, ni, err := parseBody(tokens, i+1, "switch", TCase, TDefault, TEndSwitch)
+		if err != nil {
+			return nil, 0, err
+		}
+		if t.Type == TDefault {
+			if hasDefault {
+				return nil, 0, tokenError(t, "duplicate default in switch")
 			}
-			currentBody = append(currentBody, &TextNode{Text: t.Value})
-		case TIf:
-			in, ni, err := parseIf(tokens, i)
+			hasDefault = true
+			node.Default = body
+		} else {
+			cond, err := parseExpr(caseSource(t.Value))
 			if err != nil {
-				return nil, 0, err
+				return nil, 0, tokenError(t, "invalid expression in %q: %w", t.Raw, err)
 			}
-			currentBody = append(currentBody, in)
-			i = ni
-			continue
-		case TFor:
-			fn, ni, err := parseFor(tokens, i)
-			if err != nil {
-				return nil, 0, err
+			node.Cases = append(node.Cases, SwitchCase{Cond: t.Value, Body: body, cond: cond})
+		}
+		i = ni
+	}
+	return nil, 0, tokenError(tokens[start], "unclosed switch")
+}
+
+// loopClauses: the parts of `list [where cond] [sort by key [asc|desc]]`.
+type loopClauses struct {
+	list, where, sortBy string
+	desc                bool
+}
+
+// splitLoop: the list expression of a for tag and its clauses.
+func splitLoop(src string) loopClauses {
+	lc := loopClauses{list: src}
+	toks, err := lexExpr(src)
+	if err != nil {
+		return lc
+	}
+	where, sort := -1, -1 // token indexes of the clause keywords
+	depth := 0
+	for i, t := range toks {
+		if t.kind == etPunct {
+			switch t.val {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
 			}
-			currentBody = append(currentBody, fn)
-			i = ni
+		}
+		if depth != 0 || i == 0 || t.kind != etIdent {
 			continue
+		}
+		switch {
+		case t.val == "where" && where < 0 && sort < 0 && toks[i+1].kind != etEOF:
+			where = i
+		case t.val == "sort" && sort < 0 && toks[i+1].kind == etIdent && toks[i+1].val == "by" && toks[i+2].kind != etEOF:
+			sort = i
+		}
+	}
+	end := len(src)
+	if sort >= 0 {
+		key := src[toks[sort+1].pos+len("by"):]
+		if last := toks[len(toks)-2]; last.kind == etIdent && (last.val == "asc" || last.val == "desc") && len(toks)-2 > sort+2 {
+			key = src[toks[sort+1].pos+len("by") : last.pos]
+			lc.desc = last.val == "desc"
+		}
+		lc.sortBy = strings.TrimSpace(key)
+		end = toks[sort].pos
+	}
+	if where >= 0 {
+		lc.where = strings.TrimSpace(src[toks[where].pos+len("where") : end])
+		end = toks[where].pos
+	}
+	lc.list = strings.TrimSpace(src[:end])
+	return lc
+}
+
+func parseBlock(tokens []*Token, start int) (*BlockNode, int, error) {
+	// tokens[start] is TBlock with Value `"name"`
+	name, ok := literalFromString(tokens[start].Value).(string)
+	if !ok || name == "" {
+		return nil, 0, tokenError(tokens[start], "invalid block tag: %s", tokens[start].Raw)
+	}
+	body, i, err := parseBody(tokens, start+1, "block", TEndBlock)
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == len(tokens) {
+		return nil, 0, tokenError(tokens[start], "unclosed block %q", name)
+	}
+	return &BlockNode{Name: name, Body: body}, i + 1, nil
+}
+
+func parseCache(tokens []*Token, start int) (*CacheNode, int, error) {
+	// tokens[start] is TCache with Value `keyExpr [vary=[...]] [per=5m] [ttl=1h] [stale=10m]`
+	args, kwargs, err := parseTagArgs(tokens[start].Value)
+	if err != nil || len(args) != 1 {
+		return nil, 0, tokenError(tokens[start], "invalid cache tag: %s", tokens[start].Raw)
+	}
+	node := &CacheNode{Key: tokens[start].Value, nodePos: posOf(tokens[start]), key: args[0]}
+	for _, kw := range kwargs {
+		switch kw.name {
+		case "vary":
+			node.vary = kw.val
+		case "per":
+			node.per = kw.val
+		case "ttl":
+			node.ttl = kw.val
+		case "stale":
+			node.stale = kw.val
 		default:
-			switch t.Type {
-			case TText:
-				currentBody = append(currentBody, &TextNode{Text: t.Value})
-			case TVar:
-				currentBody = append(currentBody, &VarNode{Name: t.Value, Default: t.Default})
-			default:
-				return nil, 0, fmt.Errorf("unexpected token in switch: %v", t.Type)
-			}
-			i++
+			return nil, 0, tokenError(tokens[start], "unknown cache option %q in: %s", kw.name, tokens[start].Raw)
 		}
 	}
-	return nil, 0, fmt.Errorf("unclosed switch starting at token %d", start)
+
+	body, i, err := parseClosed(tokens, start, "cache", TEndCache)
+	if err != nil {
+		return nil, 0, err
+	}
+	node.Body = body
+	return node, i, nil
+}
+
+func parseInclude(t *Token) (*IncludeNode, error) {
+	// t.Value is `"path" [name=expr ...]`
+	args, kwargs, err := parseTagArgs(t.Value)
+	if err != nil || len(args) != 1 {
+		return nil, tokenError(t, "invalid include tag: %s", t.Raw)
+	}
+	lit, ok := args[0].(*litExpr)
+	if !ok {
+		// dynamic include, the name is known at render time
+		return &IncludeNode{nodePos: posOf(t), vars: kwargs, path: args[0]}, nil
+	}
+	path, _ := lit.val.(string)
+	if path == "" {
+		return nil, tokenError(t, "include path must be a string: %s", t.Raw)
+	}
+	return &IncludeNode{Path: path, nodePos: posOf(t), vars: kwargs}, nil
 }
@@ -0,0 +1,143 @@
+package vingo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// renderSource: src compiled as a template of e and rendered with data.
+func renderSource(e *Engine, src string, data map[string]interface{}) (string, error) {
+	tpl, err := e.compile(e.resolve("test.vgo"), src, "", nil)
+	if err != nil {
+		return "", err
+	}
+	return e.execute(context.Background(), tpl.Nodes, tpl.size, "", data)
+}
+
+func TestKeywordAsVariable(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		data map[string]interface{}
+		want string
+	}{
+		{"block filtered", `<{ block | upper }>`, map[string]interface{}{"block": "main"}, "MAIN"},
+		{"block field", `<{ block.Name }>`, map[string]interface{}{"block": map[string]interface{}{"Name": "main"}}, "main"},
+		{"block tag", `<{ block "a" }>x<{ /block }>`, nil, "x"},
+		{"row outside csv", `<{ for row in items | batch:2 }><{ row | join:"," }>;<{ /for }>`, map[string]interface{}{"items": []int{1, 2, 3}}, "1,2;3;"},
+		{"row tag", `<{ csv }><{ row "a" "b,c" }><{ /csv }>`, nil, "a,\"b,c\"\n"},
+		{"row variable in csv", `<{ csv }><{ row | upper }><{ /csv }>`, map[string]interface{}{"row": "x"}, ""},
+		{"nested csv", `<{ csv delimiter=";" }><{ csv }><{ row "a" "b" }><{ /csv }><{ /csv }>`, nil, "a;b\n"},
+	}
+	for _, word := range []string{"block", "cache", "include", "csv", "row", "section", "yield", "component", "slot", "once", "params", "import"} {
+		tests = append(tests, struct {
+			name string
+			src  string
+			data map[string]interface{}
+			want string
+		}{word + " filtered", `<{ ` + word + ` | upper }>`, map[string]interface{}{word: "x"}, "X"})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderSource(New(), tt.src, tt.data)
+			if err != nil {
+				t.Fatalf("render %q: %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("render %q = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownTags(t *testing.T) {
+	e := New()
+	for src, want := range map[string]string{
+		`a<{ iff x }>b`:                   "a<{iff x}>b",
+		`<{ if x }>a<{ endif }><{ /if }>`: "a",
+	} {
+		got, err := renderSource(e, src, map[string]interface{}{"x": true})
+		if err != nil || got != want {
+			t.Errorf("render %q = %q, %v; want %q", src, got, err, want)
+		}
+	}
+
+	e.Strict = true
+	for _, src := range []string{`a<{ iff x }>b`, `<{ if x }>a<{ endif }><{ /if }>`} {
+		if _, err := renderSource(e, src, nil); err == nil {
+			t.Errorf("strict render %q: no error", src)
+		}
+	}
+}
+
+func TestNestedBlocks(t *testing.T) {
+	e := New()
+	e.Root = t.TempDir()
+	card := `[<{ title }>:<{ slot() }>|<{ slot("footer") }>]`
+	if err := os.MkdirAll(filepath.Join(e.Root, "components"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(e.Root, "components", "card.vgo"), []byte(card), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"Name": "a", "Kind": "x", "On": true},
+			map[string]interface{}{"Name": "b", "Kind": "y", "On": false},
+		},
+	}
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string // substring of the error, "" = no error
+	}{
+		{"if in for", `<{ for it in items }><{ if it.On }><{ it.Name }><{ else }>-<{ /if }><{ /for }>`, "a-", ""},
+		{"for in if", `<{ if items }><{ for it in items }><{ it.Name }><{ /for }><{ /if }>`, "ab", ""},
+		{"switch in for", `<{ for it in items }><{ switch it.Kind }><{ case "x" }>X<{ default }>?<{ /switch }><{ /for }>`, "X?", ""},
+		{"for in switch", `<{ switch 1 }><{ case 1 }><{ for it in items }><{ it.Name }><{ /for }><{ /switch }>`, "ab", ""},
+		{"if in switch in for", `<{ for it in items }><{ switch it.Kind }><{ case "x" }><{ if it.On }>on<{ /if }><{ case "y" }>y<{ /switch }><{ /for }>`, "ony", ""},
+		{"elseif chain in for", `<{ for it in items }><{ if it.Kind == "y" }>Y<{ elseif it.On }>O<{ else }>-<{ /if }><{ /for }>`, "OY", ""},
+		{"block in for", `<{ for it in items }><{ block "row" }><{ it.Name }><{ /block }><{ /for }>`, "ab", ""},
+		{"for in block", `<{ block "list" }><{ for it in items }><{ it.Name }>,<{ /for }><{ /block }>`, "a,b,", ""},
+		{"nested blocks", `<{ block "outer" }>(<{ block "inner" }>i<{ /block }>)<{ /block }>`, "(i)", ""},
+		{"component in for", `<{ for it in items }><{ component "card" title=it.Name }><{ it.Kind }><{ /component }><{ /for }>`, "[a:x|][b:y|]", ""},
+		{"for in slot", `<{ component "card" title="t" }><{ slot "footer" }><{ for it in items }><{ it.Name }><{ /for }><{ /slot }><{ /component }>`, "[t:|ab]", ""},
+		{"if in component body", `<{ component "card" title="t" }><{ if items }>yes<{ /if }><{ /component }>`, "[t:yes|]", ""},
+		{"component in block in if", `<{ if items }><{ block "b" }><{ component "card" title="t" }>c<{ /component }><{ /block }><{ /if }>`, "[t:c|]", ""},
+
+		{"unclosed if", `<{ if x }>a`, "", "unclosed if"},
+		{"unclosed for in if", `<{ if x }><{ for it in items }>a<{ /if }>`, "", "unexpected"},
+		{"unclosed switch", `<{ switch x }><{ case 1 }>a`, "", "unclosed switch"},
+		{"unclosed block in for", `<{ for it in items }><{ block "b" }><{ /for }>`, "", "unexpected"},
+		{"unclosed component", `<{ component "card" }>a`, "", "unclosed component"},
+		{"stray closer", `a<{ /for }>`, "", "unexpected"},
+		{"closer of outer block", `<{ for it in items }><{ if x }><{ /for }><{ /if }>`, "", "unexpected"},
+		{"else outside if", `<{ for it in items }><{ else }><{ /for }>`, "", "unexpected"},
+		{"case outside switch", `<{ if x }><{ case 1 }><{ /if }>`, "", "unexpected"},
+		{"content before case", `<{ switch x }>a<{ case 1 }><{ /switch }>`, "", "content before the first case"},
+		{"duplicate default", `<{ switch x }><{ default }>a<{ default }>b<{ /switch }>`, "", "duplicate default"},
+		{"elseif after else", `<{ if x }>a<{ else }>b<{ elseif y }>c<{ /if }>`, "", "after else"},
+		{"slot outside component in for", `<{ for it in items }><{ slot "s" }>a<{ /slot }><{ /for }>`, "", "outside of a component"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderSource(e, tt.src, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("render %q: error %v, want one containing %q", tt.src, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render %q: %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("render %q = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
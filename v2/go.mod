@@ -0,0 +1,14 @@
+module github.com/coderiantest/vingo/v2
+
+go 1.24.4
+
+require github.com/coderiantest/vingo v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/fsnotify/fsnotify v1.10.1 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/coderiantest/vingo => ../
@@ -0,0 +1,292 @@
+// Package vingo is the stable API of vingo: a small, curated surface over
+// the engine that downstream projects can depend on while the internals of
+// github.com/coderiantest/vingo keep evolving.
+//
+//	env, err := vingo.New(vingo.Options{
+//		Root:    "templates",
+//		Filters: map[string]vingo.Filter{"shout": shout},
+//	})
+//	...
+//	tpl, err := env.Template("index.vgo") // compiled, syntax errors surface here
+//	...
+//	err = tpl.Execute(r.Context(), w, data)
+//
+// Every type of this package is its own: none of the engine's types appear
+// in its API, so changes to them don't reach code written against v2.
+// Render is the package-level shortcut of the v1 API, kept for code moving
+// over from it.
+package vingo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	engine "github.com/coderiantest/vingo"
+)
+
+// -------------------- Stable API --------------------
+
+// Loader: reads template sources, by the resolved path of the template.
+// Compiled templates are cached per loader and path, so a Loader must be
+// comparable (a pointer or a struct of comparable fields).
+type Loader interface {
+	// ModTime: modification time of path, compared to decide recompiles.
+	ModTime(path string) (time.Time, error)
+	// ReadFile: source of the template at path.
+	ReadFile(path string) ([]byte, error)
+}
+
+// Filter: a template function, usable as `x | name:arg` or name(x, arg).
+// value is the piped value (the first argument of a call), args the rest.
+type Filter func(value interface{}, args ...interface{}) (interface{}, error)
+
+// Limits: bounds of a single render (zero fields = no limit). A render
+// exceeding one returns a *LimitError.
+type Limits struct {
+	MaxOutputBytes  int           // size of the rendered output
+	MaxIterations   int           // loop iterations, all loops together
+	MaxIncludeDepth int           // nesting of includes
+	MaxRenderTime   time.Duration // wall time of the render
+	MaxOperations   int           // node evaluations, operators and calls
+}
+
+// Options: configuration of an Env.
+type Options struct {
+	// Root: relative template names are resolved against this directory
+	// ("" = working directory). When set, templates are kept inside it:
+	// names and includes escaping it with ../ and absolute include paths
+	// are errors.
+	Root string
+
+	// AllowOutsideRoot: lifts the Root restriction, for trusted templates
+	// that use Root only to resolve relative names.
+	AllowOutsideRoot bool
+
+	// Loader: reads template sources (nil = from disk).
+	Loader Loader
+
+	// Filters: template functions by name; they shadow built-in ones.
+	Filters map[string]Filter
+
+	// Globals: constants folded into templates at compile time.
+	Globals map[string]interface{}
+
+	// Limits: output size, loop, include depth and time limits of a render.
+	Limits Limits
+
+	// Strict: unknown tags and misspelled closing tags (<{ endif }>) are
+	// compile errors instead of being written as text.
+	Strict bool
+}
+
+// Env: a configured template environment with its own compiled template
+// cache. Safe for concurrent use.
+type Env struct {
+	engine *engine.Engine
+}
+
+// filterName: names a filter can be called by in templates.
+var filterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// New: an Env configured by opts.
+func New(opts Options) (*Env, error) {
+	e := engine.New()
+	e.Root = opts.Root
+	e.AllowOutsideRoot = opts.AllowOutsideRoot
+	if opts.Loader != nil {
+		e.Loader = opts.Loader
+	}
+	e.Limits = engine.Limits{
+		MaxOutputBytes:  opts.Limits.MaxOutputBytes,
+		MaxIterations:   opts.Limits.MaxIterations,
+		MaxIncludeDepth: opts.Limits.MaxIncludeDepth,
+		MaxRenderTime:   opts.Limits.MaxRenderTime,
+		MaxOperations:   opts.Limits.MaxOperations,
+	}
+	e.Strict = opts.Strict
+	for name, f := range opts.Filters {
+		if !filterName.MatchString(name) {
+			return nil, fmt.Errorf("vingo: invalid filter name %q", name)
+		}
+		if f == nil {
+			return nil, fmt.Errorf("vingo: filter %q is nil", name)
+		}
+		e.AddFunc(name, filterFunc(f))
+	}
+	for name, v := range opts.Globals {
+		e.SetConst(name, v)
+	}
+	return &Env{engine: e}, nil
+}
+
+// filterFunc: f as an engine function.
+func filterFunc(f Filter) engine.Func {
+	return func(c *engine.Call) (interface{}, error) {
+		if len(c.Args) == 0 {
+			return f(nil)
+		}
+		return f(c.Args[0], c.Args[1:]...)
+	}
+}
+
+// Template: the template called name, compiled.
+func (env *Env) Template(name string) (*Template, error) {
+	if err := env.engine.Compile(name); err != nil {
+		return nil, convertError(err)
+	}
+	return &Template{env: env, name: name}, nil
+}
+
+// Render: renders the template called name with data.
+func (env *Env) Render(ctx context.Context, name string, data map[string]interface{}) (string, error) {
+	out, err := env.engine.RenderContext(ctx, name, data)
+	return out, convertError(err)
+}
+
+// Execute: renders the template called name with data into w. Nothing is
+// written if the render fails.
+func (env *Env) Execute(ctx context.Context, w io.Writer, name string, data map[string]interface{}) error {
+	out, err := env.Render(ctx, name, data)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// Template: a compiled template of an Env. It follows changes of the
+// source: a modified file is recompiled on the next render.
+type Template struct {
+	env  *Env
+	name string
+}
+
+// Name: the name the template was loaded by.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Render: renders the template with data.
+func (t *Template) Render(ctx context.Context, data map[string]interface{}) (string, error) {
+	return t.env.Render(ctx, t.name, data)
+}
+
+// Execute: renders the template with data into w. Nothing is written if the
+// render fails.
+func (t *Template) Execute(ctx context.Context, w io.Writer, data map[string]interface{}) error {
+	return t.env.Execute(ctx, w, t.name, data)
+}
+
+// RenderBlock: renders only the <{ block "name" }> of the template.
+func (t *Template) RenderBlock(ctx context.Context, block string, data map[string]interface{}) (string, error) {
+	out, err := t.env.engine.RenderBlockContext(ctx, t.name, block, data)
+	return out, convertError(err)
+}
+
+// Tree: the syntax tree of the template source.
+func (t *Template) Tree() (*Node, error) {
+	n, err := t.env.engine.ParseAST(t.name)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return convertNode(n), nil
+}
+
+// -------------------- Legacy shim --------------------
+
+// defaultEnv: the Env of Render, rooted at the working directory.
+var defaultEnv, _ = New(Options{})
+
+// Render: renders file with data, like the package-level Render of the v1
+// API; a one-line change for code moving to v2.
+func Render(file string, data map[string]interface{}) (string, error) {
+	return defaultEnv.Render(context.Background(), file, data)
+}
+
+// -------------------- Syntax tree --------------------
+
+// Pos: position in a template source; Line and Col are 1-based, Col and
+// Offset count bytes.
+type Pos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+// Node: one node of the syntax tree of a template, as returned by
+// Template.Tree.
+//
+// Type is "template" (the root), "text", "var", "if", "branch" (the if /
+// elseif parts of an if), "else", "for", "switch", "case", "default",
+// "block", "cache", "csv", "row", "section", "yield", "component", "slot",
+// "once", "include", "test", "params" or "import"; Attrs holds the
+// arguments of the tag by name ("expr", "cond", "name", "args"...). New
+// node types may be added.
+type Node struct {
+	Type     string            `json:"type"`
+	Pos      Pos               `json:"pos"`
+	End      *Pos              `json:"end,omitempty"` // closing tag of block tags
+	Raw      string            `json:"raw,omitempty"` // tag source between <{ and }>
+	Text     string            `json:"text,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []*Node           `json:"children,omitempty"`
+}
+
+// convertNode: n and its children as Nodes.
+func convertNode(n *engine.ASTNode) *Node {
+	out := &Node{
+		Type:  n.Type,
+		Pos:   Pos{Offset: n.Pos.Offset, Line: n.Pos.Line, Col: n.Pos.Col},
+		Raw:   n.Raw,
+		Text:  n.Text,
+		Attrs: n.Attrs,
+	}
+	if n.End != nil {
+		out.End = &Pos{Offset: n.End.Offset, Line: n.End.Line, Col: n.End.Col}
+	}
+	for _, c := range n.Children {
+		out.Children = append(out.Children, convertNode(c))
+	}
+	return out
+}
+
+// -------------------- Errors --------------------
+
+// SyntaxError: a template that does not compile.
+type SyntaxError struct {
+	Line, Col int // 1-based; Col counts bytes
+	Err       error
+
+	msg string // message of the engine, with the path of the template
+}
+
+func (e *SyntaxError) Error() string { return e.msg }
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// LimitError: a render exceeded one of the Options.Limits.
+type LimitError struct {
+	Limit string // name of the Limits field
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("vingo: render exceeded %s", e.Limit)
+}
+
+// convertError: err of the engine with its error types replaced by the
+// ones of this package.
+func convertError(err error) error {
+	var syntax *engine.SyntaxError
+	if errors.As(err, &syntax) {
+		return &SyntaxError{Line: syntax.Line, Col: syntax.Col, Err: syntax.Err, msg: err.Error()}
+	}
+	var limit *engine.LimitError
+	if errors.As(err, &limit) {
+		return &LimitError{Limit: limit.Limit}
+	}
+	return err
+}
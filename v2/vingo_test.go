@@ -0,0 +1,120 @@
+package vingo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newEnv: an Env rooted at a new directory holding files (name -> source).
+func newEnv(t *testing.T, opts Options, files map[string]string) *Env {
+	t.Helper()
+	opts.Root = t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(opts.Root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestNewFilters(t *testing.T) {
+	shout := func(v interface{}, args ...interface{}) (interface{}, error) {
+		return strings.ToUpper(v.(string)) + "!", nil
+	}
+	for _, name := range []string{"", "1st", "a-b", "a b", "a.b"} {
+		if _, err := New(Options{Filters: map[string]Filter{name: shout}}); err == nil {
+			t.Errorf("New with filter %q: no error", name)
+		}
+	}
+	if _, err := New(Options{Filters: map[string]Filter{"shout": nil}}); err == nil {
+		t.Error("New with a nil filter: no error")
+	}
+
+	env := newEnv(t, Options{Filters: map[string]Filter{"shout": shout}}, map[string]string{
+		"page.vgo": `<{ name | shout }> <{ shout(name) }>`,
+	})
+	if got, err := env.Render(context.Background(), "page.vgo", map[string]interface{}{"name": "ali"}); err != nil || got != "ALI! ALI!" {
+		t.Errorf("Render = %q, %v; want %q", got, err, "ALI! ALI!")
+	}
+}
+
+func TestTemplateSyntaxError(t *testing.T) {
+	env := newEnv(t, Options{}, map[string]string{"bad.vgo": "line one\n  <{ if x }>"})
+	_, err := env.Template("bad.vgo")
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Fatalf("Template = %v (%T), want a *SyntaxError", err, err)
+	}
+	if serr.Line != 2 || serr.Col != 3 {
+		t.Errorf("SyntaxError at %d:%d, want 2:3", serr.Line, serr.Col)
+	}
+	if !strings.Contains(serr.Error(), "bad.vgo") || serr.Unwrap() == nil {
+		t.Errorf("SyntaxError = %q, Unwrap %v", serr.Error(), serr.Unwrap())
+	}
+}
+
+func TestLimitError(t *testing.T) {
+	env := newEnv(t, Options{Limits: Limits{MaxIterations: 2}}, map[string]string{
+		"loop.vgo": `<{ for x in xs }><{ x }><{ /for }>`,
+	})
+	tpl, err := env.Template("loop.vgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = tpl.Execute(context.Background(), &buf, map[string]interface{}{"xs": []int{1, 2, 3}})
+	var lerr *LimitError
+	if !errors.As(err, &lerr) || lerr.Limit != "MaxIterations" {
+		t.Errorf("Execute = %v (%T), want a *LimitError for MaxIterations", err, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Execute wrote %q for a failed render", buf.String())
+	}
+}
+
+func TestTree(t *testing.T) {
+	env := newEnv(t, Options{}, map[string]string{"page.vgo": "Hi <{ name }>\n<{ if ok }>y<{ /if }>"})
+	tpl, err := env.Template("page.vgo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := tpl.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Type != "template" || len(root.Children) < 3 {
+		t.Fatalf("Tree = %+v", root)
+	}
+	text, v, cond := root.Children[0], root.Children[1], root.Children[3]
+	if text.Type != "text" || text.Text != "Hi " {
+		t.Errorf("first node = %+v, want the text %q", text, "Hi ")
+	}
+	if v.Type != "var" || v.Attrs["expr"] != "name" || v.Pos != (Pos{Offset: 3, Line: 1, Col: 4}) {
+		t.Errorf("second node = %+v, want the var name at 1:4", v)
+	}
+	if cond.Type != "if" || cond.End == nil || cond.End.Line != 2 {
+		t.Errorf("if node = %+v, want an if closed on line 2", cond)
+	}
+}
+
+func TestRenderShim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.vgo")
+	if err := os.WriteFile(path, []byte(`Hi <{ name }>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Render(path, map[string]interface{}{"name": "Ayşe"}); err != nil || got != "Hi Ayşe" {
+		t.Errorf("Render = %q, %v; want %q", got, err, "Hi Ayşe")
+	}
+	if _, err := Render(filepath.Join(filepath.Dir(path), "missing.vgo"), nil); err == nil {
+		t.Error("Render of a missing file: no error")
+	}
+}
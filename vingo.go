@@ -1,11 +1,15 @@
 package vingo
 
 import (
-	"os"
+	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
 // -------------------- Public API --------------------
@@ -14,72 +18,437 @@ type Template struct {
 	Filepath string
 	Nodes    []Node
 	ModTime  time.Time
+
+	size  int                  // kaynağın uzunluğu, çıktı buffer'ının ilk boyu
+	deps  map[string]time.Time // gömülen include'lar -> mod time
+	tests []*TestNode          // <{ test }> tag'leri, Nodes'tan çıkarılmış
+	decls []*DeclNode          // <{ params }> / <{ import }> tag'leri, Nodes'tan çıkarılmış
 }
 
-var (
-	// cache: filepath -> compiled template
-	tplCache   = map[string]*Template{}
-	cacheMutex sync.RWMutex
-)
+// Engine: compile edilmiş template cache'i ve render ayarları.
+// New ile oluşturulur; birden fazla goroutine tarafından kullanılabilir.
+type Engine struct {
+	// Root: relative template isimleri bu klasöre göre çözülür ("" = çalışma klasörü).
+	Root string
+
+	// Loader: template kaynaklarını okur (nil = FileLoader, diskten).
+	Loader Loader
+
+	// CacheLimits: compile edilmiş template cache'inin sınırları
+	// (sıfır = sınırsız); aşılınca en uzun süre kullanılmayan atılır.
+	CacheLimits CacheLimits
+
+	// OnChange: Watch, değişen bir dosya cache'teki bir template'i
+	// geçersiz kıldığında bu fonksiyonu dosyanın path'iyle çağırır.
+	OnChange func(path string)
+
+	// OnWatchError: Watch'ın dosya izleyicisinin hataları (ör. kaçırılan
+	// event'ler) bu fonksiyona verilir, Watch izlemeye devam eder; nil ise
+	// hatalar log'a yazılır.
+	OnWatchError func(err error)
+
+	// Output: render çıktısının satır sonu, BOM ve charset dönüşümü.
+	Output OutputOptions
+
+	// ResizeImage: image() helper'ının srcset URL'lerini üretir
+	// (nil = "hero.jpg" -> "hero-480w.jpg" isimlendirmesi).
+	ResizeImage ImageResizer
+
+	// Assets: asset(), script(), stylesheet() ayarları.
+	Assets AssetOptions
+
+	// InlineIncludes: bu boyuta (byte) kadar olan include'lar compile
+	// sırasında include eden template'e gömülür (0 = kapalı).
+	InlineIncludes int
+
+	// BuildTags: <{ if build "enterprise" }> koşullarında açık olan tag'ler;
+	// compile sırasında çözülür, ilk render'dan önce ayarlanmalı.
+	BuildTags []string
+
+	// Strict: tanınmayan tag'ler (ör. <{ iff x }>) ve kapanış tag'i yerine
+	// yazılmış <{ endif }>, <{ endfor }>... compile hatası verir. Kapalıyken
+	// (varsayılan) tanınmayan tag'ler olduğu gibi metin olarak çıktıya
+	// yazılır, <{ endif }> ise endif değişkeni olarak boş çıktı verir.
+	Strict bool
+
+	// FieldTag: struct alanlarına Go isimlerinin yanında bu struct tag'indeki
+	// isimle de erişilir (ör. "json" ile `json:"first_name"` -> user.first_name);
+	// `vingo:"..."` tag'i her zaman geçerlidir. İlk render'dan önce ayarlanmalı.
+	FieldTag string
+
+	// Clock: now() ve today() helper'larının saati (nil = time.Now);
+	// testlerde sabit bir zaman vermek için.
+	Clock func() time.Time
+
+	// Truthy: <{ if x }> koşullarında x'in doğruluğunu belirler; ok=false
+	// dönerse varsayılan kurallar (Truther, sql.Null*, boş liste...) geçerli.
+	// Kendi tiplerini değiştiremeyen uygulamalar için (ör. decimal.Decimal).
+	Truthy func(v interface{}) (truthy, ok bool)
+
+	// AllowOutsideRoot: Root verilmişse template'ler Root dışından
+	// yüklenemez; Root'tan ../ ile kaçan isimler ve include'lar, mutlak
+	// include path'leri hata verir (kullanıcıların düzenlediği template'ler,
+	// multi-tenant uygulamalar). true bu sınırı kaldırır. Root boşsa sınır
+	// yoktur.
+	AllowOutsideRoot bool
+
+	// DynamicIncludes: isimleri render sırasında hesaplanan include'ların
+	// (<{ include "widgets/" + w.Type + ".vgo" }>) yükleyebileceği template'ler,
+	// Root'a göre path.Match pattern'leri (ör. "widgets/*.vgo"); boşsa
+	// dinamik include'lar hata verir.
+	DynamicIncludes []string
+
+	// Components: <{ component "modal" }> tag'lerinin template'lerinin
+	// Root'a göre dizini ("" = "components"; "modal" -> components/modal.vgo).
+	Components string
+
+	// Limits: tek bir render'ın çıktı boyutu, döngü, include derinliği ve
+	// süre sınırları (sıfır = sınırsız).
+	Limits Limits
+
+	// Fragments: <{ cache }> fragment'larının saklandığı yer
+	// (nil = engine'e ait in-memory store).
+	Fragments FragmentStore
+
+	// Flashes: flash() helper'ının tek seferlik mesajlarının kaynağı,
+	// genelde session (nil = mesaj yok).
+	Flashes FlashProvider
+
+	// Forms: input(), select() gibi form helper'larının ayarları.
+	Forms FormOptions
+
+	// FormGuard: honeypot() helper'ının ayarları; aynı FormGuard'ın
+	// Middleware'i formları doğrular (nil = honeypot() hata verir).
+	FormGuard *FormGuard
+
+	// OGImages: og_image() helper'ının template'i ve rasterizer'ı.
+	OGImages OGImageOptions
+
+	// I18n: <{ t "key" }> çevirilerinin kataloğu ve varsayılan locale'i.
+	I18n I18nOptions
+
+	// Markdown: markdown filtresi ve .md template'leri için renderer
+	// (nil = BasicMarkdown).
+	Markdown MarkdownRenderer
+
+	// PDF: RenderPDF'in HTML'i PDF'e çeviren converter'ı
+	// (ör. CommandPDFConverter ile wkhtmltopdf).
+	PDF PDFConverter
+
+	mu         sync.RWMutex
+	reloadMu   sync.RWMutex           // render'lar okumak, ReloadOnSignal'ın reload'u yazmak için tutar
+	cache      templateCache          // (loader, filepath) -> compile edilmiş template
+	funcs      map[string]Func        // AddFunc ile eklenen fonksiyonlar
+	consts     map[string]interface{} // SetConst ile eklenen sabitler
+	watcher    *fsnotify.Watcher
+	watched    map[string]bool // watcher'a eklenmiş klasörler
+	assets     assetCache
+	fragments  MemoryStore
+	refreshing sync.Map // arka planda yenilenen fragment key'leri
+	formatter  atomic.Pointer[Formatter]
+}
+
+// New: boş cache ile yeni bir Engine oluşturur.
+func New() *Engine {
+	return &Engine{}
+}
+
+// defaultEngine: package-level Render tarafından kullanılır.
+var defaultEngine = New()
+
+// Default: package-level Render fonksiyonlarının kullandığı engine.
+func Default() *Engine {
+	return defaultEngine
+}
 
 // Render: template dosyasını oku, compile et (gerekirse cache'den), ve işle
 func Render(file string, data map[string]interface{}) (string, error) {
-	abs, err := filepath.Abs(file)
+	return defaultEngine.Render(file, data)
+}
+
+// RenderContext: Render gibi, ama ctx iptal edilirse (ör. HTTP isteği kapandı)
+// render node'lar arasında durur ve ctx.Err() döner.
+func RenderContext(ctx context.Context, file string, data map[string]interface{}) (string, error) {
+	return defaultEngine.RenderContext(ctx, file, data)
+}
+
+// Render: template dosyasını oku, compile et (gerekirse cache'den), ve işle
+func (e *Engine) Render(file string, data map[string]interface{}) (string, error) {
+	return e.RenderContext(context.Background(), file, data)
+}
+
+// RenderContext: ctx iptal edilebilir render; bkz. package-level RenderContext.
+func (e *Engine) RenderContext(ctx context.Context, file string, data map[string]interface{}) (string, error) {
+	return e.render(ctx, file, "", data)
+}
+
+// RenderBlock: template içindeki <{ block "name" }> ... <{ /block }> bölümünü
+// tek başına render eder (HTMX/turbo partial cevapları için). Block, verilen
+// data ile render edilir; döngü değişkenleri gibi çevre değişkenleri yoktur.
+func RenderBlock(file, block string, data map[string]interface{}) (string, error) {
+	return defaultEngine.RenderBlock(file, block, data)
+}
+
+// RenderBlock: bkz. package-level RenderBlock.
+func (e *Engine) RenderBlock(file, block string, data map[string]interface{}) (string, error) {
+	return e.render(context.Background(), file, block, data)
+}
+
+// RenderBlockContext: RenderBlock gibi, ama ctx iptal edilebilir.
+func (e *Engine) RenderBlockContext(ctx context.Context, file, block string, data map[string]interface{}) (string, error) {
+	return e.render(ctx, file, block, data)
+}
+
+// RenderWithLayout: önce page'i, sonra layout'u render eder. Layout, data'ya
+// ek olarak page'in çıktısını "content", page'deki <{ block }>'ları da
+// "sections" değişkeninde görür (<{ sections.sidebar }>,
+// <{ yield "sidebar" }>); block'lar content'ten çıkarılır. Page'in set
+// ettiği değişkenler (<{ set title = "..." }>) layout'ta da tanımlıdır.
+func (e *Engine) RenderWithLayout(page, layout string, data map[string]interface{}) (string, error) {
+	return e.RenderWithLayoutContext(context.Background(), page, layout, data)
+}
+
+// RenderWithLayoutContext: RenderWithLayout gibi, ama ctx iptal edilebilir.
+// Limits ikisinin toplamına uygulanır.
+func (e *Engine) RenderWithLayoutContext(ctx context.Context, page, layout string, data map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	ctx, unlock := e.lockRender(ctx)
+	defer unlock()
+	locale := ""
+	if e.I18n.InlineTranslations {
+		locale = e.localeOf(ctx, data)
+	}
+	pageTpl, err := e.load(e.resolve(page), locale, nil)
 	if err != nil {
-		abs = file
+		return "", err
 	}
+	layoutTpl, err := e.load(e.resolve(layout), locale, nil)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := e.renderContext(ctx)
+	defer cancel()
 
-	tpl, err := getOrCompile(abs)
+	st := e.newState(ctx, locale, data)
+	st.captureBlocks = true
+	content, err := st.run(pageTpl.Nodes, pageTpl.size)
 	if err != nil {
 		return "", err
 	}
 
+	layoutData := make(map[string]interface{}, len(data)+2)
+	for k, v := range data {
+		layoutData[k] = v
+	}
+	layoutData["content"] = content
+	layoutData["sections"] = st.sections
+	st.captureBlocks = false
+	st.data = layoutData
+	st.globals = (&scope{vars: layoutData}).child(st.globals.vars)
+	out, err := st.run(layoutTpl.Nodes, layoutTpl.size+len(content))
+	if err != nil {
+		return "", err
+	}
+	return e.Output.encodeOutput(out)
+}
+
+// RenderSource: src'yi Root'taki name dosyasıymış gibi compile edip render
+// eder (relative include'lar oradan çözülür); sonuç cache'lenmez.
+func (e *Engine) RenderSource(ctx context.Context, name, src string, data map[string]interface{}) (string, error) {
+	ctx, unlock := e.lockRender(ctx)
+	defer unlock()
+	tpl, err := e.compile(e.resolve(name), src, "", nil)
+	if err != nil {
+		return "", err
+	}
+	return e.execute(ctx, tpl.Nodes, tpl.size, "", data)
+}
+
+// Compile: template'i render etmeden compile edip cache'e koyar; syntax
+// hataları ilk render'dan önce görülür.
+func (e *Engine) Compile(file string) error {
+	_, err := e.load(e.resolve(file), "", nil)
+	return err
+}
+
+// render: block boş değilse sadece o block'u render eder.
+func (e *Engine) render(ctx context.Context, file, block string, data map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	ctx, unlock := e.lockRender(ctx)
+	defer unlock()
+	locale := ""
+	if e.I18n.InlineTranslations {
+		locale = e.localeOf(ctx, data)
+	}
+	tpl, err := e.load(e.resolve(file), locale, nil)
+	if err != nil {
+		return "", err
+	}
+	nodes := tpl.Nodes
+	if block != "" {
+		b := findBlock(nodes, block)
+		if b == nil {
+			return "", fmt.Errorf("vingo: block %q not found in %s", block, file)
+		}
+		nodes = b.Body
+	}
+	return e.execute(ctx, nodes, tpl.size, locale, data)
+}
+
+// execute: node'ları data ile işler; size: çıktı buffer'ının ilk boyu,
+// locale: node'ların compile edildiği locale ("" = yok).
+func (e *Engine) execute(ctx context.Context, nodes []Node, size int, locale string, data map[string]interface{}) (string, error) {
+	ctx, unlock := e.lockRender(ctx)
+	defer unlock()
+	ctx, cancel := e.renderContext(ctx)
+	defer cancel()
+
 	// Evaluate
-	out := &strings.Builder{}
-	for _, n := range tpl.Nodes {
-		out.WriteString(n.Eval(data))
+	out, err := e.newState(ctx, locale, data).run(nodes, size)
+	if err != nil {
+		return "", err
+	}
+	return e.Output.encodeOutput(out)
+}
+
+// renderContext: MaxRenderTime sınırı eklenmiş ctx.
+func (e *Engine) renderContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := e.Limits.MaxRenderTime; d > 0 {
+		return context.WithTimeoutCause(ctx, d, &LimitError{Limit: "MaxRenderTime", Max: int64(d)})
+	}
+	return ctx, func() {}
+}
+
+// newState: data'nın bir render'ının state'i.
+func (e *Engine) newState(ctx context.Context, locale string, data map[string]interface{}) *renderState {
+	st := &renderState{ctx: ctx, engine: e, data: data, locale: locale}
+	st.globals = (&scope{vars: data}).child(nil)
+	return st
+}
+
+// run: node'ların OutputOptions uygulanmadan önceki çıktısı; size: çıktı
+// buffer'ının ilk boyu.
+func (s *renderState) run(nodes []Node, size int) (string, error) {
+	out := getBuffer(size)
+	defer putBuffer(out)
+	s.eval(nodes, out)
+	if s.err != nil {
+		return "", s.err
 	}
 	return out.String(), nil
 }
 
+// sandboxed: template'ler Root içinde tutuluyor mu; bkz. AllowOutsideRoot.
+func (e *Engine) sandboxed() bool {
+	return e.Root != "" && !e.AllowOutsideRoot
+}
+
+// inRoot: path (resolve edilmiş) Root'un içinde mi; sadece path'e bakar,
+// Root içindeki symlink'ler takip edilmez.
+func (e *Engine) inRoot(path string) bool {
+	rel, err := filepath.Rel(e.resolve(""), path)
+	return err == nil && !filepath.IsAbs(rel) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve: template ismini Root'a göre mutlak path'e çevirir.
+func (e *Engine) resolve(file string) string {
+	if e.Root != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(e.Root, file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	return abs
+}
+
 // getOrCompile: cache kontrolü + compile
-func getOrCompile(path string) (*Template, error) {
-	stat, err := os.Stat(path)
+func (e *Engine) getOrCompile(path string) (*Template, error) {
+	return e.load(path, "", nil)
+}
+
+// load: getOrCompile; locale: çevirileri gömülecek locale ("" = yok),
+// stack: include'larını inline ederken compile edilmekte olan üst template'ler.
+func (e *Engine) load(path, locale string, stack []string) (*Template, error) {
+	if e.sandboxed() && !e.inRoot(path) {
+		return nil, fmt.Errorf("%s: outside of the template root", path)
+	}
+	loader := e.loader()
+	key := cacheKey{loader: loader, path: path, locale: locale}
+	mod, err := loader.ModTime(path)
 	if err != nil {
 		return nil, err
 	}
-	mod := stat.ModTime()
 
-	cacheMutex.RLock()
-	tpl, exists := tplCache[path]
-	cacheMutex.RUnlock()
+	e.mu.RLock()
+	tpl, exists := e.cache.get(key)
+	e.mu.RUnlock()
 
-	if exists && tpl.ModTime.Equal(mod) {
+	if exists && tpl.ModTime.Equal(mod) && tpl.depsFresh(loader) {
+		e.cache.hits.Add(1)
 		return tpl, nil
 	}
+	e.cache.misses.Add(1)
 
 	// compile
-	b, err := os.ReadFile(path)
+	b, err := loader.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	content := string(b)
-
-	tokens := tokenize(content)
-	nodes, err := compileTokens(tokens)
+	newTpl, err := e.compile(path, string(b), locale, stack)
 	if err != nil {
 		return nil, err
 	}
+	newTpl.ModTime = mod
 
-	newTpl := &Template{
-		Filepath: path,
-		Nodes:    nodes,
-		ModTime:  mod,
-	}
-
-	cacheMutex.Lock()
-	tplCache[path] = newTpl
-	cacheMutex.Unlock()
+	e.mu.Lock()
+	e.cache.put(key, newTpl, e.CacheLimits)
+	e.watchDirLocked(filepath.Dir(path))
+	e.mu.Unlock()
 
 	return newTpl, nil
 }
+
+// compile: path'teki template'i kaynağından compile eder, cache'e koymaz;
+// relative include'lar path'in klasörüne göre çözülür. locale verilirse
+// çeviriler gömülür (bkz. I18nOptions.InlineTranslations). Markdown
+// dosyaları parse edilmez, render edilir.
+func (e *Engine) compile(path, content, locale string, stack []string) (*Template, error) {
+	if isMarkdown(path) {
+		out, err := e.markdown().RenderMarkdown([]byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: markdown: %w", path, err)
+		}
+		return &Template{Filepath: path, Nodes: []Node{&TextNode{Text: string(out)}}, size: len(out)}, nil
+	}
+	tokens, err := tokenize(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if e.Strict {
+		if err := unknownTag(tokens); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	nodes, err := compileTokens(tokens)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	nodes, tests := splitTests(nodes)
+	nodes, decls := splitDecls(nodes)
+	setTemplate(nodes, path)
+	nodes = e.fold(nodes, locale)
+	deps := e.linkIncludes(path, nodes, locale, append(stack, path))
+	return &Template{
+		Filepath: path,
+		Nodes:    nodes,
+		size:     len(content),
+		deps:     deps,
+		tests:    tests,
+		decls:    decls,
+	}, nil
+}
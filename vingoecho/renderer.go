@@ -0,0 +1,57 @@
+// Package vingoecho: Echo (github.com/labstack/echo) integration for vingo.
+//
+//	e := echo.New()
+//	e.Renderer = &vingoecho.Renderer{Engine: engine, Layout: "layout.vgo"}
+//	...
+//	return c.Render(http.StatusOK, "profile.vgo", data)
+package vingoecho
+
+import (
+	"context"
+	"io"
+
+	"github.com/coderiantest/vingo"
+	"github.com/labstack/echo/v4"
+)
+
+// Renderer implements echo.Renderer on top of a vingo Engine, so every Echo
+// handler shares the engine's compiled template cache.
+type Renderer struct {
+	Engine *vingo.Engine // required
+
+	// Layout, when set, is rendered after the page with the page output
+	// available as the "content" variable.
+	Layout string
+}
+
+var _ echo.Renderer = (*Renderer)(nil)
+
+// Render: echo.Renderer. The render is cancelled with the request context.
+func (r *Renderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	m, err := vingo.DataMap(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if c != nil && c.Request() != nil {
+		ctx = c.Request().Context()
+	}
+
+	out, err := r.Engine.RenderContext(ctx, name, m)
+	if err != nil {
+		return err
+	}
+	if r.Layout != "" {
+		layoutData := make(map[string]interface{}, len(m)+1)
+		for k, v := range m {
+			layoutData[k] = v
+		}
+		layoutData["content"] = out
+		if out, err = r.Engine.RenderContext(ctx, r.Layout, layoutData); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
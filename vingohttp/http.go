@@ -0,0 +1,230 @@
+// Package vingohttp: net/http integration of vingo, a renderer writing
+// templates into responses with the request and session exposed to them,
+// and the template playground.
+//
+//	r := &vingohttp.Renderer{Engine: engine, ErrorTemplate: "errors/500.vgo"}
+//	r.RenderRequest(w, req, http.StatusOK, "profile.vgo", data)
+package vingohttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coderiantest/vingo"
+)
+
+// Renderer: renders templates into http responses. Output is buffered
+// completely before anything is written, so a failing render never leaves a
+// half-written 200 response behind.
+type Renderer struct {
+	Engine *vingo.Engine // nil uses the package-level engine
+
+	// ContentType defaults to "text/html; charset=<Output.Charset or utf-8>".
+	ContentType string
+
+	// ErrorTemplate is rendered with status 500 when a render fails. It gets
+	// "error", "status" and the original "data". Empty sends a plain 500.
+	ErrorTemplate string
+
+	// Request, when set, exposes the current request to templates rendered
+	// by RenderRequest as the "request" variable.
+	Request *RequestVars
+
+	// Session, when set, exposes allowlisted session values to templates
+	// rendered by RenderRequest as the "session" variable.
+	Session *SessionVars
+}
+
+// RequestVars: the parts of a request templates may read. request.path,
+// request.method and request.host are always set; query parameters,
+// headers and cookies only when allowlisted:
+//
+//	<{ if request.path == "/" }>...<{ /if }>
+//	<{ request.query.page }> <{ request.headers.hx_request }> <{ request.cookies.theme }>
+//
+// Header names are lowercased with "-" replaced by "_" ("HX-Request" ->
+// hx_request). Missing values are undefined, multi-valued ones give the first.
+type RequestVars struct {
+	Query   []string // query parameter names
+	Headers []string // header names
+	Cookies []string // cookie names
+}
+
+// SessionVars: the session values templates may read, as session.<key>.
+type SessionVars struct {
+	// Load returns the session of r, usually from the session store of the
+	// application; nil means no session.
+	Load func(r *http.Request) (map[string]interface{}, error)
+
+	// Keys: the allowlisted keys; other session values are not visible.
+	Keys []string
+}
+
+// Render: renders name with data and writes it with the given status.
+// The returned error is the render error, even if the error page was sent.
+func (h *Renderer) Render(w http.ResponseWriter, status int, name string, data any) error {
+	return h.RenderContext(context.Background(), w, status, name, data)
+}
+
+// RenderRequest: like Render, but the render is cancelled together with r,
+// and the request and session variables are added to data (see Request,
+// Session). Variables of the same name in data take precedence.
+func (h *Renderer) RenderRequest(w http.ResponseWriter, r *http.Request, status int, name string, data any) error {
+	if h.Request == nil && h.Session == nil {
+		return h.RenderContext(r.Context(), w, status, name, data)
+	}
+	m, err := vingo.DataMap(data)
+	if err == nil {
+		m, err = h.requestData(r, m)
+	}
+	if err != nil {
+		h.Error(w, err, data)
+		return err
+	}
+	return h.RenderContext(r.Context(), w, status, name, m)
+}
+
+// requestData: copy of data with the request and session variables.
+func (h *Renderer) requestData(r *http.Request, data map[string]interface{}) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(data)+2)
+	if h.Request != nil {
+		m["request"] = h.Request.vars(r)
+	}
+	if h.Session != nil {
+		vars, err := h.Session.vars(r)
+		if err != nil {
+			return nil, fmt.Errorf("vingohttp: session: %w", err)
+		}
+		m["session"] = vars
+	}
+	for k, v := range data {
+		m[k] = v
+	}
+	return m, nil
+}
+
+// vars: the "request" variable for r.
+func (rv *RequestVars) vars(r *http.Request) map[string]interface{} {
+	vars := map[string]interface{}{
+		"path":   r.URL.Path,
+		"method": r.Method,
+		"host":   r.Host,
+	}
+	if len(rv.Query) > 0 {
+		query := r.URL.Query()
+		m := map[string]interface{}{}
+		for _, name := range rv.Query {
+			if vs, ok := query[name]; ok && len(vs) > 0 {
+				m[name] = vs[0]
+			}
+		}
+		vars["query"] = m
+	}
+	if len(rv.Headers) > 0 {
+		m := map[string]interface{}{}
+		for _, name := range rv.Headers {
+			if vs := r.Header.Values(name); len(vs) > 0 {
+				m[strings.ReplaceAll(strings.ToLower(name), "-", "_")] = vs[0]
+			}
+		}
+		vars["headers"] = m
+	}
+	if len(rv.Cookies) > 0 {
+		m := map[string]interface{}{}
+		for _, name := range rv.Cookies {
+			if c, err := r.Cookie(name); err == nil {
+				m[name] = c.Value
+			}
+		}
+		vars["cookies"] = m
+	}
+	return vars
+}
+
+// vars: the "session" variable for r, only the allowlisted keys.
+func (sv *SessionVars) vars(r *http.Request) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	if sv.Load == nil {
+		return vars, nil
+	}
+	session, err := sv.Load(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range sv.Keys {
+		if v, ok := session[k]; ok {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
+
+// RenderContext: like Render with a cancellable ctx.
+func (h *Renderer) RenderContext(ctx context.Context, w http.ResponseWriter, status int, name string, data any) error {
+	m, err := vingo.DataMap(data)
+	if err == nil {
+		var out string
+		out, err = h.engine().RenderContext(ctx, name, m)
+		if err == nil {
+			h.write(w, status, out)
+			return nil
+		}
+	}
+	h.Error(w, err, data)
+	return err
+}
+
+// Error: sends the error page (or a plain 500) for err.
+func (h *Renderer) Error(w http.ResponseWriter, err error, data any) {
+	if h.ErrorTemplate != "" {
+		out, rerr := h.engine().Render(h.ErrorTemplate, map[string]interface{}{
+			"error":  err.Error(),
+			"status": http.StatusInternalServerError,
+			"data":   data,
+		})
+		if rerr == nil {
+			h.write(w, http.StatusInternalServerError, out)
+			return
+		}
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// Middleware: recovers panics raised by next and answers them with the error
+// page, instead of dropping the connection.
+func (h *Renderer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				h.Error(w, fmt.Errorf("panic: %v", v), nil)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Renderer) engine() *vingo.Engine {
+	if h.Engine != nil {
+		return h.Engine
+	}
+	return vingo.Default()
+}
+
+func (h *Renderer) write(w http.ResponseWriter, status int, out string) {
+	ct := h.ContentType
+	if ct == "" {
+		charset := h.engine().Output.Charset
+		if charset == "" {
+			charset = "utf-8"
+		}
+		ct = "text/html; charset=" + charset
+	}
+	w.Header().Set("Content-Type", ct)
+	w.WriteHeader(status)
+	w.Write([]byte(out))
+}
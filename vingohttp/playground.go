@@ -0,0 +1,157 @@
+package vingohttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coderiantest/vingo"
+)
+
+// -------------------- Playground --------------------
+//
+//	mux.Handle("/playground/", http.StripPrefix("/playground", vingohttp.Playground(engine)))
+//
+// serves a page with a template editor, a data editor (JSON, YAML or TOML)
+// and a preview rendered live by the engine of the application, so its
+// functions, constants and templates (for includes) are available while
+// prototyping. The template is compiled as "playground.vgo" in Root;
+// relative includes are resolved from there.
+//
+// Templates typed into the page run with everything the engine can do:
+// mount the playground in development or behind authentication only.
+
+// playgroundMaxBody: size limit of a render request.
+const playgroundMaxBody = 1 << 20
+
+// Playground: http.Handler of the playground page of e (nil: the default
+// engine). GET serves the page, POST renders the template it sends.
+func Playground(e *vingo.Engine) http.Handler {
+	if e == nil {
+		e = vingo.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(playgroundPage))
+		case http.MethodPost:
+			playgroundRender(e, w, r)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// playgroundRequest: body of a render request.
+type playgroundRequest struct {
+	Template string `json:"template"`
+	Data     string `json:"data"`
+	Format   string `json:"format"` // data format, "" = json
+}
+
+// playgroundResponse: rendered output, or the error of the render.
+type playgroundResponse struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// playgroundRender: renders the template of a render request. Template and
+// data errors are answered with status 200, they are the output of the
+// playground.
+func playgroundRender(e *vingo.Engine, w http.ResponseWriter, r *http.Request) {
+	var req playgroundRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, playgroundMaxBody)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resp playgroundResponse
+	out, err := req.render(r.Context(), e)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Output = out
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// render: the template of req rendered with its data.
+func (req playgroundRequest) render(ctx context.Context, e *vingo.Engine) (string, error) {
+	data := map[string]interface{}{}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	if req.Data != "" {
+		var err error
+		if data, err = vingo.DecodeData([]byte(req.Data), req.Format); err != nil {
+			return "", err
+		}
+	}
+	return e.RenderSource(ctx, "playground.vgo", req.Template, data)
+}
+
+// playgroundPage: the page of the playground. The preview is a sandboxed
+// iframe, scripts in the output don't run.
+const playgroundPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>vingo playground</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; height: 100vh; display: grid; grid-template-columns: 1fr 1fr; grid-template-rows: auto 2fr 1fr; font: 14px system-ui, sans-serif; }
+header { grid-column: 1 / 3; display: flex; gap: 1em; align-items: center; padding: .5em 1em; background: #222; color: #eee; }
+header h1 { font-size: 1em; margin: 0; }
+section { display: flex; flex-direction: column; min-height: 0; border: 1px solid #ddd; }
+section h2 { font-size: .85em; margin: 0; padding: .3em .6em; background: #f3f3f3; display: flex; justify-content: space-between; }
+textarea { flex: 1; border: 0; padding: .6em; resize: none; font: 13px ui-monospace, monospace; tab-size: 2; outline: none; }
+#preview { grid-column: 2; grid-row: 2 / 4; }
+iframe { flex: 1; border: 0; background: #fff; }
+#error { margin: 0; padding: .6em; color: #b00; white-space: pre-wrap; font: 13px ui-monospace, monospace; }
+#error:empty { display: none; }
+</style>
+</head>
+<body>
+<header><h1>vingo playground</h1><label><input type="checkbox" id="source"> show source</label></header>
+<section><h2>Template</h2><textarea id="template" spellcheck="false"><h1>Hello, <{ name }>!</h1>
+<ul>
+<{ for item in items }>  <li><{ item }></li>
+<{ /for }></ul>
+</textarea></section>
+<section id="preview"><h2>Preview</h2><pre id="error"></pre><iframe id="output" sandbox></iframe><textarea id="raw" readonly hidden></textarea></section>
+<section><h2>Data <select id="format"><option>json</option><option>yaml</option><option>toml</option></select></h2><textarea id="data" spellcheck="false">{"name": "world", "items": ["one", "two"]}</textarea></section>
+<script>
+var $ = function (id) { return document.getElementById(id) };
+var timer, seq = 0;
+function render() {
+  var n = ++seq;
+  fetch(location.pathname, {
+    method: "POST",
+    headers: {"Content-Type": "application/json"},
+    body: JSON.stringify({template: $("template").value, data: $("data").value, format: $("format").value})
+  }).then(function (r) {
+    if (!r.ok) return r.text().then(function (t) { return {error: t} });
+    return r.json();
+  }).then(function (res) {
+    if (n !== seq) return;
+    $("error").textContent = res.error || "";
+    if (!res.error) {
+      $("output").srcdoc = res.output || "";
+      $("raw").value = res.output || "";
+    }
+  });
+}
+function schedule() { clearTimeout(timer); timer = setTimeout(render, 250) }
+["template", "data", "format"].forEach(function (id) { $(id).addEventListener("input", schedule) });
+$("source").addEventListener("change", function () {
+  $("output").hidden = this.checked;
+  $("raw").hidden = !this.checked;
+});
+render();
+</script>
+</body>
+</html>
+`
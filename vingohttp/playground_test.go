@@ -0,0 +1,42 @@
+package vingohttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/coderiantest/vingo"
+)
+
+func TestPlayground(t *testing.T) {
+	h := Playground(vingo.New())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("GET = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		body   string
+		output string
+		err    string
+	}{
+		{`{"template": "<{ name | upper }>", "data": "name: ayşe", "format": "yaml"}`, "AYŞE", ""},
+		{`{"template": "<{ if x }>"}`, "", "if"},
+		{`{"template": "x", "data": "{"}`, "", "json"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+		var resp playgroundResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("POST %s: %v", tt.body, err)
+		}
+		if resp.Output != tt.output || !strings.Contains(resp.Error, tt.err) || (tt.err == "") != (resp.Error == "") {
+			t.Errorf("POST %s = %+v, want output %q, error containing %q", tt.body, resp, tt.output, tt.err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package vingoredis: Redis FragmentStore for vingo, so every instance
+// behind a load balancer serves the same cached <{ cache }> fragments.
+//
+//	engine.Fragments = vingoredis.New("localhost:6379")
+//
+// The store speaks the Redis protocol itself and only uses GET, SET and
+// PTTL, so it needs no client library and works with any Redis compatible
+// server (Valkey, KeyDB, Dragonfly).
+package vingoredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coderiantest/vingo"
+)
+
+// Store implements vingo.FragmentStore on a Redis server. Connections are
+// dialed on demand and reused; a Store is safe for concurrent use.
+type Store struct {
+	Addr     string // host:port
+	Password string // sent with AUTH when set
+	DB       int    // selected after connecting when not 0
+	Prefix   string // prepended to every fragment key
+
+	// MaxIdle: idle connections kept for reuse (0 = 8).
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle []*conn
+	dial func(ctx context.Context) (net.Conn, error) // nil: TCP to Addr
+}
+
+var _ vingo.FragmentStore = (*Store)(nil)
+
+// New: store on the server at addr, with keys prefixed by "vingo:".
+func New(addr string) *Store {
+	return &Store{Addr: addr, Prefix: "vingo:"}
+}
+
+func (s *Store) Get(ctx context.Context, key string) (string, bool, error) {
+	r, err := s.do(ctx, "GET", s.Prefix+key)
+	if err != nil {
+		return "", false, err
+	}
+	return r.str, !r.null, nil
+}
+
+func (s *Store) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []string{"SET", s.Prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	_, err := s.do(ctx, args...)
+	return err
+}
+
+func (s *Store) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	r, err := s.do(ctx, "PTTL", s.Prefix+key)
+	if err != nil {
+		return 0, false, err
+	}
+	switch {
+	case r.n == -2: // missing
+		return 0, false, nil
+	case r.n < 0: // no expiry
+		return 0, true, nil
+	}
+	return time.Duration(r.n) * time.Millisecond, true, nil
+}
+
+// Close: closes the idle connections.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	idle := s.idle
+	s.idle = nil
+	s.mu.Unlock()
+	for _, c := range idle {
+		c.Close()
+	}
+	return nil
+}
+
+// do: runs one command on a pooled connection. Connections that failed
+// are closed instead of going back to the pool.
+func (s *Store) do(ctx context.Context, args ...string) (reply, error) {
+	c, err := s.get(ctx)
+	if err != nil {
+		return reply{}, err
+	}
+	r, err := c.do(ctx, args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.Close()
+		return reply{}, err
+	}
+	s.put(c)
+	return r, err
+}
+
+func (s *Store) get(ctx context.Context) (*conn, error) {
+	s.mu.Lock()
+	if n := len(s.idle); n > 0 {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.mu.Unlock()
+		return c, nil
+	}
+	s.mu.Unlock()
+
+	dial := s.dial
+	if dial == nil {
+		dial = func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", s.Addr)
+		}
+	}
+	nc, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if s.Password != "" {
+		if _, err := c.do(ctx, "AUTH", s.Password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if s.DB != 0 {
+		if _, err := c.do(ctx, "SELECT", strconv.Itoa(s.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s *Store) put(c *conn) {
+	maxIdle := s.MaxIdle
+	if maxIdle <= 0 {
+		maxIdle = 8
+	}
+	s.mu.Lock()
+	if len(s.idle) < maxIdle {
+		s.idle = append(s.idle, c)
+		c = nil
+	}
+	s.mu.Unlock()
+	if c != nil {
+		c.Close()
+	}
+}
+
+// -------------------- Protocol --------------------
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// reply: a bulk or simple string in str (null: nil bulk string), or an
+// integer in n.
+type reply struct {
+	str  string
+	n    int64
+	null bool
+}
+
+// redisError: error reply of the server; the connection stays usable.
+type redisError string
+
+func (e redisError) Error() string { return "vingoredis: " + string(e) }
+
+func (c *conn) do(ctx context.Context, args ...string) (reply, error) {
+	deadline, _ := ctx.Deadline() // zero: none
+	c.SetDeadline(deadline)
+
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := c.w.Flush(); err != nil {
+		return reply{}, err
+	}
+	return c.read()
+}
+
+func (c *conn) read() (reply, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return reply{}, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return reply{}, fmt.Errorf("vingoredis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return reply{str: body}, nil
+	case '-':
+		return reply{}, redisError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		return reply{n: n}, err
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return reply{}, err
+		}
+		if n < 0 {
+			return reply{null: true}, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return reply{}, err
+		}
+		return reply{str: string(buf[:n])}, nil
+	}
+	return reply{}, fmt.Errorf("vingoredis: unexpected reply %q", line)
+}
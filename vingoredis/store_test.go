@@ -0,0 +1,288 @@
+package vingoredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis: an in-memory server answering GET, SET, PTTL, AUTH and SELECT
+// over net.Pipe connections.
+type fakeRedis struct {
+	password string
+
+	mu     sync.Mutex
+	values map[string]string
+	expiry map[string]time.Time
+	cmds   []string // commands received, arguments joined by spaces
+	dials  int
+	hangup bool // close the connection instead of answering the next command
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}, expiry: map[string]time.Time{}}
+}
+
+// store: a Store connected to f.
+func (f *fakeRedis) store() *Store {
+	s := New("fake")
+	s.dial = func(ctx context.Context) (net.Conn, error) {
+		client, server := net.Pipe()
+		f.mu.Lock()
+		f.dials++
+		f.mu.Unlock()
+		go f.serve(server)
+		return client, nil
+	}
+	return s
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.cmds = append(f.cmds, strings.Join(args, " "))
+		hangup := f.hangup
+		f.hangup = false
+		out := f.reply(args, &authed)
+		f.mu.Unlock()
+		if hangup {
+			return
+		}
+		if _, err := io.WriteString(c, out); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand: a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) reply(args []string, authed *bool) string {
+	cmd, key := strings.ToUpper(args[0]), ""
+	if len(args) > 1 {
+		key = args[1]
+	}
+	if cmd == "AUTH" {
+		if key != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		*authed = true
+		return "+OK\r\n"
+	}
+	if !*authed {
+		return "-NOAUTH Authentication required.\r\n"
+	}
+	if exp, ok := f.expiry[key]; ok && !time.Now().Before(exp) {
+		delete(f.values, key)
+		delete(f.expiry, key)
+	}
+	switch cmd {
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := f.values[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		f.values[key] = args[2]
+		delete(f.expiry, key)
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			f.expiry[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return "+OK\r\n"
+	case "PTTL":
+		if _, ok := f.values[key]; !ok {
+			return ":-2\r\n"
+		}
+		exp, ok := f.expiry[key]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(exp).Milliseconds())
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func TestStore(t *testing.T) {
+	f := newFakeRedis()
+	s := f.store()
+	defer s.Close()
+	ctx := context.Background()
+
+	if v, ok, err := s.Get(ctx, "missing"); v != "" || ok || err != nil {
+		t.Errorf("Get missing = %q, %v, %v; want \"\", false, nil", v, ok, err)
+	}
+	if d, ok, err := s.TTL(ctx, "missing"); d != 0 || ok || err != nil {
+		t.Errorf("TTL missing = %v, %v, %v; want 0, false, nil", d, ok, err)
+	}
+
+	if err := s.Set(ctx, "a", "<b>fragment</b>\r\n", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := s.Get(ctx, "a"); v != "<b>fragment</b>\r\n" || !ok || err != nil {
+		t.Errorf("Get = %q, %v, %v", v, ok, err)
+	}
+	if d, ok, err := s.TTL(ctx, "a"); d <= 59*time.Second || d > time.Minute || !ok || err != nil {
+		t.Errorf("TTL = %v, %v, %v; want about 1m", d, ok, err)
+	}
+	if err := s.Set(ctx, "forever", "x", 0); err != nil {
+		t.Fatal(err)
+	}
+	if d, ok, err := s.TTL(ctx, "forever"); d != 0 || !ok || err != nil {
+		t.Errorf("TTL without expiry = %v, %v, %v; want 0, true, nil", d, ok, err)
+	}
+
+	if err := s.Set(ctx, "short", "x", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok, err := s.Get(ctx, "short"); ok || err != nil {
+		t.Errorf("Get expired = %v, %v; want false, nil", ok, err)
+	}
+
+	want := []string{"GET vingo:missing", "PTTL vingo:missing", "SET vingo:a <b>fragment</b>\r\n PX 60000"}
+	for i, cmd := range want {
+		if f.cmds[i] != cmd {
+			t.Errorf("command %d = %q, want %q", i, f.cmds[i], cmd)
+		}
+	}
+	if f.dials != 1 {
+		t.Errorf("%d connections dialed, want 1 reused", f.dials)
+	}
+}
+
+func TestStoreAuth(t *testing.T) {
+	f := newFakeRedis()
+	f.password = "secret"
+	s := f.store()
+	s.Password = "wrong"
+	s.DB = 2
+	ctx := context.Background()
+
+	_, _, err := s.Get(ctx, "a")
+	var rerr redisError
+	if !errors.As(err, &rerr) || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Get with a wrong password: %v, want WRONGPASS", err)
+	}
+	if len(s.idle) != 0 {
+		t.Errorf("%d idle connections after a failed AUTH, want 0", len(s.idle))
+	}
+
+	s.Password = "secret"
+	if err := s.Set(ctx, "a", "x", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.cmds[len(f.cmds)-3:]; got[0] != "AUTH secret" || got[1] != "SELECT 2" {
+		t.Errorf("commands of a new connection = %q, want AUTH and SELECT first", got)
+	}
+	s.Close()
+}
+
+func TestStoreBrokenConnection(t *testing.T) {
+	f := newFakeRedis()
+	s := f.store()
+	defer s.Close()
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "a", "x", 0); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	f.hangup = true
+	f.mu.Unlock()
+	if _, _, err := s.Get(ctx, "a"); err == nil {
+		t.Fatal("Get on a closed connection: no error")
+	}
+	if len(s.idle) != 0 {
+		t.Errorf("%d idle connections after a broken one, want 0", len(s.idle))
+	}
+	if v, ok, err := s.Get(ctx, "a"); v != "x" || !ok || err != nil {
+		t.Errorf("Get after reconnecting = %q, %v, %v", v, ok, err)
+	}
+	if f.dials != 2 {
+		t.Errorf("%d connections dialed, want 2", f.dials)
+	}
+}
+
+func TestStoreErrorReply(t *testing.T) {
+	f := newFakeRedis()
+	s := f.store()
+	defer s.Close()
+	ctx := context.Background()
+
+	// an error reply leaves the connection usable
+	if _, err := s.do(ctx, "NOPE"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("unknown command: %v", err)
+	}
+	if len(s.idle) != 1 {
+		t.Errorf("%d idle connections after an error reply, want 1", len(s.idle))
+	}
+	if _, _, err := s.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if f.dials != 1 {
+		t.Errorf("%d connections dialed, want 1", f.dials)
+	}
+}
+
+func TestStoreDeadline(t *testing.T) {
+	s := New("fake")
+	s.dial = func(ctx context.Context) (net.Conn, error) {
+		client, server := net.Pipe()
+		go io.Copy(io.Discard, server) // reads, never answers
+		return client, nil
+	}
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.Get(ctx, "a"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Get past the deadline: %v, want a timeout", err)
+	}
+	if len(s.idle) != 0 {
+		t.Errorf("%d idle connections after a timeout, want 0", len(s.idle))
+	}
+}
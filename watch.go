@@ -0,0 +1,89 @@
+package vingo
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// -------------------- Hot reload --------------------
+
+// Watch: watches the directories of cached templates and drops a template
+// from the cache as soon as its file changes, then calls OnChange.
+// Blocks until ctx is cancelled, so it is usually started as `go e.Watch(ctx)`.
+// Templates compiled after Watch started are picked up automatically.
+// Errors of the watcher don't stop it: they go to OnWatchError, or to the
+// log if it is nil.
+func (e *Engine) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	e.mu.Lock()
+	e.watcher = w
+	e.watched = map[string]bool{}
+	for _, path := range e.cache.paths() {
+		e.watchDirLocked(filepath.Dir(path))
+	}
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.watcher = nil
+		e.watched = nil
+		e.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+				continue
+			}
+			path := filepath.Clean(ev.Name)
+			if e.invalidate(path) && e.OnChange != nil {
+				e.OnChange(path)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			if e.OnWatchError != nil {
+				e.OnWatchError(err)
+			} else {
+				log.Printf("vingo: watch: %v", err)
+			}
+		}
+	}
+}
+
+// invalidate: removes the cache entry for path and the entries that inlined
+// it, reports whether there was one.
+func (e *Engine) invalidate(path string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cache.removeIf(func(p string, tpl *Template) bool {
+		_, inlined := tpl.deps[path]
+		return p == path || inlined
+	})
+}
+
+// watchDirLocked: registers dir with the active watcher. Caller holds e.mu.
+// Directories are watched instead of files because editors save via rename.
+func (e *Engine) watchDirLocked(dir string) {
+	if e.watcher == nil || e.watched[dir] {
+		return
+	}
+	if err := e.watcher.Add(dir); err == nil {
+		e.watched[dir] = true
+	}
+}